@@ -32,6 +32,7 @@ func ConnectDB() {
 	DB.AutoMigrate(
 		&model.Account{},
 		&model.Staff{},
+		&model.MembershipTier{},
 		&model.Customer{},
 		&model.Cinema{},
 		&model.Format{},
@@ -43,17 +44,43 @@ func ConnectDB() {
 		&model.MovieTrailer{},
 		&model.Room{},
 		&model.Holiday{},
+		&model.SpecialEvent{},
+		&model.Distributor{},
+		&model.FilmContract{},
 		&model.ScheduleTemplate{},
+		&model.ScheduleTemplateItem{},
 		&model.Showtime{},
 		&model.Ticket{},
+		&model.TicketCategory{},
+		&model.AgeCheckLog{},
+		&model.ManualCheckinLog{},
 		&model.SeatType{},
 		&model.Seat{},
 		&model.Promotion{},
 		&model.ShowtimeSeat{},
 		&model.Order{},
+		&model.OrderPayment{},
+		&model.CashDrawerSession{},
+		&model.CashMovement{},
+		&model.CompPolicy{},
+		&model.CompTicketRequest{},
+		&model.ConcessionProduct{},
+		&model.ConcessionComboItem{},
+		&model.OrderConcession{},
+		&model.LoyaltySetting{},
+		&model.LoyaltyTransaction{},
+		&model.TierChangeLog{},
+		&model.MemberVoucher{},
+		&model.GiftCard{},
+		&model.StoredValueTransaction{},
+		&model.InventoryItem{},
+		&model.ConcessionRecipe{},
+		&model.StockMovement{},
 		&model.PromotionCondition{},
 		&model.PromotionUsage{},
 		&model.PasswordResetToken{},
+		&model.RoomTurnaround{},
+		&model.CinemaOperatingHour{},
 	)
 	fmt.Println("Database Migrated")
 
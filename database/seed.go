@@ -3,6 +3,7 @@ package database
 import (
 	"cinema_manager/constants"
 	"cinema_manager/model"
+	"cinema_manager/utils"
 	"log"
 	"time"
 
@@ -41,176 +42,82 @@ func SeedData(db *gorm.DB) {
 		{Name: "4DX"},
 		{Name: "IMAX"},
 	}
+	// Ngày lễ lặp lại hằng năm: dương lịch theo ngày/tháng của Date, âm lịch theo LunarMonth/LunarDay
+	lunarDate := func(month, day int) time.Time {
+		date, _ := utils.LunarToSolar(2025, month, day, false, time.UTC)
+		return date
+	}
 	holidays := []model.Holiday{
 		// === SOLAR (Dương lịch) ===
-		{
-			Name:        "Tết Dương lịch 2025",
-			Date:        parseDate("2025-01-01"),
-			Type:        "solar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Giỗ Tổ Hùng Vương 2025",
-			Date:        parseDate("2025-04-18"), // 10/3 Âm → 18/4/2025
-			Type:        "solar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Ngày Giải phóng miền Nam 2025",
-			Date:        parseDate("2025-04-30"),
-			Type:        "solar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Quốc tế Lao động 2025",
-			Date:        parseDate("2025-05-01"),
-			Type:        "solar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Quốc khánh 2025",
-			Date:        parseDate("2025-09-02"),
-			Type:        "solar",
-			IsRecurring: true,
-		},
-
-		// === LUNAR (Âm lịch) – Dùng ngày dương tương ứng ===
-		{
-			Name:        "30 Tết (nếu có) 2025",
-			Date:        parseDate("2025-01-27"), // 30/12/2024 Âm → 27/1/2025
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Mùng 1 Tết 2025",
-			Date:        parseDate("2025-01-28"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Mùng 2 Tết 2025",
-			Date:        parseDate("2025-01-29"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Mùng 3 Tết 2025",
-			Date:        parseDate("2025-01-30"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Rằm tháng Giêng 2025",
-			Date:        parseDate("2025-02-12"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Lễ Vu Lan 2025",
-			Date:        parseDate("2025-08-14"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Tết Trung Thu 2025",
-			Date:        parseDate("2025-09-17"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		// === 2026 ===
-		{
-			Name:        "Tết Dương lịch 2026",
-			Date:        parseDate("2026-01-01"),
-			Type:        "solar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Giỗ Tổ Hùng Vương 2026",
-			Date:        parseDate("2026-04-09"), // 10/3 Âm 2026
-			Type:        "solar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Ngày Giải phóng miền Nam 2026",
-			Date:        parseDate("2026-04-30"),
-			Type:        "solar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Quốc tế Lao động 2026",
-			Date:        parseDate("2026-05-01"),
-			Type:        "solar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Quốc khánh 2026",
-			Date:        parseDate("2026-09-02"),
-			Type:        "solar",
-			IsRecurring: true,
-		},
+		{Name: "Tết Dương lịch", Date: parseDate("2025-01-01"), Type: "solar", IsRecurring: true},
+		{Name: "Ngày Giải phóng miền Nam", Date: parseDate("2025-04-30"), Type: "solar", IsRecurring: true},
+		{Name: "Quốc tế Lao động", Date: parseDate("2025-05-01"), Type: "solar", IsRecurring: true},
+		{Name: "Quốc khánh", Date: parseDate("2025-09-02"), Type: "solar", IsRecurring: true},
 
-		// === Lunar Tết 2026 ===
-		{
-			Name:        "30 Tết 2026",
-			Date:        parseDate("2026-02-16"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Mùng 1 Tết 2026",
-			Date:        parseDate("2026-02-17"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Mùng 2 Tết 2026",
-			Date:        parseDate("2026-02-18"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Mùng 3 Tết 2026",
-			Date:        parseDate("2026-02-19"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Rằm tháng Giêng 2026",
-			Date:        parseDate("2026-03-03"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Lễ Vu Lan 2026",
-			Date:        parseDate("2026-08-18"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
-		{
-			Name:        "Tết Trung Thu 2026",
-			Date:        parseDate("2026-09-16"),
-			Type:        "lunar",
-			IsRecurring: true,
-		},
+		// === LUNAR (Âm lịch) ===
+		{Name: "Giao thừa (30 Tết)", Date: lunarDate(12, 29), Type: "lunar", IsRecurring: true, LunarMonth: 12, LunarDay: 30},
+		{Name: "Mùng 1 Tết", Date: lunarDate(1, 1), Type: "lunar", IsRecurring: true, LunarMonth: 1, LunarDay: 1},
+		{Name: "Mùng 2 Tết", Date: lunarDate(1, 2), Type: "lunar", IsRecurring: true, LunarMonth: 1, LunarDay: 2},
+		{Name: "Mùng 3 Tết", Date: lunarDate(1, 3), Type: "lunar", IsRecurring: true, LunarMonth: 1, LunarDay: 3},
+		{Name: "Rằm tháng Giêng", Date: lunarDate(1, 15), Type: "lunar", IsRecurring: true, LunarMonth: 1, LunarDay: 15},
+		{Name: "Giỗ Tổ Hùng Vương", Date: lunarDate(3, 10), Type: "lunar", IsRecurring: true, LunarMonth: 3, LunarDay: 10},
+		{Name: "Lễ Vu Lan", Date: lunarDate(7, 15), Type: "lunar", IsRecurring: true, LunarMonth: 7, LunarDay: 15},
+		{Name: "Tết Trung Thu", Date: lunarDate(8, 15), Type: "lunar", IsRecurring: true, LunarMonth: 8, LunarDay: 15},
+	}
+	// Bản seed cũ ghi ngày dương cố định theo từng năm (Giỗ Tổ còn bị lưu là dương lịch) → xóa để không lặp sai
+	legacyHolidays := []string{}
+	for _, year := range []string{"2025", "2026"} {
+		for _, name := range []string{"Tết Dương lịch", "Giỗ Tổ Hùng Vương", "Ngày Giải phóng miền Nam", "Quốc tế Lao động",
+			"Quốc khánh", "30 Tết (nếu có)", "30 Tết", "Mùng 1 Tết", "Mùng 2 Tết", "Mùng 3 Tết", "Rằm tháng Giêng",
+			"Lễ Vu Lan", "Tết Trung Thu"} {
+			legacyHolidays = append(legacyHolidays, name+" "+year)
+		}
 	}
+	db.Where("name IN ?", legacyHolidays).Delete(&model.Holiday{})
 	for _, f := range formats {
 		db.FirstOrCreate(&f, model.Format{Name: f.Name})
 	}
 	for _, h := range holidays {
 		var exists model.Holiday
 		result := db.
-			Where("name = ? AND date = ? AND type = ?", h.Name, h.Date.Format("2006-01-02"), h.Type).
+			Where("name = ? AND type = ?", h.Name, h.Type).
 			First(&exists)
 
 		if result.Error != nil {
 			db.Create(&h)
 		}
 	}
+	// Ngày lễ âm lịch tạo trước khi có LunarMonth/LunarDay: suy ra từ ngày dương tham chiếu
+	var lunarHolidays []model.Holiday
+	db.Where("type = ? AND (lunar_month = 0 OR lunar_day = 0)", "lunar").Find(&lunarHolidays)
+	for _, h := range lunarHolidays {
+		h.FillLunarDate()
+		db.Model(&model.Holiday{}).Where("id = ?", h.ID).Updates(map[string]interface{}{
+			"lunar_month": h.LunarMonth,
+			"lunar_day":   h.LunarDay,
+		})
+	}
 	for i := range seatTypes {
 		if err := db.Where("type = ?", seatTypes[i].Type).FirstOrCreate(&seatTypes[i]).Error; err != nil {
 			log.Println("failed to seed data for seat type:", seatTypes[i].Type, "error:", err)
 		}
 	}
+	// Loại vé mặc định: vé trẻ em chỉ bán cho phim P/K; các loại ưu đãi đều kiểm tra giấy tờ khi soát vé
+	ticketCategories := []model.TicketCategory{
+		{Code: model.TicketCategoryChild, Name: "Trẻ em", DiscountPercent: 40, AgeRatings: "P,K", RequiresIdCheck: true, SortOrder: 1, IsActive: true,
+			Description: "Trẻ em dưới 13 tuổi, chỉ áp dụng phim phân loại P và K"},
+		{Code: model.TicketCategoryStudent, Name: "Học sinh - Sinh viên", DiscountPercent: 20, RequiresIdCheck: true, SortOrder: 2, IsActive: true,
+			Description: "Xuất trình thẻ học sinh, sinh viên còn hiệu lực"},
+		{Code: model.TicketCategorySenior, Name: "Người cao tuổi", DiscountPercent: 30, RequiresIdCheck: true, SortOrder: 3, IsActive: true,
+			Description: "Người từ 60 tuổi trở lên"},
+		{Code: model.TicketCategoryU22, Name: "U22", DiscountPercent: 15, RequiresIdCheck: true, SortOrder: 4, IsActive: true,
+			Description: "Khách dưới 22 tuổi"},
+	}
+	for i := range ticketCategories {
+		if err := db.Where("code = ?", ticketCategories[i].Code).FirstOrCreate(&ticketCategories[i]).Error; err != nil {
+			log.Println("failed to seed data for ticket category:", ticketCategories[i].Code, "error:", err)
+		}
+	}
 	var account model.Account
 	db.Where(model.Account{Username: "Administration"}).First(&account)
 
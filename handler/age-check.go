@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UpdateMyDateOfBirth: khách khai ngày sinh một lần; đã có ngày sinh thì chỉ quầy / admin được sửa
+// để khách không tự đổi ngày sinh khi mua vé phim giới hạn độ tuổi
+func UpdateMyDateOfBirth(c *fiber.Ctx) error {
+	customer, ok := c.Locals("customer").(*model.Customer)
+	if !ok || customer == nil {
+		return utils.ErrorResponse(c, fiber.StatusUnauthorized, "Chưa đăng nhập", nil)
+	}
+	dob := c.Locals("dateOfBirth").(time.Time)
+	result := database.DB.Model(&model.Customer{}).
+		Where("id = ? AND date_of_birth IS NULL", customer.ID).
+		Update("date_of_birth", dob)
+	if result.Error != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể lưu ngày sinh", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Ngày sinh đã được khai báo, vui lòng liên hệ quầy vé để thay đổi", nil, "dateOfBirth")
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message":     "Đã lưu ngày sinh",
+		"dateOfBirth": dob.Format("2006-01-02"),
+	})
+}
+
+// GetAgeCheckLogs: nhật ký cho vào / từ chối khách chưa đủ tuổi khi soát vé (quản lý xem rạp mình)
+func GetAgeCheckLogs(c *fiber.Ctx) error {
+	filter := new(model.FilterAgeCheckLogInput)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+	cinemaId, msg := inventoryCinemaScope(c, filter.CinemaId)
+	if msg != "" {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, msg, nil)
+	}
+	db := database.DB.Model(&model.AgeCheckLog{})
+	if cinemaId != 0 {
+		db = db.Where("showtime_id IN (?)", database.DB.Table("showtimes").
+			Select("showtimes.id").
+			Joins("JOIN rooms ON rooms.id = showtimes.room_id").
+			Where("rooms.cinema_id = ?", cinemaId))
+	}
+	if filter.AccountId != 0 {
+		db = db.Where("account_id = ?", filter.AccountId)
+	}
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if from, err := time.Parse("2006-01-02", filter.From); err == nil {
+		db = db.Where("created_at >= ?", from)
+	}
+	if to, err := time.Parse("2006-01-02", filter.To); err == nil {
+		db = db.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var logs []model.AgeCheckLog
+	db.Order("id DESC").Find(&logs)
+	return utils.SuccessResponse(c, fiber.StatusOK, &model.ResponseCustom{
+		Rows:       logs,
+		Limit:      filter.Limit,
+		Page:       filter.Page,
+		TotalCount: total,
+	})
+}
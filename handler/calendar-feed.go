@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	// Lịch chiếu công khai: suất trong 14 ngày tới (giữ suất của hôm qua để kịp báo hủy/dời)
+	cinemaFeedDays = 14
+	// Lịch vé của khách: giữ đơn của suất chiếu trong 7 ngày qua
+	customerFeedPastDays = 7
+)
+
+func sendICal(c *fiber.Ctx, filename, content string) error {
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s"`, filename))
+	return c.SendString(content)
+}
+
+// GetCinemaShowtimeFeed: lịch chiếu công khai của rạp dạng .ics cho đối tác
+func GetCinemaShowtimeFeed(c *fiber.Ctx) error {
+	var cinema model.Cinema
+	if err := database.DB.Preload("Addresses").Where("slug = ?", c.Params("slug")).First(&cinema).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Rạp không tồn tại", err)
+	}
+	if cinema.Active != nil && !*cinema.Active {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Rạp đã ngừng hoạt động", nil)
+	}
+
+	now := time.Now()
+	var showtimes []model.Showtime
+	database.DB.
+		Preload("Movie").
+		Preload("Room").
+		Joins("JOIN rooms ON rooms.id = showtimes.room_id").
+		Where("rooms.cinema_id = ?", cinema.ID).
+		Where("showtimes.start_time >= ? AND showtimes.start_time < ?", now.AddDate(0, 0, -1), now.AddDate(0, 0, cinemaFeedDays)).
+		Order("showtimes.start_time ASC").
+		Find(&showtimes)
+
+	events := make([]utils.ICalEvent, 0, len(showtimes))
+	for _, showtime := range showtimes {
+		events = append(events, helper.ShowtimeICalEvent(showtime, cinema))
+	}
+	return sendICal(c, cinema.Slug+".ics", utils.BuildICal("Lịch chiếu "+cinema.Name, events))
+}
+
+// GetCustomerTicketFeed: lịch vé đã đặt của khách, truy cập bằng token bí mật trong đường dẫn
+func GetCustomerTicketFeed(c *fiber.Ctx) error {
+	token := c.Params("token")
+	var customer model.Customer
+	if token == "" || database.DB.Where("calendar_token = ? AND is_active = true", token).First(&customer).Error != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Lịch không tồn tại", nil)
+	}
+
+	var orders []model.Order
+	database.DB.
+		Preload("Tickets.ShowtimeSeat").
+		Preload("Showtime.Movie").
+		Preload("Showtime.Room").
+		Joins("JOIN showtimes ON showtimes.id = orders.showtime_id").
+		Where("orders.customer_id = ? AND orders.status IN ?", customer.ID, []string{"PAID", "CANCELLED", "REFUNDED"}).
+		Where("showtimes.start_time >= ?", time.Now().AddDate(0, 0, -customerFeedPastDays)).
+		Order("showtimes.start_time ASC").
+		Find(&orders)
+
+	cinemas := map[uint]model.Cinema{}
+	events := make([]utils.ICalEvent, 0, len(orders))
+	for _, order := range orders {
+		cinemaId := order.Showtime.Room.CinemaId
+		cinema, ok := cinemas[cinemaId]
+		if !ok {
+			database.DB.Preload("Addresses").First(&cinema, cinemaId)
+			cinemas[cinemaId] = cinema
+		}
+		events = append(events, helper.OrderICalEvent(order, cinema))
+	}
+	return sendICal(c, "ve-cua-toi.ics", utils.BuildICal("Vé xem phim", events))
+}
+
+// GetCustomerFeedUrl trả về đường dẫn lịch vé của khách đang đăng nhập, tạo token lần đầu
+func GetCustomerFeedUrl(c *fiber.Ctx) error {
+	customer, ok := c.Locals("customer").(*model.Customer)
+	if !ok || customer == nil {
+		return utils.ErrorResponse(c, fiber.StatusUnauthorized, "Chưa đăng nhập", nil)
+	}
+	if customer.CalendarToken == nil {
+		if err := assignCalendarToken(customer); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể tạo đường dẫn lịch", err)
+		}
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, calendarFeedResponse(*customer.CalendarToken))
+}
+
+// ResetCustomerFeedUrl đổi token, đường dẫn cũ (lỡ chia sẻ) ngừng hoạt động
+func ResetCustomerFeedUrl(c *fiber.Ctx) error {
+	customer, ok := c.Locals("customer").(*model.Customer)
+	if !ok || customer == nil {
+		return utils.ErrorResponse(c, fiber.StatusUnauthorized, "Chưa đăng nhập", nil)
+	}
+	if err := assignCalendarToken(customer); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể tạo đường dẫn lịch", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, calendarFeedResponse(*customer.CalendarToken))
+}
+
+func assignCalendarToken(customer *model.Customer) error {
+	token := strings.ReplaceAll(uuid.NewString(), "-", "")
+	if err := database.DB.Model(&model.Customer{}).Where("id = ?", customer.ID).Update("calendar_token", token).Error; err != nil {
+		return err
+	}
+	customer.CalendarToken = &token
+	return nil
+}
+
+func calendarFeedResponse(token string) fiber.Map {
+	url := helper.CustomerFeedURL(token)
+	return fiber.Map{
+		"url":    url,
+		"webcal": "webcal://" + strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://"),
+	}
+}
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// cashDrawerError: chưa mở ca trả lỗi theo key cashDrawer để client mở form mở ca
+func cashDrawerError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, helper.ErrNoCashSession) {
+		return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, err.Error(), err, "cashDrawer")
+	}
+	return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+}
+
+// counterStaffAccount: tài khoản nhân viên quầy (role STAFF) đang thao tác; ok = false với khách hoặc chưa đăng nhập
+// (route dùng OptionalJWT nên token có thể là của khách hàng)
+func counterStaffAccount(c *fiber.Ctx) (uint, bool) {
+	token, ok := c.Locals("user").(*jwt.Token)
+	if !ok || token == nil {
+		return 0, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, false
+	}
+	if accountId, _ := claims["accountId"].(float64); accountId == 0 {
+		return 0, false
+	}
+	if _, ok := claims["username"].(string); !ok {
+		return 0, false
+	}
+	accountInfo, _, _, _, isStaff := helper.GetInfoAccountFromToken(c)
+	return accountInfo.AccountId, isStaff
+}
+
+func OpenCashDrawer(c *fiber.Ctx) error {
+	input := c.Locals("cashDrawerInput").(model.OpenCashDrawerInput)
+	accountId := c.Locals("accountId").(uint)
+	cinemaId := c.Locals("cinemaId").(uint)
+
+	var session model.CashDrawerSession
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		session, err = helper.OpenCashSession(tx, accountId, cinemaId, input)
+		return err
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message": "Mở ca thu ngân thành công",
+		"data":    session,
+	})
+}
+
+// GetCurrentCashDrawer: ca đang mở của nhân viên kèm các khoản thu chi
+func GetCurrentCashDrawer(c *fiber.Ctx) error {
+	accountInfo, _, _, _, isStaff := helper.GetInfoAccountFromToken(c)
+	if !isStaff {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	var session model.CashDrawerSession
+	if err := database.DB.Preload("Movements", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id DESC")
+	}).Where("account_id = ? AND status = ?", accountInfo.AccountId, model.CashSessionOpen).First(&session).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, helper.ErrNoCashSession.Error(), err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, session)
+}
+
+// CashDrawerPayout: chi tiền mặt từ két (có lý do)
+func CashDrawerPayout(c *fiber.Ctx) error {
+	input := c.Locals("cashPayoutInput").(model.CashPayoutInput)
+	accountId := c.Locals("accountId").(uint)
+
+	var movement model.CashMovement
+	var session model.CashDrawerSession
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		session, err = helper.GetOpenCashSession(tx, accountId)
+		if err != nil {
+			return err
+		}
+		movement, err = helper.RecordCashMovement(tx, &session, model.CashMovementPayout, input.Amount, nil, input.Note, accountId)
+		return err
+	})
+	if err != nil {
+		return cashDrawerError(c, err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message":        "Ghi khoản chi thành công",
+		"data":           movement,
+		"expectedAmount": session.ExpectedAmount,
+	})
+}
+
+// CashDrawerRefund: trả tiền mặt cho khách của đơn đã hủy (phần hoàn của dòng tiền mặt), ghi vào ca đang mở
+func CashDrawerRefund(c *fiber.Ctx) error {
+	accountInfo, _, _, _, isStaff := helper.GetInfoAccountFromToken(c)
+	if !isStaff {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	var order model.Order
+	if err := database.DB.Preload("Showtime").Where("public_code = ?", c.Params("orderCode")).First(&order).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Không tìm thấy đơn hàng", err)
+	}
+	if order.Status != "CANCELLED" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Đơn hàng chưa được hủy", nil)
+	}
+	if accountInfo.CinemaId == nil || helper.ShowtimeCinemaId(database.DB, order.Showtime) != *accountInfo.CinemaId {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Đơn hàng không thuộc rạp của bạn", nil)
+	}
+
+	var movement model.CashMovement
+	var session model.CashDrawerSession
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		session, err = helper.GetOpenCashSession(tx, accountInfo.AccountId)
+		if err != nil {
+			return err
+		}
+		due, err := helper.CashRefundDue(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if due <= 0 {
+			return errors.New("đơn hàng không còn tiền mặt phải hoàn")
+		}
+		movement, err = helper.RecordCashMovement(tx, &session, model.CashMovementRefund, due, &order.ID, "Hoàn tiền hủy đơn "+order.PublicCode, accountInfo.AccountId)
+		return err
+	})
+	if err != nil {
+		return cashDrawerError(c, err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message":        "Hoàn tiền mặt thành công",
+		"refundAmount":   -movement.Amount,
+		"data":           movement,
+		"expectedAmount": session.ExpectedAmount,
+	})
+}
+
+// CloseCashDrawer: đóng ca với số tiền đếm thực tế; chênh lệch được đưa vào báo cáo cho quản lý rạp
+func CloseCashDrawer(c *fiber.Ctx) error {
+	input := c.Locals("closeCashDrawerInput").(model.CloseCashDrawerInput)
+	accountId := c.Locals("accountId").(uint)
+
+	var session model.CashDrawerSession
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		session, err = helper.GetOpenCashSession(tx, accountId)
+		if err != nil {
+			return err
+		}
+		return helper.CloseCashSession(tx, &session, input)
+	})
+	if err != nil {
+		return cashDrawerError(c, err)
+	}
+	// Lệch két: báo cáo cho quản lý rạp
+	helper.NotifyCashVariance(database.DB, session)
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Đóng ca thu ngân thành công",
+		"data":    session,
+	})
+}
+
+// GetCashDrawerSessions: danh sách ca thu ngân cho quản lý (manager chỉ xem rạp mình)
+func GetCashDrawerSessions(c *fiber.Ctx) error {
+	filter := new(model.FilterCashDrawerInput)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+	cinemaId, msg := inventoryCinemaScope(c, filter.CinemaId)
+	if msg != "" {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, msg, nil)
+	}
+	db := database.DB.Model(&model.CashDrawerSession{})
+	if cinemaId != 0 {
+		db = db.Where("cinema_id = ?", cinemaId)
+	}
+	if filter.AccountId != 0 {
+		db = db.Where("account_id = ?", filter.AccountId)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.VarianceOnly {
+		db = db.Where("variance IS NOT NULL AND variance <> 0")
+	}
+	if from, err := time.Parse("2006-01-02", filter.From); err == nil {
+		db = db.Where("opened_at >= ?", from)
+	}
+	if to, err := time.Parse("2006-01-02", filter.To); err == nil {
+		db = db.Where("opened_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var sessions []model.CashDrawerSession
+	db.Order("opened_at DESC, id DESC").Find(&sessions)
+	return utils.SuccessResponse(c, fiber.StatusOK, &model.ResponseCustom{
+		Rows:       sessions,
+		Limit:      filter.Limit,
+		Page:       filter.Page,
+		TotalCount: total,
+	})
+}
+
+// GetCashDrawerSessionById: chi tiết ca kèm các khoản thu chi; nhân viên chỉ xem ca của mình
+func GetCashDrawerSessionById(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("sessionId"))
+	if err != nil || id <= 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+	}
+	accountInfo, isAdmin, isManager, _, isStaff := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager && !isStaff {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	var session model.CashDrawerSession
+	if err := database.DB.Preload("Movements", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&session, id).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Ca thu ngân không tồn tại", err)
+	}
+	if isManager && (accountInfo.CinemaId == nil || *accountInfo.CinemaId != session.CinemaId) {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Bạn chỉ được xem ca thu ngân của rạp mình", nil)
+	}
+	if isStaff && session.AccountId != accountInfo.AccountId {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Bạn chỉ được xem ca thu ngân của mình", nil)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, session)
+}
@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// compCinemaScope: nhân viên / quản lý chỉ xem vé mời của rạp mình, admin chọn rạp qua cinemaId
+func compCinemaScope(c *fiber.Ctx, cinemaId uint) (uint, string) {
+	accountInfo, _, _, _, isStaff := helper.GetInfoAccountFromToken(c)
+	if isStaff {
+		if accountInfo.CinemaId == nil {
+			return 0, "Nhân viên chưa được gán rạp"
+		}
+		return *accountInfo.CinemaId, ""
+	}
+	return inventoryCinemaScope(c, cinemaId)
+}
+
+// GetCompUsage: hạn mức và số vé mời đã dùng trong tháng (?month=2006-01, mặc định tháng hiện tại)
+func GetCompUsage(c *fiber.Ctx) error {
+	cinemaId, msg := compCinemaScope(c, uint(c.QueryInt("cinemaId")))
+	if msg != "" {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, msg, nil)
+	}
+	if cinemaId == 0 {
+		return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Vui lòng chọn rạp", nil, "cinemaId")
+	}
+	month := time.Now()
+	if value := c.Query("month"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01", value, time.Local)
+		if err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Tháng không hợp lệ (định dạng 2006-01)", err, "month")
+		}
+		month = parsed
+	}
+	usage, err := helper.GetCompUsage(database.DB, cinemaId, month)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể lấy hạn mức vé mời", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, usage)
+}
+
+// UpdateCompPolicy: admin cấu hình hạn mức tháng và ngưỡng cần duyệt của rạp
+func UpdateCompPolicy(c *fiber.Ctx) error {
+	input := c.Locals("compPolicyInput").(model.UpdateCompPolicyInput)
+	cinemaId := c.Locals("cinemaId").(uint)
+
+	policy := helper.GetCompPolicy(database.DB, cinemaId)
+	if input.MonthlyQuota != nil {
+		policy.MonthlyQuota = *input.MonthlyQuota
+	}
+	if input.ApprovalThreshold != nil {
+		policy.ApprovalThreshold = *input.ApprovalThreshold
+	}
+	if err := database.DB.Save(&policy).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể lưu hạn mức vé mời", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, policy)
+}
+
+// CreateCompRequest: gửi yêu cầu xuất vé mời vượt ngưỡng để quản lý rạp duyệt
+func CreateCompRequest(c *fiber.Ctx) error {
+	request := c.Locals("compRequest").(model.CompTicketRequest)
+	if err := database.DB.Create(&request).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể tạo yêu cầu vé mời", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, request)
+}
+
+// GetCompRequests: danh sách yêu cầu vé mời của rạp
+func GetCompRequests(c *fiber.Ctx) error {
+	filter := new(model.FilterCompRequestInput)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+	cinemaId, msg := compCinemaScope(c, filter.CinemaId)
+	if msg != "" {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, msg, nil)
+	}
+	db := database.DB.Model(&model.CompTicketRequest{})
+	if cinemaId != 0 {
+		db = db.Where("cinema_id = ?", cinemaId)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var requests []model.CompTicketRequest
+	db.Preload("Showtime.Movie").Order("id DESC").Find(&requests)
+	return utils.SuccessResponse(c, fiber.StatusOK, &model.ResponseCustom{
+		Rows:       requests,
+		Limit:      filter.Limit,
+		Page:       filter.Page,
+		TotalCount: total,
+	})
+}
+
+// GetCompRequestById: chi tiết yêu cầu vé mời
+func GetCompRequestById(c *fiber.Ctx) error {
+	request := c.Locals("compRequest").(model.CompTicketRequest)
+	return utils.SuccessResponse(c, fiber.StatusOK, request)
+}
+
+// ReviewCompRequest: quản lý duyệt hoặc từ chối yêu cầu vé mời đang chờ
+func ReviewCompRequest(c *fiber.Ctx) error {
+	request := c.Locals("compRequest").(model.CompTicketRequest)
+	input := c.Locals("reviewInput").(model.ReviewCompRequestInput)
+	accountId := c.Locals("accountId").(uint)
+
+	status := model.CompRequestRejected
+	if *input.Approve {
+		status = model.CompRequestApproved
+	}
+	now := time.Now()
+	// Chỉ cập nhật khi yêu cầu vẫn đang chờ, tránh hai người duyệt cùng lúc
+	result := database.DB.Model(&model.CompTicketRequest{}).
+		Where("id = ? AND status = ?", request.ID, model.CompRequestPending).
+		Updates(map[string]any{
+			"status":      status,
+			"reviewed_by": accountId,
+			"reviewed_at": now,
+			"review_note": input.Note,
+		})
+	if result.Error != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật yêu cầu vé mời", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Yêu cầu vé mời đã được xử lý", nil)
+	}
+	request.Status = status
+	request.ReviewedBy = &accountId
+	request.ReviewedAt = &now
+	request.ReviewNote = input.Note
+	return utils.SuccessResponse(c, fiber.StatusOK, request)
+}
+
+// IssueCompTickets: nhân viên xuất vé mời (0đ) cho các ghế đang giữ.
+// Vượt ngưỡng của rạp phải kèm yêu cầu đã được duyệt; luôn kiểm tra hạn mức tháng.
+// Đơn vé mời không tính vào doanh thu, giá trị theo giá bán lưu ở CompValue.
+func IssueCompTickets(c *fiber.Ctx) error {
+	input := c.Locals("compInput").(model.IssueCompTicketInput)
+	accountId := c.Locals("accountId").(uint)
+	cinemaId := c.Locals("cinemaId").(uint)
+	heldBy := fmt.Sprintf("STAFF_%d", accountId)
+
+	tx := database.DB.Begin()
+
+	var showtime model.Showtime
+	if err := tx.Preload("Room").Where("public_code = ?", c.Params("code")).First(&showtime).Error; err != nil {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 404, "Suất chiếu không tồn tại", err)
+	}
+	if showtime.Status == "CANCELLED" {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 400, "Suất chiếu đã bị hủy", nil)
+	}
+	if showtime.Room.CinemaId != cinemaId {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 403, "Suất chiếu không thuộc rạp của bạn", nil)
+	}
+
+	var heldSeats []model.ShowtimeSeat
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("showtime_id = ? AND seat_id IN ? AND status = ? AND held_by = ?", showtime.ID, input.SeatIds, SeatHeld, heldBy).
+		Preload("Seat.SeatType").
+		Preload("Showtime").
+		Find(&heldSeats).Error; err != nil || len(heldSeats) != len(input.SeatIds) {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 400, "Một số ghế không hợp lệ hoặc đã hết hạn giữ chỗ", nil)
+	}
+
+	now := time.Now()
+	usage, err := helper.CheckCompQuota(tx, cinemaId, len(heldSeats), now)
+	if err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "seatIds")
+	}
+
+	reason, recipientName, recipientPhone := input.ReasonCode, input.RecipientName, input.RecipientPhone
+	var request *model.CompTicketRequest
+	if input.RequestId != nil {
+		approved, err := helper.UseCompRequest(tx, *input.RequestId, showtime.ID, len(heldSeats))
+		if err != nil {
+			tx.Rollback()
+			return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "requestId")
+		}
+		request = &approved
+		reason, recipientName, recipientPhone = approved.ReasonCode, approved.RecipientName, approved.RecipientPhone
+	} else if len(heldSeats) > usage.ApprovalThreshold {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400,
+			fmt.Sprintf("Xuất trên %d vé mời cần yêu cầu đã được quản lý duyệt", usage.ApprovalThreshold), nil, "requestId")
+	}
+
+	compValue := CalculateTotalAmount(heldSeats)
+	order := model.Order{
+		PublicCode:    "ORD-" + uuid.New().String()[:8],
+		CustomerName:  recipientName,
+		Phone:         recipientPhone,
+		PaymentMethod: model.PaymentMethodComp,
+		Status:        "PAID",
+		PaidAt:        &now,
+		CreatedBy:     accountId,
+		ShowtimeID:    showtime.ID,
+		TotalAmount:   0,
+		ActualRevenue: 0,
+		IsComp:        true,
+		CompReason:    reason,
+		CompValue:     compValue,
+	}
+	if request != nil {
+		order.CompRequestId = &request.ID
+	}
+	if err := tx.Create(&order).Error; err != nil {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 500, "Không thể tạo đơn vé mời", err)
+	}
+
+	tickets := make([]model.Ticket, 0, len(heldSeats))
+	for i := range heldSeats {
+		stSeat := &heldSeats[i]
+		ticket := model.Ticket{
+			OrderId:        order.ID,
+			ShowtimeId:     showtime.ID,
+			ShowtimeSeatId: &stSeat.ID,
+			SeatId:         stSeat.SeatId,
+			TicketCode:     "TKT-" + uuid.New().String()[:10],
+			Price:          0,
+			Status:         "PAID",
+			BookingTime:    now,
+			IssuedAt:       now,
+			CreatedBy:      accountId,
+		}
+		if err := tx.Create(&ticket).Error; err != nil {
+			tx.Rollback()
+			return utils.ErrorResponse(c, 500, "Không thể tạo vé", err)
+		}
+		tickets = append(tickets, ticket)
+
+		if err := tx.Model(stSeat).Updates(map[string]any{
+			"status":     "SOLD",
+			"held_by":    "",
+			"expired_at": nil,
+		}).Error; err != nil {
+			tx.Rollback()
+			return utils.ErrorResponse(c, 500, "Không thể release ghế sau khi tạo vé", err)
+		}
+		stSeat.Status = "SOLD"
+		stSeat.HeldBy = ""
+		stSeat.ExpiredAt = nil
+	}
+
+	if request != nil {
+		if err := tx.Model(request).Updates(map[string]any{
+			"status":   model.CompRequestIssued,
+			"order_id": order.ID,
+		}).Error; err != nil {
+			tx.Rollback()
+			return utils.ErrorResponse(c, 500, "Không thể cập nhật yêu cầu vé mời", err)
+		}
+	}
+
+	tx.Commit()
+	BroadcastSeatChange(showtime.ID, heldSeats)
+
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message":    "Xuất vé mời thành công",
+		"orderCode":  order.PublicCode,
+		"compReason": reason,
+		"compValue":  compValue,
+		"remaining":  usage.Remaining - len(tickets),
+		"tickets":    tickets,
+	})
+}
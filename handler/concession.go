@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GetConcessionProducts: danh mục bắp nước cho admin/manager (manager chỉ thấy rạp mình)
+func GetConcessionProducts(c *fiber.Ctx) error {
+	accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	filter := new(model.FilterConcessionProductInput)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+	if isManager {
+		if accountInfo.CinemaId == nil {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Tài khoản chưa được gán rạp", nil)
+		}
+		filter.CinemaId = *accountInfo.CinemaId
+	}
+	db := database.DB.Model(&model.ConcessionProduct{})
+	if filter.CinemaId != 0 {
+		db = db.Where("cinema_id = ?", filter.CinemaId)
+	}
+	if filter.Category != "" {
+		db = db.Where("category = ?", filter.Category)
+	}
+	if filter.SearchKey != "" {
+		db = db.Where("name ILIKE ? OR code ILIKE ?", "%"+filter.SearchKey+"%", "%"+filter.SearchKey+"%")
+	}
+	if filter.IsAvailable != nil {
+		db = db.Where("is_available = ?", *filter.IsAvailable)
+	}
+
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var products []model.ConcessionProduct
+	db.Preload("ComboItems.Product").Order("cinema_id ASC, sort_order ASC, name ASC").Find(&products)
+	return utils.SuccessResponse(c, fiber.StatusOK, &model.ResponseCustom{
+		Rows:       products,
+		Limit:      filter.Limit,
+		Page:       filter.Page,
+		TotalCount: total,
+	})
+}
+
+func GetConcessionProductById(c *fiber.Ctx) error {
+	product := c.Locals("concessionProduct").(model.ConcessionProduct)
+	return utils.SuccessResponse(c, fiber.StatusOK, product)
+}
+
+func CreateConcessionProduct(c *fiber.Ctx) error {
+	product := c.Locals("concessionProduct").(model.ConcessionProduct)
+	if err := database.DB.Create(&product).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể tạo sản phẩm", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message": "Tạo sản phẩm thành công",
+		"data":    product,
+	})
+}
+
+func UpdateConcessionProduct(c *fiber.Ctx) error {
+	product := c.Locals("concessionProduct").(model.ConcessionProduct)
+	comboItems, replaceItems := c.Locals("comboItems").([]model.ConcessionComboItem)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("ComboItems", "Cinema").Save(&product).Error; err != nil {
+			return err
+		}
+		if !replaceItems {
+			return nil
+		}
+		if err := tx.Where("combo_id = ?", product.ID).Delete(&model.ConcessionComboItem{}).Error; err != nil {
+			return err
+		}
+		for i := range comboItems {
+			comboItems[i].ComboId = product.ID
+		}
+		if len(comboItems) > 0 {
+			return tx.Create(&comboItems).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật sản phẩm", err)
+	}
+	database.DB.Preload("ComboItems.Product").First(&product, product.ID)
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Cập nhật sản phẩm thành công",
+		"data":    product,
+	})
+}
+
+func DeleteConcessionProduct(c *fiber.Ctx) error {
+	product := c.Locals("concessionProduct").(model.ConcessionProduct)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("combo_id = ?", product.ID).Delete(&model.ConcessionComboItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&model.ConcessionRecipe{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.ConcessionProduct{}, product.ID).Error
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể xóa sản phẩm", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Đã xóa sản phẩm")
+}
+
+// GetCinemaConcessions: menu bắp nước đang bán của rạp cho khách, nhóm theo loại
+func GetCinemaConcessions(c *fiber.Ctx) error {
+	var cinema model.Cinema
+	if err := database.DB.Select("id", "name").Where("slug = ?", c.Params("slug")).First(&cinema).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Không tìm thấy rạp", err)
+	}
+	var products []model.ConcessionProduct
+	database.DB.Preload("ComboItems.Product").
+		Where("cinema_id = ? AND is_available = ?", cinema.ID, true).
+		Order("sort_order ASC, name ASC").
+		Find(&products)
+
+	categories := []string{model.ConcessionCombo, model.ConcessionPopcorn, model.ConcessionDrink, model.ConcessionSnack}
+	grouped := make(map[string][]model.ConcessionProduct, len(categories))
+	for _, p := range products {
+		grouped[p.Category] = append(grouped[p.Category], p)
+	}
+	menu := make([]fiber.Map, 0, len(categories))
+	for _, category := range categories {
+		if len(grouped[category]) == 0 {
+			continue
+		}
+		menu = append(menu, fiber.Map{
+			"category": category,
+			"products": grouped[category],
+		})
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"cinemaId":   cinema.ID,
+		"cinemaName": cinema.Name,
+		"menu":       menu,
+	})
+}
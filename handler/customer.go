@@ -64,6 +64,10 @@ func RegisterCustomer(c *fiber.Ctx) error {
 	copier.Copy(&newCustomer, &customerInput)
 	newCustomer.Password = hash
 	newCustomer.IsActive = true
+	if customerInput.Birthday != nil {
+		dob, _ := time.Parse("2006-01-02", *customerInput.Birthday)
+		newCustomer.DateOfBirth = &dob
+	}
 
 	// 5. Lưu vào DB
 	if err := db.Create(&newCustomer).Error; err != nil {
@@ -193,6 +197,10 @@ func EditCustomer(c *fiber.Ctx) error {
 	var customer model.Customer
 	tx.First(&customer, customerId)
 	copier.Copy(&customer, &customerInput)
+	if customerInput.Birthday != nil {
+		dob, _ := time.Parse("2006-01-02", *customerInput.Birthday)
+		customer.DateOfBirth = &dob
+	}
 
 	if err := tx.Model(&model.Customer{DTO: model.DTO{ID: customerId}}).Updates(customer).Error; err != nil {
 		tx.Rollback()
@@ -357,7 +365,7 @@ func GetCurrentCustomer(c *fiber.Ctx) error {
 	// Ưu tiên dùng customer từ Locals (nếu middleware đã query)
 	if customer, ok := c.Locals("customer").(*model.Customer); ok && customer != nil {
 		//log.Println("Returning customer from Locals")
-		return utils.SuccessResponse(c, fiber.StatusOK, customer)
+		return utils.SuccessResponse(c, fiber.StatusOK, customerWithLoyalty(customer))
 	}
 
 	// Fallback: query lại từ customerId
@@ -374,7 +382,15 @@ func GetCurrentCustomer(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, constants.NOT_FOUND_RECORDS, err)
 	}
 
-	return utils.SuccessResponse(c, fiber.StatusOK, customer)
+	return utils.SuccessResponse(c, fiber.StatusOK, customerWithLoyalty(&customer))
+}
+
+// customerWithLoyalty gắn số dư và điểm sắp hết hạn vào thông tin khách
+func customerWithLoyalty(customer *model.Customer) interface{} {
+	return struct {
+		*model.Customer
+		Loyalty model.LoyaltySummary `json:"loyalty"`
+	}{customer, helper.GetLoyaltySummary(database.DB, *customer)}
 }
 
 // handler/customer.go
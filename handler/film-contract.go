@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetDistributors(c *fiber.Ctx) error {
+	_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	filter := new(model.FilterDistributorInput)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+	db := database.DB.Model(&model.Distributor{})
+	if filter.SearchKey != "" {
+		db = db.Where("name ILIKE ? OR tax_code ILIKE ?", "%"+filter.SearchKey+"%", "%"+filter.SearchKey+"%")
+	}
+	if filter.IsActive != nil {
+		db = db.Where("is_active = ?", *filter.IsActive)
+	}
+
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var distributors []model.Distributor
+	db.Order("name ASC").Find(&distributors)
+	return utils.SuccessResponse(c, fiber.StatusOK, &model.ResponseCustom{
+		Rows:       distributors,
+		Limit:      filter.Limit,
+		Page:       filter.Page,
+		TotalCount: total,
+	})
+}
+
+func GetDistributorById(c *fiber.Ctx) error {
+	distributor := c.Locals("distributor").(model.Distributor)
+	return utils.SuccessResponse(c, fiber.StatusOK, distributor)
+}
+
+func CreateDistributor(c *fiber.Ctx) error {
+	distributor := c.Locals("distributor").(model.Distributor)
+	if err := database.DB.Create(&distributor).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể tạo nhà phát hành", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message": "Tạo nhà phát hành thành công",
+		"data":    distributor,
+	})
+}
+
+func UpdateDistributor(c *fiber.Ctx) error {
+	distributor := c.Locals("distributor").(model.Distributor)
+	if err := database.DB.Save(&distributor).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật nhà phát hành", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Cập nhật nhà phát hành thành công",
+		"data":    distributor,
+	})
+}
+
+func DeleteDistributor(c *fiber.Ctx) error {
+	distributor := c.Locals("distributor").(model.Distributor)
+	if err := database.DB.Delete(&model.Distributor{}, distributor.ID).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể xóa nhà phát hành", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Đã xóa nhà phát hành")
+}
+
+func GetFilmContracts(c *fiber.Ctx) error {
+	_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	filter := new(model.FilterFilmContractInput)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+	db := database.DB.Model(&model.FilmContract{})
+	if filter.DistributorId != 0 {
+		db = db.Where("distributor_id = ?", filter.DistributorId)
+	}
+	if filter.MovieId != 0 {
+		db = db.Where("movie_id = ?", filter.MovieId)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var contracts []model.FilmContract
+	db.Preload("Distributor").Preload("Movie").Order("start_date DESC").Find(&contracts)
+	return utils.SuccessResponse(c, fiber.StatusOK, &model.ResponseCustom{
+		Rows:       contracts,
+		Limit:      filter.Limit,
+		Page:       filter.Page,
+		TotalCount: total,
+	})
+}
+
+func GetFilmContractById(c *fiber.Ctx) error {
+	contract := c.Locals("filmContract").(model.FilmContract)
+	database.DB.Preload("Distributor").Preload("Movie").Preload("Chain").First(&contract, contract.ID)
+	return utils.SuccessResponse(c, fiber.StatusOK, contract)
+}
+
+func CreateFilmContract(c *fiber.Ctx) error {
+	contract := c.Locals("filmContract").(model.FilmContract)
+	if err := database.DB.Create(&contract).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể tạo hợp đồng", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message": "Tạo hợp đồng thành công",
+		"data":    contract,
+	})
+}
+
+func UpdateFilmContract(c *fiber.Ctx) error {
+	contract := c.Locals("filmContract").(model.FilmContract)
+	if err := database.DB.Save(&contract).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật hợp đồng", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Cập nhật hợp đồng thành công",
+		"data":    contract,
+	})
+}
+
+func DeleteFilmContract(c *fiber.Ctx) error {
+	contract := c.Locals("filmContract").(model.FilmContract)
+	if err := database.DB.Delete(&model.FilmContract{}, contract.ID).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể xóa hợp đồng", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Đã xóa hợp đồng")
+}
+
+// FilmSettlementReport: số tiền phải trả nhà phát hành theo từng hợp đồng và tuần công chiếu trong kỳ
+func FilmSettlementReport(c *fiber.Ctx) error {
+	filter := c.Locals("settlementFilter").(model.FilmSettlementFilter)
+	from := c.Locals("from").(time.Time)
+	to := c.Locals("to").(time.Time)
+
+	db := database.DB.Preload("Distributor").Preload("Movie").
+		Where("start_date <= ? AND end_date >= ?", filter.To, filter.From)
+	if filter.DistributorId != 0 {
+		db = db.Where("distributor_id = ?", filter.DistributorId)
+	}
+	if filter.ContractId != 0 {
+		db = db.Where("id = ?", filter.ContractId)
+	}
+	var contracts []model.FilmContract
+	db.Order("distributor_id ASC, start_date ASC").Find(&contracts)
+
+	settlements := make([]model.FilmSettlement, 0, len(contracts))
+	totalRevenue, totalOwed := 0.0, 0.0
+	for _, contract := range contracts {
+		settlement := helper.BuildFilmSettlement(database.DB, contract, from, to)
+		totalRevenue += settlement.Revenue
+		totalOwed += settlement.AmountOwed
+		settlements = append(settlements, settlement)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"report": settlements,
+		"summary": fiber.Map{
+			"totalRevenue":    totalRevenue,
+			"totalAmountOwed": totalOwed,
+		},
+		"period": fiber.Map{
+			"from": from.Format("02/01/2006"),
+			"to":   to.Format("02/01/2006"),
+		},
+	})
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// DefaultGiftCardExpiryMonths: hạn dùng mặc định của thẻ quà tặng
+const DefaultGiftCardExpiryMonths = 36
+
+// SellGiftCard phát hành thẻ đã thanh toán; PIN chỉ trả về một lần trong response này
+func SellGiftCard(c *fiber.Ctx) error {
+	card := c.Locals("giftCard").(model.GiftCard)
+	expiryMonths := c.Locals("expiryMonths").(int)
+	if expiryMonths == 0 {
+		expiryMonths = DefaultGiftCardExpiryMonths
+	}
+	expiresAt := time.Now().AddDate(0, expiryMonths, 0)
+	card.ExpiresAt = &expiresAt
+
+	var pin string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		pin, err = helper.IssueGiftCard(tx, &card, card.SoldBy)
+		if err != nil {
+			return err
+		}
+		return recordGiftCardCashSale(c, tx, card)
+	})
+	if errors.Is(err, helper.ErrNoCashSession) {
+		return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, err.Error(), err, "cashDrawer")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể phát hành thẻ quà tặng", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message": "Phát hành thẻ quà tặng thành công",
+		"data":    card,
+		"pin":     pin,
+	})
+}
+
+// recordGiftCardCashSale: thẻ bán tại quầy thu tiền mặt được ghi vào ca thu ngân của người bán.
+// Nhân viên bán vé bắt buộc phải mở ca; quản lý không có ca thì bỏ qua.
+func recordGiftCardCashSale(c *fiber.Ctx, tx *gorm.DB, card model.GiftCard) error {
+	if card.Channel != model.GiftCardBoxOffice || card.SoldBy == nil || card.PaymentMethod != model.TenderCash {
+		return nil
+	}
+	_, _, _, _, isStaff := helper.GetInfoAccountFromToken(c)
+	session, err := helper.GetOpenCashSession(tx, *card.SoldBy)
+	if errors.Is(err, helper.ErrNoCashSession) && !isStaff {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = helper.RecordCashMovement(tx, &session, model.CashMovementSale, card.InitialValue, nil, "Bán thẻ quà tặng "+card.Code, *card.SoldBy)
+	return err
+}
+
+func GetGiftCards(c *fiber.Ctx) error {
+	accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	filter := new(model.FilterGiftCardInput)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+	if isManager {
+		if accountInfo.CinemaId == nil {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Tài khoản chưa được gán rạp", nil)
+		}
+		filter.CinemaId = *accountInfo.CinemaId
+	}
+	db := database.DB.Model(&model.GiftCard{})
+	if filter.CinemaId != 0 {
+		db = db.Where("cinema_id = ?", filter.CinemaId)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.SearchKey != "" {
+		db = db.Where("code ILIKE ? OR buyer_email ILIKE ?", "%"+filter.SearchKey+"%", "%"+filter.SearchKey+"%")
+	}
+
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var cards []model.GiftCard
+	db.Order("id DESC").Find(&cards)
+	return utils.SuccessResponse(c, fiber.StatusOK, &model.ResponseCustom{
+		Rows:       cards,
+		Limit:      filter.Limit,
+		Page:       filter.Page,
+		TotalCount: total,
+	})
+}
+
+// GetGiftCardById: thông tin thẻ kèm sổ phát sinh
+func GetGiftCardById(c *fiber.Ctx) error {
+	card := c.Locals("giftCard").(model.GiftCard)
+	var entries []model.StoredValueTransaction
+	database.DB.Where("gift_card_id = ?", card.ID).Order("id DESC").Find(&entries)
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"card":         card,
+		"transactions": entries,
+	})
+}
+
+// UpdateGiftCardStatus khóa/mở khóa thẻ; mở khóa thì xóa bộ đếm nhập sai PIN
+func UpdateGiftCardStatus(c *fiber.Ctx) error {
+	card := c.Locals("giftCard").(model.GiftCard)
+	updates := map[string]interface{}{"status": card.Status}
+	if card.Status == model.GiftCardActive {
+		updates["failed_attempts"] = 0
+	}
+	if err := database.DB.Model(&model.GiftCard{}).Where("id = ?", card.ID).Updates(updates).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật thẻ quà tặng", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Cập nhật thẻ quà tặng thành công",
+		"data":    card,
+	})
+}
+
+// CheckGiftCardBalance: tra cứu số dư thẻ bằng mã + PIN
+func CheckGiftCardBalance(c *fiber.Ctx) error {
+	input := c.Locals("giftCardCredential").(model.GiftCardCredentialInput)
+	var card model.GiftCard
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		card, err = helper.LockGiftCard(tx, input.Code, input.Pin)
+		return err
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"code":      card.Code,
+		"balance":   card.Balance,
+		"expiresAt": card.ExpiresAt,
+	})
+}
+
+// GetMyWallet: số dư ví và sổ phát sinh của khách đang đăng nhập
+func GetMyWallet(c *fiber.Ctx) error {
+	customer, ok := c.Locals("customer").(*model.Customer)
+	if !ok || customer == nil {
+		return utils.ErrorResponse(c, fiber.StatusUnauthorized, "Chưa đăng nhập", nil)
+	}
+	filter := new(model.Pagination)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+	var balance float64
+	database.DB.Model(&model.Customer{}).Select("wallet_balance").Where("id = ?", customer.ID).Scan(&balance)
+
+	db := database.DB.Model(&model.StoredValueTransaction{}).Where("customer_id = ?", customer.ID)
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var entries []model.StoredValueTransaction
+	db.Order("id DESC").Find(&entries)
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"balance": balance,
+		"transactions": &model.ResponseCustom{
+			Rows:       entries,
+			Limit:      filter.Limit,
+			Page:       filter.Page,
+			TotalCount: total,
+		},
+	})
+}
+
+// LoadGiftCardToWallet: khách nạp toàn bộ số dư thẻ quà tặng vào ví
+func LoadGiftCardToWallet(c *fiber.Ctx) error {
+	customer, ok := c.Locals("customer").(*model.Customer)
+	if !ok || customer == nil {
+		return utils.ErrorResponse(c, fiber.StatusUnauthorized, "Chưa đăng nhập", nil)
+	}
+	input := c.Locals("giftCardCredential").(model.GiftCardCredentialInput)
+
+	var amount float64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		card, err := helper.LockGiftCard(tx, input.Code, input.Pin)
+		if err != nil {
+			return err
+		}
+		amount, err = helper.LoadGiftCardToWallet(tx, &card, customer.ID)
+		return err
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+	}
+	var balance float64
+	database.DB.Model(&model.Customer{}).Select("wallet_balance").Where("id = ?", customer.ID).Scan(&balance)
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Nạp thẻ vào ví thành công",
+		"amount":  amount,
+		"balance": balance,
+	})
+}
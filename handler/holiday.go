@@ -6,6 +6,7 @@ import (
 	"cinema_manager/helper"
 	"cinema_manager/model"
 	"cinema_manager/utils"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -79,6 +80,8 @@ func UpdateHoliday(c *fiber.Ctx) error {
 		Date        *time.Time
 		Type        *string
 		IsRecurring *bool
+		LunarMonth  *int
+		LunarDay    *int
 	})
 
 	if input.Name != nil {
@@ -93,6 +96,13 @@ func UpdateHoliday(c *fiber.Ctx) error {
 	if input.IsRecurring != nil {
 		holiday.IsRecurring = *input.IsRecurring
 	}
+	if input.LunarMonth != nil {
+		holiday.LunarMonth, holiday.LunarDay = *input.LunarMonth, *input.LunarDay
+	} else if input.Date != nil || input.Type != nil {
+		// Đổi ngày tham chiếu/loại thì tính lại ngày âm
+		holiday.LunarMonth, holiday.LunarDay = 0, 0
+	}
+	holiday.FillLunarDate()
 
 	if err := database.DB.Save(&holiday).Error; err != nil {
 		return utils.ErrorResponse(c, 500, "Không thể cập nhật", err)
@@ -123,3 +133,125 @@ func DeleteHoliday(c *fiber.Ctx) error {
 		"message": "Xóa thành công",
 	})
 }
+
+// PreviewImportHoliday trả về các ngày lễ sẽ tạo mới, cập nhật, bị bỏ qua do trùng hoặc lỗi, chưa ghi DB
+func PreviewImportHoliday(c *fiber.Ctx) error {
+	plan := c.Locals("importPlan").(model.HolidayImportPlan)
+	return utils.SuccessResponse(c, fiber.StatusOK, plan)
+}
+
+func ImportHoliday(c *fiber.Ctx) error {
+	plan := c.Locals("importPlan").(model.HolidayImportPlan)
+
+	tx := database.DB.Begin()
+	for i := range plan.Creates {
+		if err := tx.Create(&plan.Creates[i]).Error; err != nil {
+			tx.Rollback()
+			return utils.ErrorResponse(c, 500, "Không thể tạo ngày lễ "+plan.Creates[i].Name, err)
+		}
+	}
+	for i := range plan.Updates {
+		if err := tx.Save(&plan.Updates[i].After).Error; err != nil {
+			tx.Rollback()
+			return utils.ErrorResponse(c, 500, "Không thể cập nhật ngày lễ "+plan.Updates[i].After.Name, err)
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return utils.ErrorResponse(c, 500, "Nhập ngày lễ thất bại", err)
+	}
+
+	return utils.SuccessResponse(c, 200, fiber.Map{
+		"message": fmt.Sprintf("Đã tạo %d, cập nhật %d, bỏ qua %d ngày lễ", len(plan.Creates), len(plan.Updates), len(plan.Duplicates)+len(plan.Errors)),
+		"data":    plan,
+	})
+}
+
+// ExportHoliday xuất bảng Holiday ra file .ics; ngày lễ âm lịch lặp lại được quy ra ngày dương của năm year
+func ExportHoliday(c *fiber.Ctx) error {
+	_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin {
+		return utils.ErrorResponse(c, 403, "Chỉ admin được phép", nil)
+	}
+	filter := new(model.HolidayExportFilter)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+	if filter.Year == 0 {
+		filter.Year = time.Now().In(time.FixedZone("ICT", 7*3600)).Year()
+	}
+	if filter.Year < 1900 || filter.Year > 2100 {
+		return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Năm phải trong khoảng 1900–2100", nil, "year")
+	}
+
+	var holidays []model.Holiday
+	database.DB.Order("date ASC").Find(&holidays)
+	content := utils.BuildICal(fmt.Sprintf("Ngày lễ %d", filter.Year), helper.HolidayICalEvents(holidays, filter.Year))
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="holidays-%d.ics"`, filter.Year))
+	return c.SendString(content)
+}
+
+// GetCalendar trả về cách hệ thống phân loại từng ngày (cuối tuần, lễ, Tết, chiếu sớm, sự kiện)
+// cùng ngày lễ khớp và mẫu lịch SuggestTemplate sẽ chọn nếu có phim, để kiểm tra trước khi xếp lịch
+func GetCalendar(c *fiber.Ctx) error {
+	filter := c.Locals("calendarFilter").(model.CalendarFilter)
+	from := c.Locals("calendarFrom").(time.Time)
+	to := c.Locals("calendarTo").(time.Time)
+	movie := c.Locals("movie").(*model.Movie)
+
+	var releaseDate time.Time
+	if movie != nil {
+		releaseDate = movie.DateRelease.Time
+	}
+
+	days := []fiber.Map{}
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		info := helper.ClassifyDayAt(date, releaseDate, filter.CinemaId, filter.MovieId)
+		lunarYear, lunarMonth, lunarDay, isLeap := utils.SolarToLunar(date)
+
+		day := fiber.Map{
+			"date":           date.Format("2006-01-02"),
+			"weekday":        info.Weekday.String(),
+			"isWeekend":      info.IsWeekend,
+			"isFriday":       info.IsFriday,
+			"isHoliday":      info.IsHoliday,
+			"isLunarHoliday": info.IsLunarHoliday,
+			"isEarly":        info.IsEarly,
+			"isSpecial":      info.IsSpecial,
+			"dayTypes":       info.DayTypes,
+			"holidayName":    info.HolidayName,
+			"holidays":       helper.FindHolidays(date),
+			"events":         info.Events,
+			"lunarDate": fiber.Map{
+				"year":   lunarYear,
+				"month":  lunarMonth,
+				"day":    lunarDay,
+				"isLeap": isLeap,
+			},
+		}
+		if info.Events == nil {
+			day["events"] = []model.SpecialEvent{}
+		}
+		if movie != nil {
+			day["isShowDate"] = helper.IsValidShowDate(date, movie)
+			day["suggestedTemplate"] = nil
+			if template := helper.SuggestTemplateAt(movie, date, filter.CinemaId, filter.IsVietnamese); template != nil {
+				day["suggestedTemplate"] = fiber.Map{
+					"id":       template.ID,
+					"name":     template.Name,
+					"priority": template.Priority,
+				}
+			}
+		}
+		days = append(days, day)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"cinemaId": filter.CinemaId,
+		"movieId":  filter.MovieId,
+		"from":     filter.From,
+		"to":       filter.To,
+		"days":     days,
+	})
+}
@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// inventoryCinemaScope: rạp được phép xem kho; manager luôn bị giới hạn trong rạp mình.
+// Trả về thông báo lỗi nếu không có quyền.
+func inventoryCinemaScope(c *fiber.Ctx, cinemaId uint) (uint, string) {
+	accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager {
+		return 0, constants.NOT_ADMIN
+	}
+	if isManager {
+		if accountInfo.CinemaId == nil {
+			return 0, "Tài khoản chưa được gán rạp"
+		}
+		return *accountInfo.CinemaId, ""
+	}
+	return cinemaId, ""
+}
+
+func GetInventoryItems(c *fiber.Ctx) error {
+	filter := new(model.FilterInventoryItemInput)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+	cinemaId, msg := inventoryCinemaScope(c, filter.CinemaId)
+	if msg != "" {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, msg, nil)
+	}
+	db := database.DB.Model(&model.InventoryItem{})
+	if cinemaId != 0 {
+		db = db.Where("cinema_id = ?", cinemaId)
+	}
+	if filter.SearchKey != "" {
+		db = db.Where("name ILIKE ? OR code ILIKE ?", "%"+filter.SearchKey+"%", "%"+filter.SearchKey+"%")
+	}
+	if filter.LowStock {
+		db = db.Where("is_active = ? AND quantity <= low_stock_threshold", true)
+	}
+
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var items []model.InventoryItem
+	db.Order("cinema_id ASC, name ASC").Find(&items)
+	return utils.SuccessResponse(c, fiber.StatusOK, &model.ResponseCustom{
+		Rows:       items,
+		Limit:      filter.Limit,
+		Page:       filter.Page,
+		TotalCount: total,
+	})
+}
+
+// GetLowStockAlerts: nguyên liệu đang ở mức cảnh báo, thiếu nhiều nhất lên đầu
+func GetLowStockAlerts(c *fiber.Ctx) error {
+	cinemaId, msg := inventoryCinemaScope(c, uint(c.QueryInt("cinemaId")))
+	if msg != "" {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, msg, nil)
+	}
+	db := database.DB.Where("is_active = ? AND quantity <= low_stock_threshold", true)
+	if cinemaId != 0 {
+		db = db.Where("cinema_id = ?", cinemaId)
+	}
+	var items []model.InventoryItem
+	db.Order("(low_stock_threshold - quantity) DESC").Find(&items)
+
+	alerts := make([]fiber.Map, 0, len(items))
+	for _, item := range items {
+		alerts = append(alerts, fiber.Map{
+			"inventoryItemId": item.ID,
+			"cinemaId":        item.CinemaId,
+			"code":            item.Code,
+			"name":            item.Name,
+			"unit":            item.Unit,
+			"quantity":        item.Quantity,
+			"threshold":       item.LowStockThreshold,
+			"outOfStock":      item.Quantity <= 0,
+		})
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, alerts)
+}
+
+func GetInventoryItemById(c *fiber.Ctx) error {
+	item := c.Locals("inventoryItem").(model.InventoryItem)
+	return utils.SuccessResponse(c, fiber.StatusOK, item)
+}
+
+func CreateInventoryItem(c *fiber.Ctx) error {
+	item := c.Locals("inventoryItem").(model.InventoryItem)
+	opening := c.Locals("openingQuantity").(float64)
+	accountId := c.Locals("accountId").(uint)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+		if opening > 0 {
+			_, err := helper.ApplyStockMovement(tx, &item, model.StockReceive, opening, nil, "Tồn đầu kỳ", accountId)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể tạo nguyên liệu", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message": "Tạo nguyên liệu thành công",
+		"data":    item,
+	})
+}
+
+func UpdateInventoryItem(c *fiber.Ctx) error {
+	item := c.Locals("inventoryItem").(model.InventoryItem)
+	// Không ghi đè tồn kho: chỉ thay đổi qua phiếu xuất nhập
+	if err := database.DB.Model(&item).Select("code", "name", "unit", "low_stock_threshold", "is_active").Updates(&item).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật nguyên liệu", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Cập nhật nguyên liệu thành công",
+		"data":    item,
+	})
+}
+
+func DeleteInventoryItem(c *fiber.Ctx) error {
+	item := c.Locals("inventoryItem").(model.InventoryItem)
+	if err := database.DB.Delete(&model.InventoryItem{}, item.ID).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể xóa nguyên liệu", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Đã xóa nguyên liệu")
+}
+
+// CreateStockMovement: nhập hàng, hủy hàng hoặc kiểm kê (đặt tồn theo số đếm thực tế)
+func CreateStockMovement(c *fiber.Ctx) error {
+	item := c.Locals("inventoryItem").(model.InventoryItem)
+	input := c.Locals("movementInput").(model.CreateStockMovementInput)
+	accountId := c.Locals("accountId").(uint)
+
+	var movement model.StockMovement
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, item.ID).Error; err != nil {
+			return err
+		}
+		var quantity float64
+		switch input.Type {
+		case model.StockReceive:
+			quantity = input.Quantity
+		case model.StockWaste:
+			if input.Quantity > item.Quantity {
+				return errors.New("số lượng hủy vượt quá tồn kho")
+			}
+			quantity = -input.Quantity
+		case model.StockAdjustment:
+			quantity = *input.CountedQuantity - item.Quantity
+		}
+		var err error
+		movement, err = helper.ApplyStockMovement(tx, &item, input.Type, quantity, nil, input.Note, accountId)
+		return err
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Không thể ghi nhận xuất nhập kho", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message":    "Ghi nhận xuất nhập kho thành công",
+		"data":       movement,
+		"item":       item,
+		"isLowStock": helper.IsLowStock(item),
+	})
+}
+
+func GetStockMovements(c *fiber.Ctx) error {
+	filter := new(model.FilterStockMovementInput)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+	cinemaId, msg := inventoryCinemaScope(c, filter.CinemaId)
+	if msg != "" {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, msg, nil)
+	}
+	db := database.DB.Model(&model.StockMovement{})
+	if cinemaId != 0 {
+		db = db.Where("cinema_id = ?", cinemaId)
+	}
+	if filter.InventoryItemId != 0 {
+		db = db.Where("inventory_item_id = ?", filter.InventoryItemId)
+	}
+	if filter.Type != "" {
+		db = db.Where("type = ?", filter.Type)
+	}
+	if from, err := time.Parse("2006-01-02", filter.From); err == nil {
+		db = db.Where("created_at >= ?", from)
+	}
+	if to, err := time.Parse("2006-01-02", filter.To); err == nil {
+		db = db.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var movements []model.StockMovement
+	db.Preload("InventoryItem").Order("created_at DESC, id DESC").Find(&movements)
+	return utils.SuccessResponse(c, fiber.StatusOK, &model.ResponseCustom{
+		Rows:       movements,
+		Limit:      filter.Limit,
+		Page:       filter.Page,
+		TotalCount: total,
+	})
+}
+
+func GetConcessionRecipe(c *fiber.Ctx) error {
+	product := c.Locals("concessionProduct").(model.ConcessionProduct)
+	var recipes []model.ConcessionRecipe
+	database.DB.Preload("InventoryItem").Where("product_id = ?", product.ID).Find(&recipes)
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"productId": product.ID,
+		"name":      product.Name,
+		"lines":     recipes,
+	})
+}
+
+// UpdateConcessionRecipe thay toàn bộ công thức của sản phẩm
+func UpdateConcessionRecipe(c *fiber.Ctx) error {
+	product := c.Locals("concessionProduct").(model.ConcessionProduct)
+	input := c.Locals("recipeInput").(model.UpdateRecipeInput)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", product.ID).Delete(&model.ConcessionRecipe{}).Error; err != nil {
+			return err
+		}
+		if len(input.Lines) == 0 {
+			return nil
+		}
+		recipes := make([]model.ConcessionRecipe, 0, len(input.Lines))
+		for _, line := range input.Lines {
+			recipes = append(recipes, model.ConcessionRecipe{
+				ProductId:       product.ID,
+				InventoryItemId: line.InventoryItemId,
+				Quantity:        line.Quantity,
+			})
+		}
+		return tx.Create(&recipes).Error
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật công thức", err)
+	}
+	return GetConcessionRecipe(c)
+}
+
+// StockReport: báo cáo xuất nhập tồn theo rạp trong kỳ
+func StockReport(c *fiber.Ctx) error {
+	filter := c.Locals("stockReportFilter").(model.StockReportFilter)
+	to, _ := time.Parse("2006-01-02", filter.To)
+
+	items, err := helper.BuildStockReport(database.DB, filter.CinemaId, filter.From, to.AddDate(0, 0, 1).Format("2006-01-02"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Lỗi lấy báo cáo tồn kho", err)
+	}
+	lowStock := 0
+	for _, item := range items {
+		if item.IsLowStock {
+			lowStock++
+		}
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"cinemaId": filter.CinemaId,
+		"report":   items,
+		"summary": fiber.Map{
+			"items":         len(items),
+			"lowStockItems": lowStock,
+		},
+		"period": fiber.Map{
+			"from": filter.From,
+			"to":   filter.To,
+		},
+	})
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func GetLoyaltySetting(c *fiber.Ctx) error {
+	_, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Không có quyền", nil)
+	}
+	setting := helper.GetLoyaltySetting(database.DB)
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"setting":   setting,
+		"isDefault": setting.ID == 0,
+	})
+}
+
+func UpdateLoyaltySetting(c *fiber.Ctx) error {
+	input := c.Locals("loyaltySettingInput").(model.UpdateLoyaltySettingInput)
+
+	setting := helper.GetLoyaltySetting(database.DB)
+	setting.AmountPerPoint = input.AmountPerPoint
+	setting.PointValue = input.PointValue
+	setting.ExpiryMonths = input.ExpiryMonths
+	setting.MaxRedeemPercent = input.MaxRedeemPercent
+	setting.MinRedeemPoints = input.MinRedeemPoints
+	if input.IsActive != nil {
+		setting.IsActive = *input.IsActive
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&setting).Error; err != nil {
+			return err
+		}
+		// Tạo mới bỏ qua giá trị false do cột có default:true
+		return tx.Model(&setting).Update("is_active", setting.IsActive).Error
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật cấu hình điểm", err)
+	}
+	return GetLoyaltySetting(c)
+}
+
+// loyaltyHistory: sổ điểm của một khách, mới nhất lên đầu
+func loyaltyHistory(c *fiber.Ctx, customerId uint) error {
+	filter := c.Locals("loyaltyFilter").(model.FilterLoyaltyTransactionInput)
+	db := database.DB.Model(&model.LoyaltyTransaction{}).Where("customer_id = ?", customerId)
+	if filter.Type != "" {
+		db = db.Where("type = ?", filter.Type)
+	}
+
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var entries []model.LoyaltyTransaction
+	db.Preload("Order", func(tx *gorm.DB) *gorm.DB {
+		return tx.Select("id", "public_code", "total_amount", "status")
+	}).Order("id DESC").Find(&entries)
+	return utils.SuccessResponse(c, fiber.StatusOK, &model.ResponseCustom{
+		Rows:       entries,
+		Limit:      filter.Limit,
+		Page:       filter.Page,
+		TotalCount: total,
+	})
+}
+
+// GetMyLoyaltyHistory: lịch sử điểm của khách đang đăng nhập
+func GetMyLoyaltyHistory(c *fiber.Ctx) error {
+	customer, ok := c.Locals("customer").(*model.Customer)
+	if !ok || customer == nil {
+		return utils.ErrorResponse(c, fiber.StatusUnauthorized, "Chưa đăng nhập", nil)
+	}
+	return loyaltyHistory(c, customer.ID)
+}
+
+// GetCustomerLoyalty: admin/manager xem sổ điểm của khách
+func GetCustomerLoyalty(c *fiber.Ctx) error {
+	_, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	customerId := c.Locals("inputId").(int)
+	var customer model.Customer
+	if err := database.DB.First(&customer, customerId).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, constants.NOT_FOUND_RECORDS, err)
+	}
+	return loyaltyHistory(c, customer.ID)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GetMembershipTiers: danh sách hạng kèm số khách đang thuộc mỗi hạng
+func GetMembershipTiers(c *fiber.Ctx) error {
+	_, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	var tiers []model.MembershipTier
+	database.DB.Order("rank ASC").Find(&tiers)
+
+	var counts []struct {
+		TierId uint
+		Total  int64
+	}
+	database.DB.Model(&model.Customer{}).
+		Select("tier_id, COUNT(*) AS total").
+		Where("tier_id IS NOT NULL").
+		Group("tier_id").
+		Scan(&counts)
+	countMap := make(map[uint]int64, len(counts))
+	for _, item := range counts {
+		countMap[item.TierId] = item.Total
+	}
+
+	result := make([]fiber.Map, 0, len(tiers))
+	for _, tier := range tiers {
+		result = append(result, fiber.Map{
+			"tier":      tier,
+			"customers": countMap[tier.ID],
+		})
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, result)
+}
+
+func GetMembershipTierById(c *fiber.Ctx) error {
+	tier := c.Locals("membershipTier").(model.MembershipTier)
+	return utils.SuccessResponse(c, fiber.StatusOK, tier)
+}
+
+func CreateMembershipTier(c *fiber.Ctx) error {
+	tier := c.Locals("membershipTier").(model.MembershipTier)
+	if err := database.DB.Create(&tier).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể tạo hạng thành viên", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message": "Tạo hạng thành viên thành công",
+		"data":    tier,
+	})
+}
+
+func UpdateMembershipTier(c *fiber.Ctx) error {
+	tier := c.Locals("membershipTier").(model.MembershipTier)
+	if err := database.DB.Save(&tier).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật hạng thành viên", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Cập nhật hạng thành viên thành công",
+		"data":    tier,
+	})
+}
+
+func DeleteMembershipTier(c *fiber.Ctx) error {
+	tier := c.Locals("membershipTier").(model.MembershipTier)
+	if err := database.DB.Delete(&model.MembershipTier{}, tier.ID).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể xóa hạng thành viên", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Đã xóa hạng thành viên")
+}
+
+// OverrideCustomerTier: admin gán hạng và khóa, hoặc bỏ khóa để xét lại theo chi tiêu ngay
+func OverrideCustomerTier(c *fiber.Ctx) error {
+	customer := c.Locals("targetCustomer").(model.Customer)
+	input := c.Locals("tierInput").(model.OverrideCustomerTierInput)
+	accountId := c.Locals("accountId").(uint)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		spend := helper.RollingSpend(tx, customer.ID, now)
+		if !input.Release {
+			return helper.SetCustomerTier(tx, &customer, input.TierId, true, model.TierChangeOverride, input.Note, &accountId, spend)
+		}
+		var tierId *uint
+		if tier := helper.TierForSpend(helper.ActiveTiers(tx), spend); tier != nil {
+			tierId = &tier.ID
+		}
+		return helper.SetCustomerTier(tx, &customer, tierId, false, model.TierChangeRelease, input.Note, &accountId, spend)
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật hạng khách hàng", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message":  "Cập nhật hạng khách hàng thành công",
+		"progress": helper.BuildTierProgress(database.DB, &customer),
+	})
+}
+
+// GetCustomerTierHistory: admin/manager xem hạng hiện tại và lịch sử đổi hạng của khách
+func GetCustomerTierHistory(c *fiber.Ctx) error {
+	_, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	customerId := c.Locals("inputId").(int)
+	var customer model.Customer
+	if err := database.DB.First(&customer, customerId).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, constants.NOT_FOUND_RECORDS, err)
+	}
+	var logs []model.TierChangeLog
+	database.DB.Preload("FromTier").Preload("ToTier").
+		Where("customer_id = ?", customer.ID).
+		Order("id DESC").
+		Find(&logs)
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"progress": helper.BuildTierProgress(database.DB, &customer),
+		"history":  logs,
+	})
+}
+
+// GetMyTierProgress: khách xem hạng, tiến độ lên hạng và voucher còn dùng được
+func GetMyTierProgress(c *fiber.Ctx) error {
+	customer, ok := c.Locals("customer").(*model.Customer)
+	if !ok || customer == nil {
+		return utils.ErrorResponse(c, fiber.StatusUnauthorized, "Chưa đăng nhập", nil)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, helper.BuildTierProgress(database.DB, customer))
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var roomTypes = []model.RoomType{model.Small, model.Medium, model.Large, model.IMAX, model.FourDX}
+
+// GetRoomTurnaround: thời gian dọn phòng / quảng cáo theo loại phòng (kèm mặc định nếu chưa cấu hình)
+func GetRoomTurnaround(c *fiber.Ctx) error {
+	_, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Không có quyền", nil)
+	}
+	var saved []model.RoomTurnaround
+	database.DB.Find(&saved)
+	savedMap := make(map[model.RoomType]model.RoomTurnaround)
+	for _, s := range saved {
+		savedMap[s.RoomType] = s
+	}
+
+	result := make([]fiber.Map, 0, len(roomTypes))
+	for _, t := range roomTypes {
+		config, ok := savedMap[t]
+		if !ok {
+			config = helper.DefaultRoomTurnaround(t)
+		}
+		result = append(result, fiber.Map{
+			"roomType":        t,
+			"cleaningMinutes": config.CleaningMinutes,
+			"adMinutes":       config.AdMinutes,
+			"isDefault":       !ok,
+		})
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, result)
+}
+
+func UpdateRoomTurnaround(c *fiber.Ctx) error {
+	input := c.Locals("turnaroundInput").(model.UpdateRoomTurnaroundInput)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, item := range input.Items {
+			var config model.RoomTurnaround
+			if err := tx.Where("room_type = ?", item.RoomType).FirstOrInit(&config).Error; err != nil {
+				return err
+			}
+			config.RoomType = item.RoomType
+			config.CleaningMinutes = item.CleaningMinutes
+			config.AdMinutes = item.AdMinutes
+			if err := tx.Save(&config).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật thời gian dọn phòng", err)
+	}
+	return GetRoomTurnaround(c)
+}
+
+// GetCinemaOperatingHours: giờ hoạt động 7 ngày trong tuần của rạp
+func GetCinemaOperatingHours(c *fiber.Ctx) error {
+	cinemaId := c.Locals("cinemaId").(uint)
+	loc := time.FixedZone("ICT", 7*3600)
+
+	// Lấy tuần bắt đầu từ Chủ nhật để duyệt đủ 7 thứ
+	now := time.Now().In(loc)
+	sunday := now.AddDate(0, 0, -int(now.Weekday()))
+
+	var saved []model.CinemaOperatingHour
+	database.DB.Where("cinema_id = ?", cinemaId).Find(&saved)
+	configured := make(map[int]bool)
+	for _, h := range saved {
+		configured[h.Weekday] = true
+	}
+
+	result := make([]fiber.Map, 0, 7)
+	for i := 0; i < 7; i++ {
+		window := helper.GetOperatingWindow(database.DB, cinemaId, sunday.AddDate(0, 0, i))
+		result = append(result, fiber.Map{
+			"weekday":     i,
+			"weekdayName": helper.WeekdayName(time.Weekday(i)),
+			"openTime":    window.Open.Format("15:04"),
+			"closeTime":   window.Close.Format("15:04"),
+			"overnight":   window.Close.Day() != window.Open.Day(),
+			"isDefault":   !configured[i],
+		})
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, result)
+}
+
+func UpdateCinemaOperatingHours(c *fiber.Ctx) error {
+	cinemaId := c.Locals("cinemaId").(uint)
+	input := c.Locals("operatingHoursInput").(model.UpdateOperatingHoursInput)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, h := range input.Hours {
+			var hour model.CinemaOperatingHour
+			if err := tx.Where("cinema_id = ? AND weekday = ?", cinemaId, *h.Weekday).FirstOrInit(&hour).Error; err != nil {
+				return err
+			}
+			hour.CinemaId = cinemaId
+			hour.Weekday = *h.Weekday
+			hour.OpenTime = h.OpenTime
+			hour.CloseTime = h.CloseTime
+			if err := tx.Save(&hour).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật giờ hoạt động", err)
+	}
+	return GetCinemaOperatingHours(c)
+}
@@ -3,13 +3,16 @@ package handler
 import (
 	"bytes"
 	"cinema_manager/database"
+	"cinema_manager/helper"
 	"cinema_manager/model"
 	"cinema_manager/utils"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"log"
+	"math"
 	"os"
 	"strings"
 	"time"
@@ -33,6 +36,7 @@ func GetMyOrders(c *fiber.Ctx) error {
 		Preload("Showtime").
 		Preload("Showtime.Movie").
 		Preload("Showtime.Movie.Posters").
+		Preload("Concessions").
 		Where("customer_id = ? AND status = ?", customer.ID, "PAID").
 		Order("created_at desc").
 		Find(&orders).Error; err != nil {
@@ -54,14 +58,18 @@ func GetMyOrders(c *fiber.Ctx) error {
 			}
 
 			tickets = append(tickets, map[string]interface{}{
-				"ticketCode": ticket.TicketCode,
-				"seatLabel":  seatLabel,
+				"ticketCode":   ticket.TicketCode,
+				"seatLabel":    seatLabel,
+				"categoryCode": ticket.CategoryCode,
+				"categoryName": ticket.CategoryName,
+				"price":        ticket.Price,
 			})
 		}
-		qrContent := order.PublicCode
-		qrBytes, err := utils.GenerateQRCode(qrContent, 400) // size lớn hơn cho dễ quét
+		// QR ký Ed25519 (mã đơn, vé, suất chiếu, hạn dùng) để không thể tự tạo từ mã đơn; lỗi thì bỏ QR của đơn
 		qrBase64 := ""
-		if err != nil {
+		if qrContent, err := helper.OrderQRContent(order, order.Showtime, nil); err != nil {
+			log.Printf("Lỗi ký QR cho đơn hàng %s: %v", order.PublicCode, err)
+		} else if qrBytes, err := utils.GenerateQRCode(qrContent, 400); err != nil { // size lớn hơn cho dễ quét
 			log.Printf("Lỗi tạo QR cho đơn hàng %s: %v", order.PublicCode, err)
 		} else {
 			qrBase64 = "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrBytes)
@@ -87,6 +95,7 @@ func GetMyOrders(c *fiber.Ctx) error {
 			"poster":      posterUrl,
 			"ticketCount": len(order.Tickets),
 			"tickets":     tickets,
+			"concessions": order.Concessions,
 			"qrCode":      qrBase64,
 		})
 	}
@@ -103,6 +112,8 @@ func GetOrderDetail(c *fiber.Ctx) error {
 		Preload("Tickets.ShowtimeSeat.Seat").
 		Preload("Showtime").
 		Preload("Showtime.Movie").
+		Preload("Concessions").
+		Preload("Payments").
 		Where("public_code = ?", orderCode).
 		First(&order).Error; err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Không tìm thấy đơn hàng", err)
@@ -113,12 +124,15 @@ func GetOrderDetail(c *fiber.Ctx) error {
 	for _, ticket := range order.Tickets {
 		seat := ticket.ShowtimeSeat.Seat
 		if seat.ID != 0 {
-			seats = append(seats, fmt.Sprintf("%s%d", seat.Row, seat.Column))
+			seats = append(seats, helper.TicketSeatLabel(ticket))
 		}
 	}
 
 	// === TẠO 1 QR DUY NHẤT CHO CẢ ĐƠN HÀNG ===
-	qrContent := order.PublicCode                        // hoặc link: https://yourdomain.com/staff/checkin/order?code=ORD-ABC123
+	qrContent, err := helper.OrderQRContent(order, order.Showtime, nil)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể tạo mã QR check-in", err)
+	}
 	qrBytes, err := utils.GenerateQRCode(qrContent, 400) // size lớn hơn cho dễ quét
 	qrBase64 := ""
 	if err != nil {
@@ -129,20 +143,32 @@ func GetOrderDetail(c *fiber.Ctx) error {
 	languageLabel := utils.GetLanguageLabel(utils.LanguageType(order.Showtime.LanguageType))
 	// Response – chỉ 1 qrCode cho cả đơn
 	response := map[string]interface{}{
-		"orderCode":     order.PublicCode,
-		"movieTitle":    order.Showtime.Movie.Title,
-		"showtime":      order.Showtime.StartTime.Format("15:04 - 02/01/2006"),
-		"format":        order.Showtime.Format, // nếu có field format trong Showtime
-		"language":      languageLabel,
-		"seats":         seats,
-		"totalAmount":   order.TotalAmount,
-		"paymentMethod": order.PaymentMethod,
-		"paidAt":        order.PaidAt.Format("15:04 - 02/01/2006"),
-		"customerName":  order.CustomerName,
-		"phone":         order.Phone,
-		"email":         order.Email,
-		"qrCode":        qrBase64, // ← 1 QR DUY NHẤT
-		"status":        order.Status,
+		"orderCode":        order.PublicCode,
+		"movieTitle":       order.Showtime.Movie.Title,
+		"showtime":         order.Showtime.StartTime.Format("15:04 - 02/01/2006"),
+		"format":           order.Showtime.Format, // nếu có field format trong Showtime
+		"language":         languageLabel,
+		"seats":            seats,
+		"tickets":          order.Tickets,
+		"concessions":      order.Concessions,
+		"concessionAmount": order.ConcessionAmount,
+		"pointsRedeemed":   order.PointsRedeemed,
+		"loyaltyDiscount":  order.LoyaltyDiscount,
+		"pointsEarned":     order.PointsEarned,
+		"memberDiscount":   order.MemberDiscount,
+		"voucherDiscount":  order.VoucherDiscount,
+		"giftCardAmount":   order.GiftCardAmount,
+		"walletAmount":     order.WalletAmount,
+		"walletRefund":     order.WalletRefund,
+		"totalAmount":      order.TotalAmount,
+		"paymentMethod":    order.PaymentMethod,
+		"payments":         order.Payments,
+		"paidAt":           order.PaidAt.Format("15:04 - 02/01/2006"),
+		"customerName":     order.CustomerName,
+		"phone":            order.Phone,
+		"email":            order.Email,
+		"qrCode":           qrBase64, // ← 1 QR DUY NHẤT
+		"status":           order.Status,
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, response)
@@ -157,6 +183,7 @@ func GetOrderSuccessDetail(c *fiber.Ctx) error {
 		Preload("Tickets.ShowtimeSeat.Seat").
 		Preload("Showtime").
 		Preload("Showtime.Movie").
+		Preload("Concessions").
 		Where("public_code = ?", orderCode).
 		First(&order).Error; err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Không tìm thấy đơn hàng", err)
@@ -167,12 +194,15 @@ func GetOrderSuccessDetail(c *fiber.Ctx) error {
 	for _, ticket := range order.Tickets {
 		seat := ticket.ShowtimeSeat.Seat
 		if seat.ID != 0 {
-			seats = append(seats, fmt.Sprintf("%s%d", seat.Row, seat.Column))
+			seats = append(seats, helper.TicketSeatLabel(ticket))
 		}
 	}
 
 	// === TẠO 1 QR DUY NHẤT CHO CẢ ĐƠN HÀNG ===
-	qrContent := order.PublicCode // hoặc link check-in
+	qrContent, err := helper.OrderQRContent(order, order.Showtime, nil)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể tạo mã QR check-in", err)
+	}
 	qrBytes, err := utils.GenerateQRCode(qrContent, 400)
 	qrBase64 := ""
 	if err == nil {
@@ -187,6 +217,7 @@ func GetOrderSuccessDetail(c *fiber.Ctx) error {
 		"movieTitle":  order.Showtime.Movie.Title,
 		"showtime":    order.Showtime.StartTime.Format("15:04 - 02/01/2006"),
 		"seats":       seats,
+		"concessions": order.Concessions,
 		"totalAmount": order.TotalAmount,
 		"qrCode":      qrBase64, // ← 1 QR duy nhất
 	}
@@ -199,7 +230,7 @@ func SendCancelConfirmationEmail(order model.Order, refundAmount float64) {
 	for _, ticket := range order.Tickets {
 		seat := ticket.ShowtimeSeat.Seat
 		if seat.ID != 0 {
-			seatLabels = append(seatLabels, fmt.Sprintf("%s%d", seat.Row, seat.Column))
+			seatLabels = append(seatLabels, helper.TicketSeatLabel(ticket))
 		}
 	}
 	// Data cho template
@@ -258,6 +289,71 @@ func SendCancelConfirmationEmail(order model.Order, refundAmount float64) {
 		log.Printf("Đã gửi email xác nhận hủy vé đến %s (hoàn %fđ)", order.Email, refundAmount)
 	}
 }
+
+// orderSeatLabels: danh sách ghế của đơn hàng dạng "A1, A2"
+func orderSeatLabels(order model.Order) string {
+	seatLabels := make([]string, 0, len(order.Tickets))
+	for _, ticket := range order.Tickets {
+		seat := ticket.ShowtimeSeat.Seat
+		if seat.ID != 0 {
+			seatLabels = append(seatLabels, helper.TicketSeatLabel(ticket))
+		}
+	}
+	return strings.Join(seatLabels, ", ")
+}
+
+// sendShowtimeChangeEmail render template và gửi email thông báo thay đổi suất chiếu
+func sendShowtimeChangeEmail(to, subject, tmplPath string, data interface{}) error {
+	tmpl, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		return fmt.Errorf("load template %s: %w", tmplPath, err)
+	}
+	var htmlBody bytes.Buffer
+	if err := tmpl.Execute(&htmlBody, data); err != nil {
+		return fmt.Errorf("render template %s: %w", tmplPath, err)
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", "CinemaPro <cinema_hub@gmail.com>")
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", htmlBody.String())
+
+	d := gomail.NewDialer(os.Getenv("SMTP_HOST"), 587, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+	return d.DialAndSend(m)
+}
+
+// SendShowtimeCancelledEmail báo khách suất chiếu bị hủy và được hoàn 100%
+func SendShowtimeCancelledEmail(order model.Order, reason string) {
+	data := utils.ShowtimeChangeData{
+		CustomerName: order.CustomerName,
+		OrderCode:    order.PublicCode,
+		MovieName:    order.Showtime.Movie.Title,
+		CinemaName:   order.Showtime.Room.Cinema.Name,
+		RoomName:     order.Showtime.Room.Name,
+		Showtime:     order.Showtime.StartTime.In(time.FixedZone("ICT", 7*3600)).Format("15:04 - 02/01/2006"),
+		Seats:        orderSeatLabels(order),
+		TotalAmount:  order.TotalAmount,
+		RefundAmount: order.RefundAmount, // 0 với đơn chưa thanh toán
+		Reason:       reason,
+	}
+	subject := fmt.Sprintf("Suất chiếu đã bị hủy - Mã đơn: %s", order.PublicCode)
+	if err := sendShowtimeChangeEmail(order.Email, subject, "templates/showtime_cancelled.html", data); err != nil {
+		log.Printf("Lỗi gửi email hủy suất chiếu cho %s: %v", order.Email, err)
+		return
+	}
+	log.Printf("Đã gửi email hủy suất chiếu đến %s (đơn %s)", order.Email, order.PublicCode)
+}
+
+// SendShowtimeMovedEmail báo khách suất chiếu đã được dời (giờ/phòng/ghế mới) kèm link hủy hoàn 100%
+func SendShowtimeMovedEmail(to string, data utils.ShowtimeChangeData) {
+	subject := fmt.Sprintf("Thay đổi suất chiếu - Mã đơn: %s", data.OrderCode)
+	if err := sendShowtimeChangeEmail(to, subject, "templates/showtime_moved.html", data); err != nil {
+		log.Printf("Lỗi gửi email dời suất chiếu cho %s: %v", to, err)
+		return
+	}
+	log.Printf("Đã gửi email dời suất chiếu đến %s (đơn %s)", to, data.OrderCode)
+}
 func CancelOrder(c *fiber.Ctx) error {
 	orderCode := c.Params("orderCode")
 	db := database.DB
@@ -282,22 +378,26 @@ func CancelOrder(c *fiber.Ctx) error {
 	if order.Status == "CANCELLED" {
 		return utils.ErrorResponse(c, 400, "Đơn hàng đã được hủy trước đó", nil)
 	}
+	// Đơn đặt chỗ chưa thanh toán được hủy tại quầy, không hoàn tiền
+	if order.Status == model.OrderReserved {
+		return utils.ErrorResponse(c, 400, "Đơn đặt chỗ chưa thanh toán, vui lòng liên hệ quầy vé để hủy", nil)
+	}
 
-	// Tính thời gian còn lại đến suất chiếu
-	showtimeStart := order.Showtime.StartTime
-	hoursBefore := time.Until(showtimeStart).Hours()
+	if msg := nonRefundableReason(order); msg != "" {
+		return utils.ErrorResponse(c, 400, msg, nil)
+	}
 
-	var refundPercent float64
-	if hoursBefore >= 2 {
-		refundPercent = 1.0 // 100%
-	} else if hoursBefore >= 1.0 { // 30 phút
-		refundPercent = 0.5 // 50%
-	} else {
+	refundPercent, ok := cancelRefundPercent(order)
+	if !ok {
 		return utils.ErrorResponse(c, 400, "Quá muộn để hủy vé. Không thể hoàn tiền.", nil)
 	}
 
 	refundAmount := float64(order.TotalAmount) * refundPercent
 	actualRevenue := order.TotalAmount - refundAmount
+	refundToWallet := requestRefundToWallet(c)
+	staffId, byStaff := counterStaffAccount(c)
+	var refund model.OrderRefundResult
+	var cashRefunded float64
 	// Transaction: cập nhật trạng thái + hoàn tiền
 	err := db.Transaction(func(tx *gorm.DB) error {
 		// Cập nhật order
@@ -324,9 +424,23 @@ func CancelOrder(c *fiber.Ctx) error {
 			return err
 		}
 
-		return nil
+		if err := helper.CancelOrderSideEffects(tx, order.ID, 0); err != nil {
+			return err
+		}
+		var err error
+		if refund, err = helper.AllocateOrderRefund(tx, &order, refundAmount, refundToWallet, nil); err != nil {
+			return err
+		}
+		// Hủy tại quầy: trả luôn phần tiền mặt qua két của nhân viên (khách hủy online nhận tại quầy sau)
+		if byStaff {
+			cashRefunded, err = helper.RecordOrderCashRefund(tx, staffId, &order)
+		}
+		return err
 	})
 
+	if errors.Is(err, helper.ErrNoCashSession) {
+		return cashDrawerError(c, err)
+	}
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Hủy vé thất bại", err)
 	}
@@ -335,14 +449,65 @@ func CancelOrder(c *fiber.Ctx) error {
 	go SendCancelConfirmationEmail(order, refundAmount)
 
 	return utils.SuccessResponse(c, 200, fiber.Map{
-		"message":        "Hủy vé thành công",
-		"refund_amount":  refundAmount,
-		"refund_percent": refundPercent * 100,
+		"message":           "Hủy vé thành công",
+		"refund_amount":     refundAmount,
+		"refund_percent":    refundPercent * 100,
+		"ticket_refund":     (order.TotalAmount - order.ConcessionAmount) * refundPercent,
+		"concession_refund": order.ConcessionAmount * refundPercent,
+		"refund_breakdown":  refund,
+		"cash_refunded":     cashRefunded,
 	})
 }
 
-// Query params: ?orderCode=ORD-ABC123&ticketCodes=TKT-123,TKT-456
+// requestRefundToWallet: khách chọn nhận tiền hoàn vào ví thay vì phương thức thanh toán ban đầu
+// (body {"refundTo": "WALLET"}, mặc định hoàn theo phương thức ban đầu)
+func requestRefundToWallet(c *fiber.Ctx) bool {
+	var body struct {
+		RefundTo string `json:"refundTo"`
+	}
+	if len(c.Body()) > 0 {
+		c.BodyParser(&body)
+	}
+	return strings.EqualFold(body.RefundTo, "WALLET")
+}
+
+// nonRefundableReason: lý do không cho hủy nếu suất chiếu thuộc sự kiện không hoàn vé
+// (trừ khi suất đã bị dời, khách luôn được hủy hoàn 100%)
+func nonRefundableReason(order model.Order) string {
+	if inFullRefundWindow(order) {
+		return ""
+	}
+	if event := helper.FindNonRefundableEvent(database.DB, order.Showtime); event != nil {
+		return fmt.Sprintf("Vé thuộc sự kiện \"%s\" không được hủy hoặc hoàn tiền", event.Name)
+	}
+	return ""
+}
+
+// inFullRefundWindow: đơn thuộc suất chiếu đã bị dời, khách được hủy hoàn 100% đến giờ chiếu mới
+func inFullRefundWindow(order model.Order) bool {
+	return order.FullRefundUntil != nil && time.Now().Before(*order.FullRefundUntil)
+}
+
+// cancelRefundPercent: tỉ lệ hoàn khi hủy đơn theo thời gian còn lại đến suất chiếu (cần preload Showtime).
+// Suất đã bị dời hoặc còn >= 2 giờ hoàn 100%, còn >= 1 giờ hoàn 50%; ok = false nếu đã quá muộn để hủy.
+func cancelRefundPercent(order model.Order) (float64, bool) {
+	hoursBefore := time.Until(order.Showtime.StartTime).Hours()
+	switch {
+	case inFullRefundWindow(order), hoursBefore >= 2:
+		return 1.0, true
+	case hoursBefore >= 1.0:
+		return 0.5, true
+	}
+	return 0, false
+}
+
+// POST /api/v1/staff/orders/cancel?orderCode=ORD-ABC123&ticketCodes=TKT-123,TKT-456
+// Nhân viên quầy hủy cả đơn hoặc một số vé; phần tiền mặt được chi ngay từ két của nhân viên.
 func CancelOrderByCode(c *fiber.Ctx) error {
+	staffId, isStaff := counterStaffAccount(c)
+	if !isStaff {
+		return utils.ErrorResponse(c, 403, "FORBIDDEN", nil)
+	}
 	orderCode := c.Query("orderCode")
 	rawTicketCodes := c.Query("ticketCodes") // "TKT-123,TKT-456" hoặc rỗng (hủy cả đơn)
 
@@ -361,15 +526,33 @@ func CancelOrderByCode(c *fiber.Ctx) error {
 	}
 
 	// Kiểm tra thời gian hủy: trước giờ chiếu ít nhất 60 phút (có thể tùy chỉnh)
-	if time.Now().Add(60 * time.Minute).After(order.Showtime.StartTime) {
+	refundPercent, ok := cancelRefundPercent(order)
+	if !ok {
 		return utils.ErrorResponse(c, 400, "Chỉ được hủy trước giờ chiếu ít nhất 60 phút", nil)
 	}
+	if msg := nonRefundableReason(order); msg != "" {
+		return utils.ErrorResponse(c, 400, msg, nil)
+	}
+
+	// Vé còn hiệu lực của đơn (bỏ vé đã hủy ở lần trước)
+	var activeTickets []model.Ticket
+	var cancelledValue float64 // giá các vé đã hủy trước đó
+	for _, t := range order.Tickets {
+		if t.Status == "CANCELLED" {
+			cancelledValue += t.Price
+			continue
+		}
+		if t.Status == "CHECKED_IN" || t.Status == "USED" {
+			return utils.ErrorResponse(c, 400, "Có vé đã check-in, không thể hủy", nil)
+		}
+		activeTickets = append(activeTickets, t)
+	}
 
 	// Danh sách vé cần hủy
 	var ticketsToCancel []model.Ticket
 	if rawTicketCodes == "" {
 		// Hủy toàn bộ vé trong đơn
-		ticketsToCancel = order.Tickets
+		ticketsToCancel = activeTickets
 	} else {
 		codes := strings.Split(rawTicketCodes, ",")
 		codeMap := make(map[string]bool)
@@ -377,7 +560,7 @@ func CancelOrderByCode(c *fiber.Ctx) error {
 			codeMap[strings.TrimSpace(code)] = true
 		}
 
-		for _, t := range order.Tickets {
+		for _, t := range activeTickets {
 			if codeMap[t.TicketCode] {
 				ticketsToCancel = append(ticketsToCancel, t)
 			}
@@ -388,51 +571,69 @@ func CancelOrderByCode(c *fiber.Ctx) error {
 		}
 	}
 
-	// Transaction
-	tx := database.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
+	cancelAll := len(ticketsToCancel) == len(activeTickets)
+	refundAmount := cancelTicketsRefund(order, ticketsToCancel, cancelAll, cancelledValue, refundPercent)
+	refundToWallet := requestRefundToWallet(c)
 
 	now := time.Now()
+	var refund model.OrderRefundResult
+	var cashRefunded float64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, ticket := range ticketsToCancel {
+			// Giải phóng ghế
+			if err := tx.Model(&model.ShowtimeSeat{}).
+				Where("id = ?", ticket.ShowtimeSeatId).
+				Updates(map[string]any{
+					"status":     SeatAvailable,
+					"held_by":    "",
+					"expired_at": nil,
+				}).Error; err != nil {
+				return err
+			}
 
-	for _, ticket := range ticketsToCancel {
-		// Giải phóng ghế
-		if err := tx.Model(&model.ShowtimeSeat{}).
-			Where("id = ?", ticket.ShowtimeSeatId).
-			Updates(map[string]any{
-				"status":     SeatAvailable,
-				"held_by":    "",
-				"expired_at": nil,
-			}).Error; err != nil {
-			tx.Rollback()
-			return utils.ErrorResponse(c, 500, "Lỗi giải phóng ghế", err)
+			// Cập nhật vé
+			if err := tx.Model(&ticket).
+				Updates(map[string]any{
+					"status":       "CANCELLED",
+					"cancelled_at": now,
+				}).Error; err != nil {
+				return err
+			}
 		}
 
-		// Cập nhật vé
-		if err := tx.Model(&ticket).
-			Updates(map[string]any{
-				"status":       "CANCELLED",
-				"cancelled_at": now,
-			}).Error; err != nil {
-			tx.Rollback()
-			return utils.ErrorResponse(c, 500, "Lỗi cập nhật vé", err)
+		updates := map[string]interface{}{
+			"refund_amount":  order.RefundAmount + refundAmount,
+			"actual_revenue": order.ActualRevenue - refundAmount,
+		}
+		// Nếu hủy hết vé → hủy đơn
+		if cancelAll {
+			updates["status"] = "CANCELLED"
+			updates["cancelled_at"] = now
+		}
+		if err := tx.Model(&order).Updates(updates).Error; err != nil {
+			return err
+		}
+		if cancelAll {
+			if err := helper.CancelOrderSideEffects(tx, order.ID, 0); err != nil {
+				return err
+			}
 		}
-	}
 
-	// Nếu hủy hết vé → hủy đơn
-	if len(ticketsToCancel) == len(order.Tickets) {
-		order.Status = "CANCELLED"
-		order.CancelledAt = &now
-		if err := tx.Save(&order).Error; err != nil {
-			tx.Rollback()
-			return utils.ErrorResponse(c, 500, "Lỗi cập nhật đơn hàng", err)
+		// Phân bổ tiền hoàn về từng dòng thanh toán của đơn
+		var err error
+		if refund, err = helper.AllocateOrderRefund(tx, &order, refundAmount, refundToWallet, nil); err != nil {
+			return err
 		}
+		// Hủy tại quầy: trả luôn phần tiền mặt qua két của nhân viên
+		cashRefunded, err = helper.RecordOrderCashRefund(tx, staffId, &order)
+		return err
+	})
+	if errors.Is(err, helper.ErrNoCashSession) {
+		return cashDrawerError(c, err)
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Hủy vé thất bại", err)
 	}
-
-	tx.Commit()
 
 	// Broadcast realtime
 	BroadcastShowtime(order.ShowtimeID)
@@ -440,9 +641,27 @@ func CancelOrderByCode(c *fiber.Ctx) error {
 	return utils.SuccessResponse(c, 200, fiber.Map{
 		"message":           "Hủy vé thành công! Tiền sẽ được hoàn lại trong 3-7 ngày làm việc.",
 		"cancelled_tickets": len(ticketsToCancel),
+		"refund_amount":     refundAmount,
+		"refund_percent":    refundPercent * 100,
+		"refund_breakdown":  refund,
+		"cash_refunded":     cashRefunded,
 	})
 }
 
+// cancelTicketsRefund: tiền hoàn khi hủy vé của đơn. Hủy một phần hoàn theo giá vé; hủy phần còn lại
+// của đơn thì hoàn cả bắp nước, tức tổng đơn trừ giá các vé đã hủy trước đó. Không vượt quá số chưa hoàn.
+func cancelTicketsRefund(order model.Order, ticketsToCancel []model.Ticket, cancelAll bool, cancelledValue, refundPercent float64) float64 {
+	var refundAmount float64
+	if cancelAll {
+		refundAmount = (order.TotalAmount - cancelledValue) * refundPercent
+	} else {
+		for _, t := range ticketsToCancel {
+			refundAmount += t.Price * refundPercent
+		}
+	}
+	return math.Max(math.Min(refundAmount, order.TotalAmount-order.RefundAmount), 0)
+}
+
 // POST /api/v1/orders/:publicCode/cancel
 func CancelOrderByUser(c *fiber.Ctx) error {
 	publicCode := c.Params("publicCode")
@@ -469,38 +688,66 @@ func CancelOrderByUser(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, 400, "Đơn hàng không thể hủy", nil)
 	}
 
-	if time.Now().Add(60 * time.Minute).After(order.Showtime.StartTime) {
+	refundPercent, ok := cancelRefundPercent(order)
+	if !ok {
 		return utils.ErrorResponse(c, 400, "Chỉ được hủy trước giờ chiếu ít nhất 60 phút", nil)
 	}
+	if msg := nonRefundableReason(order); msg != "" {
+		return utils.ErrorResponse(c, 400, msg, nil)
+	}
 
-	tx := database.DB.Begin()
+	refundAmount := order.TotalAmount * refundPercent
+	refundToWallet := requestRefundToWallet(c)
 	now := time.Now()
+	var refund model.OrderRefundResult
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, ticket := range order.Tickets {
+			// Giải phóng ghế
+			if err := tx.Model(&model.ShowtimeSeat{}).
+				Where("id = ?", ticket.ShowtimeSeatId).
+				Updates(map[string]any{
+					"status":     SeatAvailable,
+					"held_by":    "",
+					"expired_at": nil,
+				}).Error; err != nil {
+				return err
+			}
 
-	for _, ticket := range order.Tickets {
-		// Giải phóng ghế
-		tx.Model(&model.ShowtimeSeat{}).
-			Where("id = ?", ticket.ShowtimeSeatId).
-			Updates(map[string]any{
-				"status":     SeatAvailable,
-				"held_by":    "",
-				"expired_at": nil,
-			})
+			// Hủy vé
+			if err := tx.Model(&ticket).Updates(map[string]any{
+				"status":       "CANCELLED",
+				"cancelled_at": now,
+			}).Error; err != nil {
+				return err
+			}
+		}
 
-		// Hủy vé
-		tx.Model(&ticket).Updates(map[string]any{
-			"status":       "CANCELLED",
-			"cancelled_at": now,
-		})
+		// Hủy đơn
+		if err := tx.Model(&order).Updates(map[string]interface{}{
+			"status":         "CANCELLED",
+			"cancelled_at":   now,
+			"refund_amount":  refundAmount,
+			"actual_revenue": order.TotalAmount - refundAmount,
+		}).Error; err != nil {
+			return err
+		}
+		if err := helper.CancelOrderSideEffects(tx, order.ID, 0); err != nil {
+			return err
+		}
+		var err error
+		refund, err = helper.AllocateOrderRefund(tx, &order, refundAmount, refundToWallet, nil)
+		return err
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Hủy đơn hàng thất bại", err)
 	}
 
-	// Hủy đơn
-	order.Status = "CANCELLED"
-	order.CancelledAt = &now
-	tx.Save(&order)
-
-	tx.Commit()
-
 	BroadcastShowtime(order.ShowtimeID)
 
-	return utils.SuccessResponse(c, 200, "Hủy đơn hàng thành công!")
+	return utils.SuccessResponse(c, 200, fiber.Map{
+		"message":          "Hủy đơn hàng thành công!",
+		"refund_amount":    refundAmount,
+		"refund_percent":   refundPercent * 100,
+		"refund_breakdown": refund,
+	})
 }
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"cinema_manager/model"
+	"testing"
+)
+
+func TestCancelTicketsRefund(t *testing.T) {
+	tickets := func(prices ...float64) []model.Ticket {
+		list := make([]model.Ticket, 0, len(prices))
+		for _, price := range prices {
+			list = append(list, model.Ticket{Price: price})
+		}
+		return list
+	}
+	cases := []struct {
+		name           string
+		order          model.Order
+		cancel         []model.Ticket
+		cancelAll      bool
+		cancelledValue float64
+		percent        float64
+		want           float64
+	}{
+		{"Hủy một vé, hoàn 100% giá vé", model.Order{TotalAmount: 250000}, tickets(90000), false, 0, 1, 90000},
+		{"Hủy một vé sát giờ, hoàn 50%", model.Order{TotalAmount: 250000}, tickets(90000), false, 0, 0.5, 45000},
+		{"Hủy cả đơn hoàn cả bắp nước", model.Order{TotalAmount: 250000}, tickets(90000, 90000), true, 0, 1, 250000},
+		{"Hủy phần còn lại sau khi đã hủy lẻ", model.Order{TotalAmount: 250000, RefundAmount: 90000}, tickets(90000), true, 90000, 1, 160000},
+		{"Không hoàn quá số chưa hoàn", model.Order{TotalAmount: 250000, RefundAmount: 200000}, tickets(90000), false, 0, 1, 50000},
+		{"Đã hoàn hết thì không hoàn thêm", model.Order{TotalAmount: 250000, RefundAmount: 250000}, tickets(90000), true, 0, 1, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := cancelTicketsRefund(tc.order, tc.cancel, tc.cancelAll, tc.cancelledValue, tc.percent); got != tc.want {
+				t.Errorf("cancelTicketsRefund = %.0f, muốn %.0f", got, tc.want)
+			}
+		})
+	}
+}
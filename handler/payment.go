@@ -6,6 +6,7 @@ import (
 	"cinema_manager/model"
 	"errors"
 	"fmt"
+	"log"
 	"math/rand"
 	"net/url"
 	"os"
@@ -108,6 +109,15 @@ func VNPayCallback(c *fiber.Ctx) error {
 		var order model.Order
 		database.DB.Where("id = ?", payment.OrderId).First(&order)
 		database.DB.Model(&order).Update("status", "PAID")
+		if err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := helper.DeductOrderStock(tx, order.ID, 0, true); err != nil {
+				return err
+			}
+			_, err := helper.EarnOrderPoints(tx, order.ID)
+			return err
+		}); err != nil {
+			log.Printf("Không trừ được kho/tích điểm cho đơn %d: %v", order.ID, err)
+		}
 
 		// Redirect success
 		return c.Redirect(fmt.Sprintf("%s/success?orderId=%d", os.Getenv("APP_URL"), payment.OrderId))
@@ -131,6 +141,15 @@ func VNPayIPN(c *fiber.Ctx) error {
 		if payment.ID > 0 {
 			database.DB.Model(&payment).Update("status", "PAID")
 			database.DB.Model(&model.Order{}).Where("id = ?", payment.OrderId).Update("status", "PAID")
+			if err := database.DB.Transaction(func(tx *gorm.DB) error {
+				if err := helper.DeductOrderStock(tx, payment.OrderId, 0, true); err != nil {
+					return err
+				}
+				_, err := helper.EarnOrderPoints(tx, payment.OrderId)
+				return err
+			}); err != nil {
+				log.Printf("Không trừ được kho/tích điểm cho đơn %d: %v", payment.OrderId, err)
+			}
 		}
 
 		// Response cho VNPay
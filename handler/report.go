@@ -86,6 +86,87 @@ func StaffCheckInReport(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// Báo cáo quầy vé theo hình thức thanh toán (tiền mặt, thẻ, thẻ quà tặng...); lọc theo nhân viên bằng ?staffId=
+func BoxOfficeTenderReport(c *fiber.Ctx) error {
+	fromStr := c.Query("from", time.Now().AddDate(0, 0, -7).Format("2006-01-02"))
+	toStr := c.Query("to", time.Now().Format("2006-01-02"))
+
+	from, _ := time.Parse("2006-01-02", fromStr)
+	to, _ := time.Parse("2006-01-02", toStr)
+
+	accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Không có quyền truy cập báo cáo", nil)
+	}
+
+	var cinemaID *uint
+	if isManager {
+		if accountInfo.CinemaId == nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Quản lý chưa được gán rạp", nil)
+		}
+		cinemaID = accountInfo.CinemaId
+	}
+	var staffID *uint
+	if staffStr := c.Query("staffId"); staffStr != "" {
+		id, err := strconv.ParseUint(staffStr, 10, 64)
+		if err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "staffId không hợp lệ", err, "staffId")
+		}
+		staff := uint(id)
+		staffID = &staff
+	}
+
+	report, summary, err := utils.GetBoxOfficeTenderReport(database.DB, from, to, cinemaID, staffID)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Lỗi tải báo cáo quầy vé", err)
+	}
+
+	return utils.SuccessResponse(c, 200, fiber.Map{
+		"report":  report,
+		"summary": summary,
+		"period": fiber.Map{
+			"from": from.Format("02/01/2006"),
+			"to":   to.Format("02/01/2006"),
+		},
+	})
+}
+
+// Báo cáo chênh lệch két: tổng tiền dự kiến / tiền đếm thực tế của các ca đã đóng theo nhân viên
+func CashDrawerVarianceReport(c *fiber.Ctx) error {
+	fromStr := c.Query("from", time.Now().AddDate(0, 0, -7).Format("2006-01-02"))
+	toStr := c.Query("to", time.Now().Format("2006-01-02"))
+
+	from, _ := time.Parse("2006-01-02", fromStr)
+	to, _ := time.Parse("2006-01-02", toStr)
+
+	accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Không có quyền truy cập báo cáo", nil)
+	}
+
+	var cinemaID *uint
+	if isManager {
+		if accountInfo.CinemaId == nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Quản lý chưa được gán rạp", nil)
+		}
+		cinemaID = accountInfo.CinemaId
+	}
+
+	report, summary, err := utils.GetCashDrawerVarianceReport(database.DB, from, to, cinemaID)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Lỗi tải báo cáo két", err)
+	}
+
+	return utils.SuccessResponse(c, 200, fiber.Map{
+		"report":  report,
+		"summary": summary,
+		"period": fiber.Map{
+			"from": from.Format("02/01/2006"),
+			"to":   to.Format("02/01/2006"),
+		},
+	})
+}
 func NoShowDetailReport(c *fiber.Ctx) error {
 	fromStr := c.Query("from", time.Now().AddDate(0, 0, -7).Format("2006-01-02"))
 	toStr := c.Query("to", time.Now().Format("2006-01-02"))
@@ -313,12 +394,13 @@ func DashboardReport(c *fiber.Ctx) error {
 	totalPages := report.Pagination.TotalPages
 
 	return utils.SuccessResponse(c, 200, fiber.Map{
-		"kpi":              report.Summary,        // Bao gồm KPI
-		"top_movies":       report.TopMovies,      // Lấy từ query riêng nếu cần (hoặc append vào report)
-		"revenue_cinemas":  report.RevenueCinemas, // Tương tự
-		"daily_metrics":    report.DailyMetrics,
-		"ticket_by_hours":  report.TicketByHours,
-		"occupancy_trends": report.Trends,
+		"kpi":                report.Summary,        // Bao gồm KPI
+		"top_movies":         report.TopMovies,      // Lấy từ query riêng nếu cần (hoặc append vào report)
+		"revenue_cinemas":    report.RevenueCinemas, // Tương tự
+		"daily_metrics":      report.DailyMetrics,
+		"ticket_by_hours":    report.TicketByHours,
+		"occupancy_trends":   report.Trends,
+		"revenue_categories": report.Categories,
 		"pagination": fiber.Map{
 			"currentPage": page,
 			"totalPages":  totalPages,
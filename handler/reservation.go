@@ -0,0 +1,371 @@
+package handler
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateReservation: nhân viên chuyển các ghế đang giữ thành đơn đặt chỗ cho khách gọi điện.
+// Ghế được giữ đến hạn (mặc định 30 phút trước giờ chiếu), khách thanh toán tại quầy bằng mã đơn.
+func CreateReservation(c *fiber.Ctx) error {
+	input := c.Locals("reservationInput").(model.CreateReservationInput)
+	accountId := c.Locals("accountId").(uint)
+	cinemaId := c.Locals("cinemaId").(uint)
+	heldBy := fmt.Sprintf("STAFF_%d", accountId)
+
+	tx := database.DB.Begin()
+
+	var showtime model.Showtime
+	if err := tx.Preload("Room").Preload("Movie").Where("public_code = ?", c.Params("code")).First(&showtime).Error; err != nil {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 404, "Suất chiếu không tồn tại", err)
+	}
+	if showtime.Status == "CANCELLED" {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 400, "Suất chiếu đã bị hủy", nil)
+	}
+	if showtime.Room.CinemaId != cinemaId {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 403, "Suất chiếu không thuộc rạp của bạn", nil)
+	}
+	now := time.Now()
+	cutoff := helper.ReservationCutoff(showtime.StartTime, input.CutoffMinutes)
+	if !now.Before(cutoff) {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, fmt.Sprintf("Đã quá hạn đặt chỗ (trước %s)", cutoff.Format("15:04 02/01/2006")), nil, "cutoffMinutes")
+	}
+
+	var heldSeats []model.ShowtimeSeat
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("showtime_id = ? AND seat_id IN ? AND status = ? AND held_by = ?", showtime.ID, input.SeatIds, SeatHeld, heldBy).
+		Preload("Seat.SeatType").
+		Preload("Showtime").
+		Find(&heldSeats).Error; err != nil || len(heldSeats) != len(input.SeatIds) {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 400, "Một số ghế không hợp lệ hoặc đã hết hạn giữ chỗ", nil)
+	}
+
+	seatCategories, err := helper.ResolveSeatCategories(tx, showtime.Movie, input.SeatIds, input.SeatCategories)
+	if err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "seatCategories")
+	}
+	totalAmount := CalculateCategorizedAmount(heldSeats, seatCategories)
+	order := model.Order{
+		PublicCode:      "ORD-" + uuid.New().String()[:8],
+		CustomerName:    input.CustomerName,
+		Phone:           input.Phone,
+		Email:           input.Email,
+		Status:          model.OrderReserved,
+		CreatedBy:       accountId,
+		ShowtimeID:      showtime.ID,
+		TotalAmount:     totalAmount,
+		ReservedUntil:   &cutoff,
+		ReservationNote: input.Note,
+	}
+	if err := tx.Create(&order).Error; err != nil {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 500, "Không thể tạo đơn đặt chỗ", err)
+	}
+
+	holder := helper.ReservationHolder(order.PublicCode)
+	tickets := make([]model.Ticket, 0, len(heldSeats))
+	for i := range heldSeats {
+		stSeat := &heldSeats[i]
+		category := helper.SeatCategory(seatCategories, stSeat.SeatId)
+		ticket := model.Ticket{
+			OrderId:        order.ID,
+			ShowtimeId:     showtime.ID,
+			ShowtimeSeatId: &stSeat.ID,
+			SeatId:         stSeat.SeatId,
+			TicketCode:     "TKT-" + uuid.New().String()[:10],
+			Price:          helper.TicketCategoryPrice(category, float64(showtime.Price), stSeat.Seat.SeatType.PriceModifier),
+			Status:         helper.SeatReserved,
+			BookingTime:    now,
+			IssuedAt:       now,
+			CreatedBy:      accountId,
+		}
+		helper.ApplyTicketCategory(&ticket, category)
+		if err := tx.Create(&ticket).Error; err != nil {
+			tx.Rollback()
+			return utils.ErrorResponse(c, 500, "Không thể tạo vé", err)
+		}
+		tickets = append(tickets, ticket)
+
+		if err := tx.Model(stSeat).Updates(map[string]any{
+			"status":     helper.SeatReserved,
+			"held_by":    holder,
+			"expired_at": cutoff,
+		}).Error; err != nil {
+			tx.Rollback()
+			return utils.ErrorResponse(c, 500, "Không thể giữ ghế cho đơn đặt chỗ", err)
+		}
+		stSeat.Status = helper.SeatReserved
+		stSeat.HeldBy = holder
+		stSeat.ExpiredAt = &cutoff
+	}
+
+	tx.Commit()
+	BroadcastSeatChange(showtime.ID, heldSeats)
+
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message":       "Đặt chỗ thành công",
+		"orderCode":     order.PublicCode,
+		"reservedUntil": cutoff,
+		"totalAmount":   totalAmount,
+		"tickets":       tickets,
+	})
+}
+
+// GetReservations: danh sách đơn đặt chỗ của rạp (tra cứu theo số điện thoại, suất chiếu)
+func GetReservations(c *fiber.Ctx) error {
+	accountInfo, isAdmin, isManager, _, isStaff := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager && !isStaff {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	filter := new(model.FilterReservationInput)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+
+	db := database.DB.Model(&model.Order{}).
+		Joins("JOIN showtimes ON showtimes.id = orders.showtime_id").
+		Joins("JOIN rooms ON rooms.id = showtimes.room_id").
+		Where("orders.reserved_until IS NOT NULL")
+	if !isAdmin {
+		if accountInfo.CinemaId == nil {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Tài khoản chưa được gán rạp", nil)
+		}
+		db = db.Where("rooms.cinema_id = ?", *accountInfo.CinemaId)
+	}
+	if filter.Status != "" {
+		db = db.Where("orders.status = ?", filter.Status)
+	}
+	if filter.Phone != "" {
+		db = db.Where("orders.phone LIKE ?", "%"+filter.Phone+"%")
+	}
+	if filter.ShowtimeCode != "" {
+		db = db.Where("showtimes.public_code = ?", filter.ShowtimeCode)
+	}
+
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var orders []model.Order
+	db.Preload("Showtime.Movie").Preload("Tickets").
+		Order("orders.reserved_until ASC, orders.id DESC").
+		Find(&orders)
+	return utils.SuccessResponse(c, fiber.StatusOK, &model.ResponseCustom{
+		Rows:       orders,
+		Limit:      filter.Limit,
+		Page:       filter.Page,
+		TotalCount: total,
+	})
+}
+
+// GetReservationByCode: tra cứu đơn đặt chỗ tại quầy theo mã đơn
+func GetReservationByCode(c *fiber.Ctx) error {
+	order := c.Locals("reservation").(model.Order)
+	seats := make([]string, 0, len(order.Tickets))
+	for _, ticket := range order.Tickets {
+		seat := ticket.ShowtimeSeat.Seat
+		if seat.ID != 0 {
+			seats = append(seats, helper.TicketSeatLabel(ticket))
+		}
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"orderCode":     order.PublicCode,
+		"status":        order.Status,
+		"customerName":  order.CustomerName,
+		"phone":         order.Phone,
+		"email":         order.Email,
+		"movieTitle":    order.Showtime.Movie.Title,
+		"showtime":      order.Showtime.StartTime.Format("15:04 - 02/01/2006"),
+		"room":          order.Showtime.Room.Name,
+		"seats":         seats,
+		"totalAmount":   order.TotalAmount,
+		"reservedUntil": order.ReservedUntil,
+		"note":          order.ReservationNote,
+	})
+}
+
+// lockReservation khóa đơn đặt chỗ trong tx và kiểm tra lại trạng thái (tránh tranh chấp với worker hết hạn)
+func lockReservation(tx *gorm.DB, orderId uint) (model.Order, error) {
+	var order model.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderId).Error; err != nil {
+		return order, err
+	}
+	if order.Status != model.OrderReserved {
+		return order, fmt.Errorf("đơn đặt chỗ đang ở trạng thái %s", order.Status)
+	}
+	return order, nil
+}
+
+// PayReservation: khách đến quầy thanh toán đơn đặt chỗ → đơn PAID, vé PAID, ghế SOLD
+func PayReservation(c *fiber.Ctx) error {
+	reservation := c.Locals("reservation").(model.Order)
+	input := c.Locals("payReservationInput").(model.PayReservationInput)
+	accountId := c.Locals("accountId").(uint)
+
+	tx := database.DB.Begin()
+	order, err := lockReservation(tx, reservation.ID)
+	if err != nil {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 400, err.Error(), err)
+	}
+	now := time.Now()
+	if order.ReservedUntil != nil && now.After(*order.ReservedUntil) {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 400, "Đơn đặt chỗ đã quá hạn thanh toán", nil)
+	}
+
+	paymentLines := []model.OrderPayment{{Method: input.PaymentMethod, Amount: order.TotalAmount, CreatedBy: accountId}}
+	if len(input.Payments) > 0 {
+		paymentLines, err = helper.BuildCounterPaymentLines(tx, input.Payments, order.TotalAmount, accountId)
+		if err != nil {
+			tx.Rollback()
+			return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "payments")
+		}
+	}
+	updates := map[string]interface{}{
+		"status":         "PAID",
+		"paid_at":        now,
+		"actual_revenue": order.TotalAmount,
+		"payment_method": helper.PaymentMethodLabel(paymentLines),
+	}
+	for _, line := range paymentLines {
+		if line.Method == model.TenderGiftCard {
+			order.GiftCardAmount += line.Amount
+			if order.GiftCardId == nil {
+				order.GiftCardId = line.GiftCardId
+			}
+		}
+	}
+	if order.GiftCardAmount > 0 {
+		updates["gift_card_amount"] = order.GiftCardAmount
+		updates["gift_card_id"] = order.GiftCardId
+	}
+	if err := tx.Model(&order).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 500, "Không thể cập nhật đơn hàng", err)
+	}
+	order.Status = "PAID"
+	order.PaidAt = &now
+	order.PaymentMethod = updates["payment_method"].(string)
+	if err := helper.SaveOrderPayments(tx, &order, paymentLines); err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "payments")
+	}
+	if err := helper.RecordOrderCashSale(tx, accountId, &order); err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "cashDrawer")
+	}
+
+	if err := tx.Model(&model.Ticket{}).
+		Where("order_id = ? AND status = ?", order.ID, helper.SeatReserved).
+		Updates(map[string]interface{}{"status": "PAID", "issued_at": now}).Error; err != nil {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 500, "Không thể xuất vé", err)
+	}
+	var seats []model.ShowtimeSeat
+	if err := tx.Preload("Seat.SeatType").
+		Where("showtime_id = ? AND status = ? AND held_by = ?", order.ShowtimeID, helper.SeatReserved, helper.ReservationHolder(order.PublicCode)).
+		Find(&seats).Error; err != nil {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 500, "Không thể tải ghế của đơn", err)
+	}
+	for i := range seats {
+		if err := tx.Model(&seats[i]).Updates(map[string]any{
+			"status":     "SOLD",
+			"held_by":    "",
+			"expired_at": nil,
+		}).Error; err != nil {
+			tx.Rollback()
+			return utils.ErrorResponse(c, 500, "Không thể cập nhật ghế", err)
+		}
+		seats[i].Status = "SOLD"
+		seats[i].HeldBy = ""
+		seats[i].ExpiredAt = nil
+	}
+
+	tx.Commit()
+	BroadcastSeatChange(order.ShowtimeID, seats)
+
+	return utils.SuccessResponse(c, 200, fiber.Map{
+		"message":     "Thanh toán đơn đặt chỗ thành công",
+		"orderCode":   order.PublicCode,
+		"totalAmount": order.TotalAmount,
+		"payments":    order.Payments,
+	})
+}
+
+// CancelReservation: hủy đơn đặt chỗ theo yêu cầu của khách, trả ghế về trạng thái trống
+func CancelReservation(c *fiber.Ctx) error {
+	reservation := c.Locals("reservation").(model.Order)
+
+	var seats []model.ShowtimeSeat
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockReservation(tx, reservation.ID)
+		if err != nil {
+			return err
+		}
+		seats, err = helper.ReleaseReservation(tx, &order, time.Now())
+		return err
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error(), err)
+	}
+	BroadcastSeatChange(reservation.ShowtimeID, seats)
+
+	return utils.SuccessResponse(c, 200, fiber.Map{
+		"message":   "Hủy đơn đặt chỗ thành công",
+		"orderCode": reservation.PublicCode,
+	})
+}
+
+// ExpireReservations tự hủy các đơn đặt chỗ quá hạn chưa thanh toán và giải phóng ghế
+func ExpireReservations() {
+	db := database.DB
+	now := time.Now()
+
+	var ids []uint
+	if err := db.Model(&model.Order{}).
+		Where("status = ? AND reserved_until < ?", model.OrderReserved, now).
+		Limit(200).
+		Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+		return
+	}
+
+	for _, id := range ids {
+		var order model.Order
+		var seats []model.ShowtimeSeat
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			order, err = lockReservation(tx, id)
+			if err != nil {
+				return err
+			}
+			seats, err = helper.ReleaseReservation(tx, &order, now)
+			return err
+		})
+		if err != nil {
+			log.Printf("Không thể hủy đơn đặt chỗ quá hạn #%d: %v", id, err)
+			continue
+		}
+		log.Printf("Đã hủy đơn đặt chỗ quá hạn %s (%d ghế)", order.PublicCode, len(seats))
+		BroadcastSeatChange(order.ShowtimeID, seats)
+	}
+}
@@ -66,7 +66,9 @@ func GetScheduleTemplateById(c *fiber.Ctx) error {
 	scheduleId := c.Locals("scheduleTemplateId").(uint)
 	db := database.DB
 	var schedule model.ScheduleTemplate
-	db.First(&schedule, scheduleId)
+	db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("room_id, time")
+	}).First(&schedule, scheduleId)
 	return utils.SuccessResponse(c, fiber.StatusOK, schedule)
 
 }
@@ -90,6 +92,7 @@ func CreateScheduleTemplate(c *fiber.Ctx) error {
 		MaxRooms:    input.MaxRooms,
 		MaxPerDay:   input.MaxPerDay,
 		Priority:    input.Priority,
+		FormatRules: input.FormatRules,
 		CreatedBy:   accountInfo.AccountId,
 	}
 
@@ -148,6 +151,9 @@ func UpdateSchedulerTemplate(c *fiber.Ctx) error {
 	if input.Priority != nil {
 		template.Priority = *input.Priority
 	}
+	if input.FormatRules != nil {
+		template.FormatRules = input.FormatRules
+	}
 	if isAdmin || isManager {
 		template.CreatedBy = accountInfo.AccountId
 	}
@@ -174,6 +180,12 @@ func DeleteScheduleTemplate(c *fiber.Ctx) error {
 
 	db := database.DB
 	tx := db.Begin()
+	if err := tx.Where("schedule_template_id = ?", scheduleTemplateId).Delete(&model.ScheduleTemplateItem{}).Error; err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Không thể xóa các suất của mẫu: %s", err.Error()),
+		})
+	}
 	if err := tx.Where("id = ?", scheduleTemplateId).Delete(&model.ScheduleTemplate{}).Error; err != nil {
 		tx.Rollback()
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -190,3 +202,78 @@ func DeleteScheduleTemplate(c *fiber.Ctx) error {
 		"message": "Xóa mẫu thành công",
 	})
 }
+
+// GetDefaultFormatRules trả về quy tắc định dạng mặc định (dùng khi mẫu lịch không khai báo)
+func GetDefaultFormatRules(c *fiber.Ctx) error {
+	_, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	rules := make([]model.FormatRule, 0, len(helper.DefaultFormatRules))
+	for _, format := range []string{"2D", "3D", "4DX", "IMAX"} {
+		rules = append(rules, helper.DefaultFormatRules[format])
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, rules)
+}
+
+// GetScheduleTemplateItems danh sách suất trong mẫu lịch, sắp theo phòng và giờ
+func GetScheduleTemplateItems(c *fiber.Ctx) error {
+	scheduleTemplateId := c.Locals("scheduleTemplateId").(uint)
+	var items []model.ScheduleTemplateItem
+	if err := database.DB.Where("schedule_template_id = ?", scheduleTemplateId).
+		Order("room_id, time").Find(&items).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không lấy được danh sách suất", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, items)
+}
+
+func CreateScheduleTemplateItem(c *fiber.Ctx) error {
+	scheduleTemplateId := c.Locals("scheduleTemplateId").(uint)
+	input := c.Locals("createItemInput").(model.CreateScheduleTemplateItemInput)
+
+	item := model.ScheduleTemplateItem{
+		ScheduleTemplateId: scheduleTemplateId,
+		RoomId:             input.RoomId,
+		Time:               input.Time,
+		Format:             input.Format,
+		LanguageType:       model.LangViSub,
+	}
+	if input.LanguageType != "" {
+		item.LanguageType = model.LanguageType(input.LanguageType)
+	}
+	if err := database.DB.Create(&item).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể thêm suất vào mẫu", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, item)
+}
+
+func UpdateScheduleTemplateItem(c *fiber.Ctx) error {
+	item := c.Locals("scheduleTemplateItem").(model.ScheduleTemplateItem)
+	input := c.Locals("updateItemInput").(model.UpdateScheduleTemplateItemInput)
+
+	if input.RoomId != nil {
+		item.RoomId = *input.RoomId
+	}
+	if input.Time != nil {
+		item.Time = *input.Time
+	}
+	if input.Format != nil {
+		item.Format = *input.Format
+	}
+	if input.LanguageType != nil {
+		item.LanguageType = model.LanguageType(*input.LanguageType)
+	}
+	item.Room = model.Room{}
+	if err := database.DB.Save(&item).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật suất trong mẫu", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, item)
+}
+
+func DeleteScheduleTemplateItem(c *fiber.Ctx) error {
+	item := c.Locals("scheduleTemplateItem").(model.ScheduleTemplateItem)
+	if err := database.DB.Delete(&model.ScheduleTemplateItem{}, item.ID).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể xóa suất trong mẫu", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Đã xóa suất khỏi mẫu")
+}
@@ -10,6 +10,7 @@ import (
 	"html/template"
 	"io"
 	"log"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -217,6 +218,14 @@ func HoldSeat(c *fiber.Ctx) error {
 		tx.Rollback()
 		return utils.ErrorResponse(c, 400, "Showtime already started", nil)
 	}
+	if showtime.Status == "CANCELLED" {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 400, "Suất chiếu đã bị hủy", nil)
+	}
+	if err := helper.CheckPresaleAccess(tx, customer, showtime, time.Now()); err != nil {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 403, err.Error(), err)
+	}
 
 	var updatedSeats []model.ShowtimeSeat
 	for _, seatId := range input.SeatIds {
@@ -376,12 +385,21 @@ func PurchaseSeats(c *fiber.Ctx) error {
 	code := c.Params("code")
 
 	var input struct {
-		SeatIds       []uint `json:"seatIds" validate:"required"`
-		HeldBy        string `json:"heldBy" validate:"required"`
-		PaymentMethod string `json:"paymentMethod" validate:"required"`
-		CustomerName  string `json:"customer_name"`
-		Phone         string `json:"phone"`
-		Email         string `json:"email,omitempty"`
+		SeatIds       []uint                      `json:"seatIds" validate:"required"`
+		HeldBy        string                      `json:"heldBy" validate:"required"`
+		PaymentMethod string                      `json:"paymentMethod" validate:"required"`
+		CustomerName  string                      `json:"customer_name"`
+		Phone         string                      `json:"phone"`
+		Email         string                      `json:"email,omitempty"`
+		Concessions   []model.ConcessionLineInput `json:"concessions"`
+		RedeemPoints  int                         `json:"redeemPoints"`
+		VoucherCode   string                      `json:"voucherCode"`
+		GiftCard      *model.GiftCardPaymentInput `json:"giftCard"`
+		WalletAmount  float64                     `json:"walletAmount"`
+		// Loại vé từng ghế (trẻ em, HSSV...); ghế không khai báo tính giá thường
+		SeatCategories []model.SeatCategoryInput `json:"seatCategories"`
+		// Phim T13/T16/T18: xác nhận người xem đủ tuổi (bắt buộc khi chưa khai ngày sinh)
+		AgeConfirmed bool `json:"ageConfirmed"`
 	}
 
 	if err := c.BodyParser(&input); err != nil {
@@ -390,6 +408,19 @@ func PurchaseSeats(c *fiber.Ctx) error {
 
 	//log.Printf("PurchaseSeats - input: %+v", input)
 
+	// Lấy customer từ Locals
+	customer, isLoggedIn := c.Locals("customer").(*model.Customer)
+	//log.Printf("PurchaseSeats - isLoggedIn: %v, customer: %+v", isLoggedIn, customer)
+	if input.RedeemPoints < 0 || (input.RedeemPoints > 0 && (!isLoggedIn || customer == nil)) {
+		return utils.ErrorResponseHaveKey(c, 400, "Chỉ thành viên đăng nhập mới được dùng điểm", nil, "redeemPoints")
+	}
+	if input.VoucherCode != "" && (!isLoggedIn || customer == nil) {
+		return utils.ErrorResponseHaveKey(c, 400, "Chỉ thành viên đăng nhập mới được dùng voucher", nil, "voucherCode")
+	}
+	if input.WalletAmount < 0 || (input.WalletAmount > 0 && (!isLoggedIn || customer == nil)) {
+		return utils.ErrorResponseHaveKey(c, 400, "Chỉ thành viên đăng nhập mới được thanh toán bằng ví", nil, "walletAmount")
+	}
+
 	tx := db.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -402,6 +433,15 @@ func PurchaseSeats(c *fiber.Ctx) error {
 		tx.Rollback()
 		return utils.ErrorResponse(c, 404, "Suất chiếu không tồn tại", err)
 	}
+	// Phim giới hạn độ tuổi: chặn khách chưa đủ tuổi theo ngày sinh, chưa khai ngày sinh thì phải xác nhận
+	var buyerDob *time.Time
+	if isLoggedIn && customer != nil {
+		buyerDob = customer.DateOfBirth
+	}
+	if err := helper.CheckBuyerAge(showtime.Movie.AgeRestriction, buyerDob, showtime.StartTime, input.AgeConfirmed); err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "ageConfirmed")
+	}
 
 	var heldSeats []model.ShowtimeSeat
 	if err := tx.Where("showtime_id = ? AND seat_id IN ? AND status = ? AND held_by = ?",
@@ -413,33 +453,115 @@ func PurchaseSeats(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, 400, "Một số ghế không hợp lệ hoặc đã hết hạn giữ chỗ", nil)
 	}
 
-	totalAmount := CalculateTotalAmount(heldSeats)
+	concessions, concessionAmount, err := helper.BuildOrderConcessions(tx, helper.ShowtimeCinemaId(tx, showtime), input.Concessions)
+	if err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "concessions")
+	}
+	seatCategories, err := helper.ResolveSeatCategories(tx, showtime.Movie, input.SeatIds, input.SeatCategories)
+	if err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "seatCategories")
+	}
+	ticketAmount := CalculateCategorizedAmount(heldSeats, seatCategories)
+
+	// Quyền lợi hạng thành viên trên tiền vé (nâng hạng ghế VIP, giảm %)
+	var memberTier *model.MembershipTier
+	if isLoggedIn && customer != nil {
+		memberTier = helper.GetCustomerTier(tx, customer)
+	}
+	memberDiscount, upgradedSeats := helper.CalculateMemberDiscount(tx, memberTier, heldSeats, ticketAmount)
+	upgradedSeatIds := make([]uint, 0, len(upgradedSeats))
+	for _, seat := range heldSeats {
+		if upgradedSeats[seat.ID] {
+			upgradedSeatIds = append(upgradedSeatIds, seat.SeatId)
+		}
+	}
+	totalAmount := ticketAmount - memberDiscount + concessionAmount
+
+	// Voucher sinh nhật
+	var voucher model.MemberVoucher
+	voucherDiscount := 0.0
+	if input.VoucherCode != "" {
+		voucher, voucherDiscount, err = helper.LockMemberVoucher(tx, customer.ID, input.VoucherCode, totalAmount)
+		if err != nil {
+			tx.Rollback()
+			return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "voucherCode")
+		}
+		totalAmount -= voucherDiscount
+	}
+
+	// Dùng điểm thành viên giảm trừ vào tổng đơn
+	loyaltyDiscount, err := helper.LoyaltyRedeemDiscount(helper.GetLoyaltySetting(tx), input.RedeemPoints, totalAmount)
+	if err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "redeemPoints")
+	}
+	totalAmount -= loyaltyDiscount
+
+	// Thanh toán một phần hoặc toàn bộ bằng ví / thẻ quà tặng, phần còn lại qua PaymentMethod
+	if input.WalletAmount > totalAmount {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, "Số tiền trừ ví vượt quá tổng đơn", nil, "walletAmount")
+	}
+	var giftCard model.GiftCard
+	giftCardAmount := 0.0
+	if input.GiftCard != nil {
+		if input.GiftCard.Code == "" || input.GiftCard.Pin == "" || input.GiftCard.Amount < 0 {
+			tx.Rollback()
+			return utils.ErrorResponseHaveKey(c, 400, "Vui lòng nhập mã thẻ và PIN", nil, "giftCard")
+		}
+		giftCard, err = helper.LockGiftCard(tx, input.GiftCard.Code, input.GiftCard.Pin)
+		if err != nil {
+			tx.Rollback()
+			return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "giftCard")
+		}
+		remaining := totalAmount - input.WalletAmount
+		giftCardAmount = input.GiftCard.Amount
+		if giftCardAmount == 0 {
+			giftCardAmount = math.Min(giftCard.Balance, remaining)
+		}
+		if giftCardAmount > remaining || giftCardAmount > giftCard.Balance {
+			tx.Rollback()
+			return utils.ErrorResponseHaveKey(c, 400, fmt.Sprintf("Số tiền dùng thẻ vượt quá số dư (%.0fđ) hoặc số còn phải trả", giftCard.Balance), nil, "giftCard")
+		}
+	}
 
 	now := time.Now()
 	order := model.Order{
-		PublicCode:    "ORD-" + uuid.New().String()[:8],
-		CustomerID:    nil,
-		ShowtimeID:    showtime.ID,
-		TotalAmount:   totalAmount,
-		ActualRevenue: totalAmount,
-		Status:        "PAID",
-		PaymentMethod: input.PaymentMethod,
-		PaidAt:        &now,
-		CustomerName:  input.CustomerName,
-		Phone:         input.Phone,
-		Email:         input.Email,
+		PublicCode:       "ORD-" + uuid.New().String()[:8],
+		CustomerID:       nil,
+		ShowtimeID:       showtime.ID,
+		TotalAmount:      totalAmount,
+		ActualRevenue:    totalAmount,
+		ConcessionAmount: concessionAmount,
+		PointsRedeemed:   input.RedeemPoints,
+		LoyaltyDiscount:  loyaltyDiscount,
+		MemberDiscount:   memberDiscount,
+		VoucherDiscount:  voucherDiscount,
+		GiftCardAmount:   giftCardAmount,
+		WalletAmount:     input.WalletAmount,
+		AgeConfirmed:     input.AgeConfirmed && helper.MinimumAge(showtime.Movie.AgeRestriction) > 0,
+		Status:           "PAID",
+		PaymentMethod:    input.PaymentMethod,
+		PaidAt:           &now,
+		CustomerName:     input.CustomerName,
+		Phone:            input.Phone,
+		Email:            input.Email,
 	}
 
-	// Lấy customer từ Locals
-	customer, isLoggedIn := c.Locals("customer").(*model.Customer)
-	//log.Printf("PurchaseSeats - isLoggedIn: %v, customer: %+v", isLoggedIn, customer)
-
 	if isLoggedIn && customer != nil {
 		order.CustomerID = &customer.ID
 		//log.Printf("PurchaseSeats - Customer ID: %d", customer.ID)
+		if voucher.ID != 0 {
+			order.VoucherId = &voucher.ID
+		}
 	} else {
 		//log.Println("PurchaseSeats - Guest checkout")
 	}
+	if giftCard.ID != 0 {
+		order.GiftCardId = &giftCard.ID
+	}
 
 	if err := tx.Create(&order).Error; err != nil {
 		tx.Rollback()
@@ -455,10 +577,11 @@ func PurchaseSeats(c *fiber.Ctx) error {
 			seat.Seat.SeatType.Type,
 			seat.Seat.SeatType.PriceModifier,
 			showtime.Price*seat.Seat.SeatType.PriceModifier)
-		ticketPrice := showtime.Price * seat.Seat.SeatType.PriceModifier
+		category := helper.SeatCategory(seatCategories, seat.SeatId)
+		ticketPrice := helper.TicketCategoryPrice(category, showtime.Price, seat.Seat.SeatType.PriceModifier)
 		ticket := model.Ticket{
 			OrderId:        order.ID,
-			ShowtimeSeatId: seat.ID,
+			ShowtimeSeatId: &seat.ID,
 			TicketCode:     "TKT-" + uuid.New().String()[:10],
 			Status:         "ISSUED",
 			IssuedAt:       time.Now(),
@@ -466,6 +589,7 @@ func PurchaseSeats(c *fiber.Ctx) error {
 			SeatId:         seat.SeatId, // ← Sửa: Lấy SeatId từ ShowtimeSeat (id ghế thật)
 			ShowtimeId:     showtime.ID, // ← Thêm nếu cần
 		}
+		helper.ApplyTicketCategory(&ticket, category)
 		tickets = append(tickets, ticket)
 
 		if err := tx.Model(&seat).Updates(map[string]any{
@@ -477,13 +601,52 @@ func PurchaseSeats(c *fiber.Ctx) error {
 			return utils.ErrorResponse(c, 500, "Không thể cập nhật trạng thái ghế", err)
 		}
 
-		seatLabels = append(seatLabels, fmt.Sprintf("%s%d", seat.Seat.Row, seat.Seat.Column))
+		seatLabel := fmt.Sprintf("%s%d", seat.Seat.Row, seat.Seat.Column)
+		if category != nil {
+			seatLabel += " (" + category.Name + ")"
+		}
+		seatLabels = append(seatLabels, seatLabel)
 	}
 
 	if err := tx.Create(&tickets).Error; err != nil {
 		tx.Rollback()
 		return utils.ErrorResponse(c, 500, "Không thể tạo vé", err)
 	}
+	if err := helper.CreateOrderConcessions(tx, order.ID, concessions); err != nil {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 500, "Không thể lưu bắp nước", err)
+	}
+	if err := helper.DeductOrderStock(tx, order.ID, 0, false); err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "concessions")
+	}
+	// Dòng thanh toán: ví, thẻ quà tặng, phần còn lại qua PaymentMethod
+	paymentLines := []model.OrderPayment{
+		{Method: model.TenderWallet, Amount: input.WalletAmount},
+		{Method: model.TenderGiftCard, Amount: giftCardAmount, GiftCardId: order.GiftCardId, Reference: giftCard.Code},
+		{Method: input.PaymentMethod, Amount: totalAmount - input.WalletAmount - giftCardAmount},
+	}
+	if err := helper.SaveOrderPayments(tx, &order, paymentLines); err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "payments")
+	}
+	if order.VoucherId != nil {
+		if err := helper.UseMemberVoucher(tx, *order.VoucherId, order.ID); err != nil {
+			tx.Rollback()
+			return utils.ErrorResponse(c, 500, "Không thể sử dụng voucher", err)
+		}
+	}
+	if order.CustomerID != nil {
+		if err := helper.RedeemOrderPoints(tx, *order.CustomerID, order.ID, input.RedeemPoints); err != nil {
+			tx.Rollback()
+			return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "redeemPoints")
+		}
+		if order.PointsEarned, err = helper.EarnOrderPoints(tx, order.ID); err != nil {
+			tx.Rollback()
+			return utils.ErrorResponse(c, 500, "Không thể tích điểm thành viên", err)
+		}
+	}
+	order.Concessions = concessions
 
 	tx.Commit()
 
@@ -507,6 +670,7 @@ func PurchaseSeats(c *fiber.Ctx) error {
 			MovieName:     showtime.Movie.Title,
 			Showtime:      showtime.StartTime.Format("02/01/2006 15:04"),
 			Seats:         strings.Join(seatLabels, ", "),
+			Concessions:   helper.ConcessionLabels(concessions),
 			TotalAmount:   totalAmount,
 			PaymentMethod: order.PaymentMethod,
 			DetailLink:    detailLink,
@@ -536,10 +700,16 @@ func PurchaseSeats(c *fiber.Ctx) error {
 			m.SetHeader("Subject", "Vé xem phim - Mã đơn: "+order.PublicCode)
 			m.SetBody("text/html", htmlBody.String())
 
-			// === TẠO QR VÀ NHÚNG INLINE VỚI CID ===
-			qrContent := order.PublicCode
-			qrBytes, err := utils.GenerateQRCode(qrContent, 400)
+			// === TẠO QR (ĐÃ KÝ) VÀ NHÚNG INLINE VỚI CID ===
+			ticketCodes := make([]string, 0, len(tickets))
+			for _, ticket := range tickets {
+				ticketCodes = append(ticketCodes, ticket.TicketCode)
+			}
+			qrContent, err := helper.OrderQRContent(order, showtime, ticketCodes)
+			var qrBytes []byte
 			if err != nil {
+				log.Printf("Lỗi ký QR: %v", err) // không nhúng QR, khách xem QR trong trang đơn hàng
+			} else if qrBytes, err = utils.GenerateQRCode(qrContent, 400); err != nil {
 				log.Printf("Lỗi tạo QR: %v", err)
 			} else {
 				// Nhúng inline từ memory bằng SetCopyFunc
@@ -572,11 +742,18 @@ func PurchaseSeats(c *fiber.Ctx) error {
 		"message":         "Thanh toán và tạo vé thành công",
 		"emailSent":       email != "",
 		"orderPublicCode": order.PublicCode,
+		"memberTier":      memberTier,
+		"upgradedSeatIds": upgradedSeatIds,
 	})
 }
 
 // Hàm tính tổng tiền (bạn cần implement theo logic giá ghế)
 func CalculateTotalAmount(seats []model.ShowtimeSeat) float64 {
+	return CalculateCategorizedAmount(seats, nil)
+}
+
+// CalculateCategorizedAmount: tổng tiền vé theo loại vé chọn cho từng ghế (map theo seat_id)
+func CalculateCategorizedAmount(seats []model.ShowtimeSeat, categories map[uint]model.TicketCategory) float64 {
 	var total float64
 	processedCouples := make(map[uint]bool) // Đánh dấu cặp đã tính
 
@@ -592,7 +769,7 @@ func CalculateTotalAmount(seats []model.ShowtimeSeat) float64 {
 			continue // Bỏ qua ghế thứ 2 của cặp
 		}
 
-		total += basePrice * modifier
+		total += helper.TicketCategoryPrice(helper.SeatCategory(categories, s.SeatId), basePrice, modifier)
 
 		if s.Seat.CoupleId != nil {
 			processedCouples[*s.Seat.CoupleId] = true
@@ -664,6 +841,7 @@ func StartExpireSeatWorker() {
 	go func() {
 		for range ticker.C {
 			ExpireSeats()
+			ExpireReservations()
 		}
 	}()
 }
@@ -772,6 +950,10 @@ func HoldSeatForStaff(c *fiber.Ctx) error {
 		tx.Rollback()
 		return utils.ErrorResponse(c, 404, "Showtime not found", err)
 	}
+	if showtime.Status == "CANCELLED" {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 400, "Suất chiếu đã bị hủy", nil)
+	}
 
 	var updatedSeats []model.ShowtimeSeat
 	heldBy := fmt.Sprintf("STAFF_%d", accountInfo.AccountId)
@@ -911,16 +1093,24 @@ func CreateTicketForStaff(c *fiber.Ctx) error {
 	code := c.Params("code")
 
 	var input struct {
-		SeatIds       []uint `json:"seatIds" validate:"required"`
-		CustomerName  string `json:"customerName"`
-		Phone         string `json:"phone"`
-		Email         string `json:"email"`
-		PaymentMethod string `json:"paymentMethod" validate:"required"`
+		SeatIds       []uint                      `json:"seatIds" validate:"required"`
+		CustomerName  string                      `json:"customerName"`
+		Phone         string                      `json:"phone"`
+		Email         string                      `json:"email"`
+		PaymentMethod string                      `json:"paymentMethod"`
+		Concessions   []model.ConcessionLineInput `json:"concessions"`
+		// Thanh toán nhiều hình thức; bỏ trống thì cả đơn thanh toán bằng PaymentMethod
+		Payments []model.PaymentLineInput `json:"payments"`
+		// Loại vé từng ghế (trẻ em, HSSV...); ghế không khai báo tính giá thường
+		SeatCategories []model.SeatCategoryInput `json:"seatCategories"`
 	}
 
 	if err := c.BodyParser(&input); err != nil {
 		return utils.ErrorResponse(c, 400, "Invalid input", err)
 	}
+	if input.PaymentMethod == "" && len(input.Payments) == 0 {
+		return utils.ErrorResponseHaveKey(c, 400, "Vui lòng chọn hình thức thanh toán", nil, "paymentMethod")
+	}
 
 	// Kiểm tra quyền STAFF
 	accountInfo, _, _, _, isBanve := helper.GetInfoAccountFromToken(c)
@@ -947,25 +1137,61 @@ func CreateTicketForStaff(c *fiber.Ctx) error {
 		tx.Rollback()
 		return utils.ErrorResponse(c, 400, "Một số ghế không hợp lệ hoặc đã hết hạn giữ chỗ", nil)
 	}
-	totalAmount := CalculateTotalAmount(heldSeats)
+	concessions, concessionAmount, err := helper.BuildOrderConcessions(tx, helper.ShowtimeCinemaId(tx, showtime), input.Concessions)
+	if err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "concessions")
+	}
+	seatCategories, err := helper.ResolveSeatCategories(tx, showtime.Movie, input.SeatIds, input.SeatCategories)
+	if err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "seatCategories")
+	}
+	totalAmount := CalculateCategorizedAmount(heldSeats, seatCategories) + concessionAmount
+	paymentLines := []model.OrderPayment{{Method: input.PaymentMethod, Amount: totalAmount, CreatedBy: accountInfo.AccountId}}
+	if len(input.Payments) > 0 {
+		paymentLines, err = helper.BuildCounterPaymentLines(tx, input.Payments, totalAmount, accountInfo.AccountId)
+		if err != nil {
+			tx.Rollback()
+			return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "payments")
+		}
+	}
 	now := time.Now()
 	order := model.Order{
-		PublicCode:    "ORD-" + uuid.New().String()[:8],
-		CustomerName:  input.CustomerName,
-		Phone:         input.Phone,
-		Email:         input.Email,
-		PaymentMethod: input.PaymentMethod,
-		Status:        "PAID",
-		PaidAt:        &now,
-		CreatedBy:     accountInfo.AccountId,
-		ShowtimeID:    showtime.ID,
-		TotalAmount:   totalAmount, // sẽ tính sau
-		ActualRevenue: totalAmount,
+		PublicCode:       "ORD-" + uuid.New().String()[:8],
+		CustomerName:     input.CustomerName,
+		Phone:            input.Phone,
+		Email:            input.Email,
+		PaymentMethod:    helper.PaymentMethodLabel(paymentLines),
+		Status:           "PAID",
+		PaidAt:           &now,
+		CreatedBy:        accountInfo.AccountId,
+		ShowtimeID:       showtime.ID,
+		TotalAmount:      totalAmount, // sẽ tính sau
+		ActualRevenue:    totalAmount,
+		ConcessionAmount: concessionAmount,
+	}
+	for _, line := range paymentLines {
+		if line.Method == model.TenderGiftCard {
+			order.GiftCardAmount += line.Amount
+			if order.GiftCardId == nil {
+				order.GiftCardId = line.GiftCardId
+			}
+		}
 	}
 	if err := tx.Create(&order).Error; err != nil {
 		tx.Rollback()
 		return utils.ErrorResponse(c, 500, "Không thể tạo đơn hàng", err)
 	}
+	if err := helper.SaveOrderPayments(tx, &order, paymentLines); err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "payments")
+	}
+	// Phần tiền mặt ghi vào ca thu ngân đang mở của nhân viên
+	if err := helper.RecordOrderCashSale(tx, accountInfo.AccountId, &order); err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "cashDrawer")
+	}
 	// Kiểm tra và tạo vé cho từng ghế
 	var tickets []model.Ticket
 	for _, seatId := range input.SeatIds {
@@ -979,18 +1205,20 @@ func CreateTicketForStaff(c *fiber.Ctx) error {
 			return utils.ErrorResponse(c, 400, fmt.Sprintf("Ghế %d không được giữ bởi bạn", seatId), err)
 		}
 		ticketCode := "TKT-" + uuid.New().String()[:10]
+		category := helper.SeatCategory(seatCategories, stSeat.SeatId)
 		// Tạo vé
 		ticket := model.Ticket{
 			OrderId:        order.ID, // ← QUAN TRỌNG: liên kết với Order
 			ShowtimeId:     showtime.ID,
-			ShowtimeSeatId: stSeat.ID, // ← liên kết với ghế trong suất
+			ShowtimeSeatId: &stSeat.ID, // ← liên kết với ghế trong suất
 			SeatId:         stSeat.SeatId,
 			TicketCode:     ticketCode,
-			Price:          float64(showtime.Price) * stSeat.SeatType.PriceModifier,
+			Price:          helper.TicketCategoryPrice(category, float64(showtime.Price), stSeat.SeatType.PriceModifier),
 			Status:         "PAID",
 			IssuedAt:       now,
 			CreatedBy:      accountInfo.AccountId,
 		}
+		helper.ApplyTicketCategory(&ticket, category)
 		if err := tx.Create(&ticket).Error; err != nil {
 			tx.Rollback()
 			return utils.ErrorResponse(c, 500, "Không thể tạo vé", err)
@@ -1007,12 +1235,23 @@ func CreateTicketForStaff(c *fiber.Ctx) error {
 			return utils.ErrorResponse(c, 500, "Không thể release ghế sau khi tạo vé", err)
 		}
 	}
+	if err := helper.CreateOrderConcessions(tx, order.ID, concessions); err != nil {
+		tx.Rollback()
+		return utils.ErrorResponse(c, 500, "Không thể lưu bắp nước", err)
+	}
+	if err := helper.DeductOrderStock(tx, order.ID, accountInfo.AccountId, false); err != nil {
+		tx.Rollback()
+		return utils.ErrorResponseHaveKey(c, 400, err.Error(), err, "concessions")
+	}
 
 	tx.Commit()
 
 	return utils.SuccessResponse(c, 200, fiber.Map{
-		"tickets": tickets,
-		"message": "Tạo vé thành công",
+		"tickets":     tickets,
+		"concessions": concessions,
+		"totalAmount": totalAmount,
+		"payments":    order.Payments,
+		"message":     "Tạo vé thành công",
 	})
 }
 
@@ -1056,6 +1295,14 @@ func CheckinByBookingCode(c *fiber.Ctx) error {
 func CheckinByOrderCode(c *fiber.Ctx) error {
 	type CheckinInput struct {
 		Code string `json:"code" validate:"required"`
+		// Phim T13/T16/T18, khách chưa đủ tuổi theo ngày sinh trong hồ sơ: nhân viên đã xem giấy tờ
+		// và cho vào (AgeOverride, bắt buộc ghi chú) hoặc từ chối (AgeDenied); cả hai đều ghi nhật ký
+		AgeOverride bool   `json:"ageOverride"`
+		AgeDenied   bool   `json:"ageDenied"`
+		AgeNote     string `json:"ageNote"`
+		// Mã đơn nhập tay (không phải QR có chữ ký): nhân viên phải bật ManualEntry và ghi lý do
+		ManualEntry  bool   `json:"manualEntry"`
+		ManualReason string `json:"manualReason"`
 	}
 
 	var input CheckinInput
@@ -1068,13 +1315,33 @@ func CheckinByOrderCode(c *fiber.Ctx) error {
 	if !isBanve {
 		return utils.ErrorResponse(c, 403, "FORBIDDEN", nil)
 	}
+
+	// QR có chữ ký: kiểm tra chữ ký, chỉ check-in các vé ghi trong QR. Hạn dùng kiểm tra theo suất chiếu
+	// hiện tại trong DB (bước 2) vì suất có thể đã bị dời sau khi QR được ký và gửi khách.
+	// Mã đơn không ký chỉ được nhận khi nhân viên xác nhận nhập tay kèm lý do, và được ghi nhật ký.
+	orderCode := input.Code
+	var qrPayload *utils.QRTicketPayload
+	if utils.IsSignedQR(input.Code) {
+		payload, err := utils.VerifyQRToken(input.Code)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, err.Error(), err)
+		}
+		qrPayload = &payload
+		orderCode = payload.OrderCode
+	} else if !input.ManualEntry {
+		return utils.ErrorResponseHaveKey(c, fiber.StatusForbidden, "Chỉ chấp nhận mã QR vé có chữ ký, vui lòng quét QR trong email hoặc trang đơn hàng. Nhập mã đơn thủ công cần xác nhận nhập tay", nil, "manualEntry")
+	} else if strings.TrimSpace(input.ManualReason) == "" {
+		return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Vui lòng ghi lý do check-in bằng mã nhập tay", nil, "manualReason")
+	}
+
 	// 1️⃣ Lấy order + tickets + seat + showtime + movie
 	var order model.Order
 	err := db.
+		Preload("Customer").
 		Preload("Tickets.ShowtimeSeat.Seat").
 		Preload("Tickets.Showtime.Movie").
 		Preload("Tickets.Showtime.Room").
-		Where("public_code = ?", input.Code).
+		Where("public_code = ?", orderCode).
 		First(&order).Error
 
 	if err != nil {
@@ -1089,12 +1356,37 @@ func CheckinByOrderCode(c *fiber.Ctx) error {
 	if len(order.Tickets) == 0 {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Đơn hàng không có vé", nil)
 	}
+	if order.Status == model.OrderReserved {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Đơn đặt chỗ chưa thanh toán, vui lòng thanh toán tại quầy trước khi check-in", nil)
+	}
+	if order.Status != "PAID" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("Đơn hàng đang ở trạng thái %s, không thể check-in", order.Status), nil)
+	}
 
 	showtime := order.Tickets[0].Showtime
 
+	if qrPayload != nil {
+		if showtime.PublicCode != qrPayload.ShowtimeCode {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.ErrQRInvalid.Error(), nil)
+		}
+		qrTickets := make(map[string]bool, len(qrPayload.Tickets))
+		for _, code := range qrPayload.Tickets {
+			qrTickets[code] = true
+		}
+		tickets := make([]model.Ticket, 0, len(qrPayload.Tickets))
+		for _, ticket := range order.Tickets {
+			if qrTickets[ticket.TicketCode] {
+				tickets = append(tickets, ticket)
+			}
+		}
+		if len(tickets) == 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Mã QR không chứa vé nào của đơn hàng", nil)
+		}
+		order.Tickets = tickets
+	}
 	// 2️⃣ KIỂM TRA SUẤT CHIẾU ĐÃ KẾT THÚC CHƯA
 	// Thời gian kết thúc ước tính = start_time + duration (phút) + 15 phút dọn phòng
-	endTime := showtime.StartTime.Add(time.Duration(showtime.Movie.Duration+15) * time.Minute)
+	endTime := helper.ShowtimeCheckinEnd(showtime)
 
 	if time.Now().After(endTime) {
 		return utils.ErrorResponse(
@@ -1120,12 +1412,93 @@ func CheckinByOrderCode(c *fiber.Ctx) error {
 		}
 	}
 
-	// 4️⃣ Check-in tất cả vé (transaction)
+	// Chỉ check-in vé đã thanh toán (PAID / ISSUED); vé đã hủy lẻ (kể cả còn ghi trong QR cũ) bị bỏ qua và báo lại
+	checkable := make([]model.Ticket, 0, len(order.Tickets))
+	skippedTickets := []fiber.Map{}
+	for _, ticket := range order.Tickets {
+		if ticket.Status == "PAID" || ticket.Status == "ISSUED" {
+			checkable = append(checkable, ticket)
+			continue
+		}
+		skippedTickets = append(skippedTickets, fiber.Map{"ticketCode": ticket.TicketCode, "status": ticket.Status})
+	}
+	if len(checkable) == 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Không có vé hợp lệ để check-in (vé đã hủy hoặc chưa thanh toán)", nil)
+	}
+	order.Tickets = checkable
+	ticketCodes := make([]string, 0, len(order.Tickets))
+	for _, ticket := range order.Tickets {
+		ticketCodes = append(ticketCodes, ticket.TicketCode)
+	}
+
+	// 4️⃣ Phim giới hạn độ tuổi: ngày sinh trong hồ sơ chưa đủ tuổi thì nhân viên phải xử lý và được ghi nhật ký
+	rating := showtime.Movie.AgeRestriction
+	minAge := helper.MinimumAge(rating)
+	var customerAge *int
+	if minAge > 0 && order.Customer != nil && order.Customer.DateOfBirth != nil {
+		age := helper.AgeAt(*order.Customer.DateOfBirth, showtime.StartTime)
+		customerAge = &age
+	}
+	var ageLog *model.AgeCheckLog
+	if customerAge != nil && *customerAge < minAge {
+		ageLog = &model.AgeCheckLog{
+			OrderId:     order.ID,
+			ShowtimeId:  showtime.ID,
+			AccountId:   accountInfo.AccountId,
+			Rating:      rating,
+			MinimumAge:  minAge,
+			CustomerAge: customerAge,
+			Note:        input.AgeNote,
+		}
+		switch {
+		case input.AgeDenied:
+			ageLog.Action = model.AgeCheckDenied
+			if err := db.Create(ageLog).Error; err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể ghi nhật ký kiểm tra độ tuổi", err)
+			}
+			return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+				"message":   fmt.Sprintf("Đã từ chối khách chưa đủ %d tuổi, vé chưa được check-in", minAge),
+				"orderCode": order.PublicCode,
+				"denied":    true,
+			})
+		case input.AgeOverride:
+			if strings.TrimSpace(input.AgeNote) == "" {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Vui lòng ghi chú giấy tờ đã kiểm tra khi cho khách vào", nil, "ageNote")
+			}
+			ageLog.Action = model.AgeCheckOverride
+		default:
+			return utils.ErrorResponseHaveKey(c, fiber.StatusForbidden,
+				fmt.Sprintf("KIỂM TRA GIẤY TỜ: phim %s dành cho khán giả từ %d tuổi, khách mới %d tuổi theo ngày sinh đã khai. Xem giấy tờ rồi xác nhận cho vào hoặc từ chối", rating, minAge, *customerAge),
+				nil, "ageOverride")
+		}
+	}
+
+	// 5️⃣ Check-in các vé (cả đơn, hoặc các vé ghi trong QR có chữ ký) trong transaction
 	now := time.Now()
+	var manualLog *model.ManualCheckinLog
+	if qrPayload == nil {
+		manualLog = &model.ManualCheckinLog{
+			OrderId:    order.ID,
+			ShowtimeId: showtime.ID,
+			AccountId:  accountInfo.AccountId,
+			Code:       input.Code,
+			Reason:     strings.TrimSpace(input.ManualReason),
+		}
+	}
 
 	err = db.Transaction(func(tx *gorm.DB) error {
+		if ageLog != nil {
+			if err := tx.Create(ageLog).Error; err != nil {
+				return err
+			}
+		}
+		if manualLog != nil {
+			if err := tx.Create(manualLog).Error; err != nil {
+				return err
+			}
+		}
 		return tx.Model(&model.Ticket{}).
-			Where("order_id = ?", order.ID).
+			Where("order_id = ? AND ticket_code IN ? AND status IN ?", order.ID, ticketCodes, []string{"PAID", "ISSUED"}).
 			Updates(map[string]interface{}{
 				"status":        "CHECKED_IN",
 				"used_at":       &now,
@@ -1142,25 +1515,49 @@ func CheckinByOrderCode(c *fiber.Ctx) error {
 		)
 	}
 
-	// 5️⃣ Build danh sách ghế
+	if manualLog != nil {
+		log.Printf("Check-in nhập tay đơn %s bởi tài khoản %d: %s", order.PublicCode, accountInfo.AccountId, manualLog.Reason)
+	}
+
+	// 6️⃣ Build danh sách ghế; vé ưu đãi (trẻ em, HSSV...) cần kiểm tra giấy tờ tại cửa
 	seats := make([]string, 0, len(order.Tickets))
+	idChecks := make([]fiber.Map, 0)
 	for _, ticket := range order.Tickets {
 		seat := ticket.ShowtimeSeat.Seat
 		if seat.ID != 0 {
-			seats = append(seats, fmt.Sprintf("%s%d", seat.Row, seat.Column))
+			seats = append(seats, helper.TicketSeatLabel(ticket))
+		}
+		if ticket.RequiresIdCheck {
+			idChecks = append(idChecks, fiber.Map{
+				"ticketCode":   ticket.TicketCode,
+				"seat":         fmt.Sprintf("%s%d", seat.Row, seat.Column),
+				"categoryCode": ticket.CategoryCode,
+				"categoryName": ticket.CategoryName,
+			})
 		}
 	}
 
-	// 6️⃣ Trả response
+	// 7️⃣ Trả response
 	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
 		"message":   fmt.Sprintf("Check-in thành công %d vé!", len(order.Tickets)),
 		"orderCode": order.PublicCode,
 
-		"movie":         showtime.Movie.Title,
-		"showtime":      showtime.StartTime.Format("15:04 - 02/01/2006"),
-		"seats":         strings.Join(seats, ", "),
-		"checked_in_at": now.Format("15:04:05"),
-		"room":          showtime.Room.Name,
-		"ticketCount":   len(order.Tickets),
+		"movie":           showtime.Movie.Title,
+		"showtime":        showtime.StartTime.Format("15:04 - 02/01/2006"),
+		"seats":           strings.Join(seats, ", "),
+		"checked_in_at":   now.Format("15:04:05"),
+		"room":            showtime.Room.Name,
+		"ticketCount":     len(order.Tickets),
+		"idCheckRequired": len(idChecks) > 0 || minAge > 0,
+		"idChecks":        idChecks,
+		// Phim giới hạn độ tuổi: màn hình soát vé hiển thị nổi bật "KIỂM TRA GIẤY TỜ"
+		"verifyAge":      minAge > 0,
+		"ageRestriction": rating,
+		"minimumAge":     minAge,
+		"ageConfirmed":   order.AgeConfirmed,
+		"ageOverridden":  ageLog != nil,
+		"signedQr":       qrPayload != nil,
+		"manualEntry":    manualLog != nil,
+		"skippedTickets": skippedTickets, // vé đã hủy / không hợp lệ, không được check-in
 	})
 }
@@ -8,12 +8,17 @@ import (
 	"cinema_manager/utils"
 	"errors"
 	"fmt"
+	"log"
+	"math"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func GetShowtime(c *fiber.Ctx) error {
@@ -414,9 +419,21 @@ func CreateShowtimeBatch(c *fiber.Ctx) error {
 	location := time.FixedZone("ICT", 7*3600)
 	currentTime := time.Now().In(location)
 
+	// Phòng + thời gian quảng cáo/dọn phòng theo loại phòng
+	rooms := make(map[uint]model.Room)
+	for _, roomID := range input.RoomIDs {
+		var room model.Room
+		if err := db.First(&room, roomID).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Phòng không tồn tại", err, "roomIds")
+		}
+		rooms[roomID] = room
+	}
+
 	var showtimes []model.Showtime
 	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
 		for _, roomID := range input.RoomIDs {
+			turnaround := helper.GetRoomTurnaround(db, rooms[roomID].Type)
+			events := helper.FindSpecialEvents(db, d, rooms[roomID].CinemaId, input.MovieID)
 			for _, format := range input.Formats {
 				for _, slot := range input.TimeSlots {
 					startStr := fmt.Sprintf("%s %s", d.Format("2006-01-02"), slot)
@@ -430,16 +447,17 @@ func CreateShowtimeBatch(c *fiber.Ctx) error {
 						continue
 					}
 
-					endTime := startTime.Add(time.Duration(duration) * time.Minute)
-					price := helper.CalculatePrice(startTime, format, d)
+					endTime := helper.ShowtimeEndTime(startTime, duration, turnaround)
+					price := helper.ApplyEventPricing(helper.CalculatePrice(startTime, format, d), events)
 					showtimes = append(showtimes, model.Showtime{
-						MovieId:   input.MovieID,
-						RoomId:    roomID,
-						StartTime: startTime,
-						EndTime:   endTime,
-						Format:    format,
-						Price:     price,
-						Status:    "scheduled",
+						MovieId:      input.MovieID,
+						RoomId:       roomID,
+						StartTime:    startTime,
+						EndTime:      endTime,
+						Format:       format,
+						LanguageType: model.LanguageType(input.LanguageType),
+						Price:        price,
+						Status:       "scheduled",
 					})
 				}
 			}
@@ -453,35 +471,24 @@ func CreateShowtimeBatch(c *fiber.Ctx) error {
 	tx := db.Begin()
 	for i, st := range showtimes {
 		// Kiểm tra phòng chiếu hoạt động
-		var room model.Room
-		if err := tx.Where("id = ? AND status = ?", st.RoomId, "active").First(&room).Error; err != nil {
+		room := rooms[st.RoomId]
+		if room.Status != "available" {
 			tx.Rollback()
 			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest,
-				fmt.Sprintf("Phòng chiếu không hoạt động (index %d)", i), err, "roomId")
+				fmt.Sprintf("Phòng chiếu không hoạt động (index %d)", i), nil, "roomId")
 		}
 
-		// Kiểm tra xung đột
-		var conflict int64
-		if err := tx.Model(&model.Showtime{}).
-			Where(`room_id = ? 
-					AND ((start_time <= ? AND end_time >= ?) 
-					OR (start_time <= ? AND end_time >= ?))`,
-				st.RoomId, st.StartTime, st.StartTime, st.EndTime, st.EndTime).
-			Count(&conflict).Error; err != nil {
-			tx.Rollback()
-			return utils.ErrorResponse(c, 500, "Lỗi kiểm tra xung đột lịch chiếu", err)
-		}
-		if conflict > 0 {
+		// Kiểm tra giờ hoạt động + xung đột (kể cả thời gian dọn phòng, các suất vừa tạo trong lô)
+		if err := helper.CheckShowtimeSlot(tx, room, st.StartTime, st.EndTime, 0); err != nil {
 			tx.Rollback()
-			return utils.ErrorResponseHaveKey(c, 400,
-				fmt.Sprintf("Xung đột lịch chiếu (Phòng %d, %s)", st.RoomId, st.StartTime.Format("02/01 15:04")),
-				nil, "startTime")
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, err.Error(), err, "startTime")
 		}
 
 		if err := tx.Create(&st).Error; err != nil {
 			tx.Rollback()
 			return utils.ErrorResponse(c, 500, "Không thể tạo lịch chiếu", err)
 		}
+		showtimes[i] = st
 	}
 
 	tx.Commit()
@@ -645,6 +652,18 @@ func AutoGenerateShowtimeSchedule(c *fiber.Ctx) error {
 			fmt.Errorf("unsupported formats: %v", unsupported),
 			"formats")
 	}
+
+	// Quy tắc từng định dạng lấy từ mẫu lịch (hoặc mặc định hệ thống)
+	formatRules := make(map[string]model.FormatRule)
+	for _, format := range input.Formats {
+		rule := helper.GetFormatConfig(format, template)
+		if len(input.RoomIDs) > rule.MaxRooms {
+			tx.Rollback()
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest,
+				fmt.Sprintf("Định dạng %s chỉ được chiếu tối đa ở %d phòng", format, rule.MaxRooms), nil, "roomIds")
+		}
+		formatRules[format] = rule
+	}
 	const (
 		MinGapBetweenRooms = 10 * time.Minute // cách nhau tối thiểu giữa hai phòng chiếu cùng phim
 		BreakStartHour     = 11
@@ -655,15 +674,14 @@ func AutoGenerateShowtimeSchedule(c *fiber.Ctx) error {
 	loc, _ := time.LoadLocation("Asia/Ho_Chi_Minh")
 	createdCount := 0
 	skippedCount := 0
+	skippedReasons := []string{}
 
 	// Duyệt từng ngày
-	// Thêm map để đếm suất IMAX muộn per day (nếu cần "không quá 1h" nghĩa là không quá 1 suất sau 22:00)
-	imaxLateCountPerDay := make(map[string]int) // key: "2006-01-02"
 	for currentDate := startDate; !currentDate.After(endDate); currentDate = currentDate.AddDate(0, 0, 1) {
-		dateKey := currentDate.Format("2006-01-02")
-		imaxLateCountPerDay[dateKey] = 0 // reset nếu cần, nhưng map sẽ tự tạo
+		lateCountByFormat := make(map[string]int) // format → số suất muộn trong ngày (mọi phòng)
 
 		for _, roomID := range input.RoomIDs {
+			dailyCreatedByFormat := make(map[string]int) // format → số suất trong ngày của phòng
 
 			var room model.Room
 			if err := tx.Preload("Formats").First(&room, roomID).Error; err != nil {
@@ -674,11 +692,8 @@ func AutoGenerateShowtimeSchedule(c *fiber.Ctx) error {
 				tx.Rollback()
 				return utils.ErrorResponse(c, 400, "Phòng đang bị khóa", nil)
 			}
-			if len(input.RoomIDs) > 6 {
-				return utils.ErrorResponse(c, 400, "Tối đa 6 phòng cho 1 phim", nil)
-			}
-			// Lọc định dạng phòng hỗ trợ
-			dailyCreatedByFormat := make(map[string]int) // format to count
+			turnaround := helper.GetRoomTurnaround(tx, room.Type)
+			events := helper.FindSpecialEvents(tx, currentDate, room.CinemaId, movie.ID)
 
 			for _, format := range input.Formats {
 
@@ -687,24 +702,17 @@ func AutoGenerateShowtimeSchedule(c *fiber.Ctx) error {
 					continue
 				}
 
-				maxPerDay := helper.GetMaxPerDay(format, template)
-				config := helper.GetFormatConfig(format, template)
-
-				// Kiểm tra số phòng (chỉ 1-2 phòng cho phim đặc biệt)
-				if config.MaxPerDay <= 4 && len(input.RoomIDs) > 2 {
-					return utils.ErrorResponse(c, 400, fmt.Sprintf("Định dạng %s chỉ được chiếu tối đa ở 2 phòng", format), nil)
-				}
-				if dailyCreatedByFormat[format] >= maxPerDay {
+				rule := formatRules[format]
+				if dailyCreatedByFormat[format] >= rule.MaxPerDay {
 					continue // đã đủ suất cho format này
 				}
 
-				//specialFormats := map[string]bool{"4DX": true, "IMAX": true}
-				offset := time.Duration(config.OffsetMinutes) * time.Minute
+				offset := time.Duration(rule.OffsetMinutes) * time.Minute
 
-				validSlots := helper.FilterSlotsByFormatAndTime(input.TimeSlots, format, movie.Duration, currentDate, loc)
+				validSlots := helper.FilterSlotsByFormatAndTime(input.TimeSlots, rule)
 
 				for _, slot := range validSlots {
-					if dailyCreatedByFormat[format] >= maxPerDay {
+					if dailyCreatedByFormat[format] >= rule.MaxPerDay {
 						break
 					}
 
@@ -733,20 +741,19 @@ func AutoGenerateShowtimeSchedule(c *fiber.Ctx) error {
 						continue
 					}
 
-					endTime := startTime.Add(time.Minute * time.Duration(movie.Duration))
+					endTime := helper.ShowtimeEndTime(startTime, movie.Duration, turnaround)
 
-					// IMAX: chỉ 1 suất sau 22:00/ngày
-					if format == "IMAX" && startTime.Hour() >= 22 {
-						if imaxLateCountPerDay[dateKey] >= 1 {
-							skippedCount++
-							continue
-						}
-						imaxLateCountPerDay[dateKey]++
+					// Giới hạn suất muộn (VD: IMAX chỉ 1 suất sau 22:00/ngày)
+					isLate := rule.LateHour > 0 && startTime.Hour() >= rule.LateHour
+					if isLate && lateCountByFormat[format] >= rule.MaxLatePerDay {
+						skippedCount++
+						continue
 					}
 
-					// Kiểm tra trùng phòng
-					if helper.HasConflict(tx, roomID, startTime, endTime) {
+					// Kiểm tra giờ hoạt động + trùng phòng (tính cả thời gian dọn phòng)
+					if err := helper.CheckShowtimeSlot(tx, room, startTime, endTime, 0); err != nil {
 						skippedCount++
+						skippedReasons = append(skippedReasons, err.Error())
 						continue
 					}
 
@@ -770,7 +777,7 @@ func AutoGenerateShowtimeSchedule(c *fiber.Ctx) error {
 						if input.Price > 0 {
 							price = float64(input.Price) // frontend override
 						} else {
-							price = helper.CalculateDynamicPrice(startTime, format, currentDate, input.IsVietnamese)
+							price = helper.ApplyEventPricing(helper.CalculateDynamicPrice(startTime, format, currentDate, input.IsVietnamese), events)
 						}
 						showtime := model.Showtime{
 							PublicCode:   "ST-" + utils.RandomString(6),
@@ -794,8 +801,11 @@ func AutoGenerateShowtimeSchedule(c *fiber.Ctx) error {
 
 						createdCount++
 					}
+					dailyCreatedByFormat[format]++
+					if isLate {
+						lateCountByFormat[format]++
+					}
 				}
-				dailyCreatedByFormat[format]++
 			}
 
 		}
@@ -807,96 +817,98 @@ func AutoGenerateShowtimeSchedule(c *fiber.Ctx) error {
 	}
 
 	return utils.SuccessResponse(c, 201, fiber.Map{
-		"message":    "Tạo lịch khung thành công",
-		"created":    createdCount,
-		"skipped":    skippedCount,
-		"totalDays":  int(endDate.Sub(startDate).Hours()/24) + 1,
-		"totalRooms": len(input.RoomIDs),
+		"message":        "Tạo lịch khung thành công",
+		"created":        createdCount,
+		"skipped":        skippedCount,
+		"skippedReasons": skippedReasons,
+		"totalDays":      int(endDate.Sub(startDate).Hours()/24) + 1,
+		"totalRooms":     len(input.RoomIDs),
 	})
 
 }
+
+// AutoSchedule áp dụng mẫu lịch cho phim vào các ngày: mỗi item tạo một suất chiếu bán được ngay
+// (có mã công khai và sơ đồ ghế). Nếu có suất xung đột thì không tạo suất nào.
 func AutoSchedule(c *fiber.Ctx) error {
-	template := c.Locals("template").(struct {
-		ID      uint
-		MovieID uint
-		Items   []struct {
-			RoomID uint   `gorm:"column:room_id"`
-			Time   string `gorm:"column:time"`
-			Format string `gorm:"column:format"`
-		}
-	})
-	duration := c.Locals("movieDuration").(int)
-	applyDates := c.Locals("applyDates").([]string)
+	template := c.Locals("template").(model.ScheduleTemplate)
+	movie := c.Locals("movie").(model.Movie)
+	applyDates := c.Locals("applyDates").([]time.Time)
 
-	location := time.FixedZone("ICT", 7*3600)
-	var showtimes []model.Showtime
+	isVietnamese := helper.IsVietnameseMovie(movie)
 	conflicts := []string{}
+	var showtimes []model.Showtime
 
-	// === TẠO KẾ HOẠCH ===
-	for _, dateStr := range applyDates {
-		date, _ := time.Parse("2006-01-02", dateStr)
+	tx := database.DB.Begin()
+	for _, date := range applyDates {
+		dayInfos := make(map[uint]*helper.DayInfo) // cinemaId → loại ngày (sự kiện theo rạp)
 		for _, item := range template.Items {
-			timeStr := fmt.Sprintf("%s %s", date.Format("2006-01-02"), item.Time)
-			startTime, err := time.ParseInLocation("2006-01-02 15:04", timeStr, location)
+			dayInfo, ok := dayInfos[item.Room.CinemaId]
+			if !ok {
+				dayInfo = helper.ClassifyDayAt(date, movie.DateRelease.Time, item.Room.CinemaId, movie.ID)
+				dayInfos[item.Room.CinemaId] = dayInfo
+			}
+			startTime, err := time.ParseInLocation("2006-01-02 15:04", date.Format("2006-01-02")+" "+item.Time, date.Location())
 			if err != nil {
 				continue
 			}
+			turnaround := helper.GetRoomTurnaround(tx, item.Room.Type)
+			endTime := helper.ShowtimeEndTime(startTime, movie.Duration, turnaround)
 
-			endTime := startTime.Add(time.Duration(duration) * time.Minute)
-			price := helper.CalculatePrice(startTime, item.Format, date)
-
-			showtimes = append(showtimes, model.Showtime{
-				MovieId:   template.MovieID,
-				RoomId:    item.RoomID,
-				StartTime: startTime,
-				EndTime:   endTime,
-				Format:    item.Format,
-				Price:     price,
-				Status:    "scheduled",
-			})
-		}
-	}
-
-	if len(showtimes) == 0 {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Không tạo được suất nào", nil)
-	}
+			// Tạo từng suất trong transaction để các suất sau thấy được suất vừa tạo
+			if err := helper.CheckShowtimeSlot(tx, item.Room, startTime, endTime, 0); err != nil {
+				conflicts = append(conflicts, err.Error())
+				continue
+			}
 
-	// === KIỂM TRA XUNG ĐỘT ===
-	tx := database.DB.Begin()
-	for _, st := range showtimes {
-		var count int64
-		tx.Model(&model.Showtime{}).
-			Where("room_id = ? AND status != 'cancelled'", st.RoomId).
-			Where("(start_time < ? AND end_time > ?) OR (start_time < ? AND end_time > ?)",
-				st.EndTime, st.StartTime, st.EndTime, st.StartTime).
-			Count(&count)
-		if count > 0 {
-			conflicts = append(conflicts, fmt.Sprintf("Phòng %d: %s", st.RoomId, st.StartTime.Format("02/01 15:04")))
+			showtime := model.Showtime{
+				PublicCode:   "ST-" + utils.RandomString(6),
+				MovieId:      movie.ID,
+				RoomId:       item.RoomId,
+				StartTime:    startTime,
+				EndTime:      endTime,
+				LanguageType: item.LanguageType,
+				Format:       item.Format,
+				Price:        helper.CalculateDayTypePrice(startTime, item.Format, dayInfo, isVietnamese),
+				Status:       "AVAILABLE",
+			}
+			if err := tx.Create(&showtime).Error; err != nil {
+				tx.Rollback()
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Lỗi tạo suất chiếu", err)
+			}
+			if err := helper.CreateShowtimeSeats(tx, showtime.ID, item.RoomId); err != nil {
+				tx.Rollback()
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không tạo được danh sách ghế", err)
+			}
+			showtimes = append(showtimes, showtime)
 		}
 	}
 
 	if len(conflicts) > 0 {
 		tx.Rollback()
-		return utils.ErrorResponse(c, fiber.StatusConflict,
-			fmt.Sprintf("Xung đột %d suất: %s", len(conflicts), strings.Join(conflicts[:3], ", ")), nil)
+		shown := conflicts
+		if len(shown) > 3 {
+			shown = shown[:3]
+		}
+		return utils.ErrorResponseHaveKey(c, fiber.StatusConflict,
+			fmt.Sprintf("Xung đột %d suất: %s", len(conflicts), strings.Join(shown, "; ")), nil, "applyDates")
 	}
-
-	// === TẠO HÀNG LOẠT ===
-	if err := tx.Create(&showtimes).Error; err != nil {
+	if len(showtimes) == 0 {
 		tx.Rollback()
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Lỗi tạo suất chiếu", err)
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Không tạo được suất nào", nil)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Lỗi commit", err)
 	}
-
-	tx.Commit()
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
-		"message": fmt.Sprintf("Tạo thành công %d suất chiếu", len(showtimes)),
-		"days":    len(applyDates),
-		"perDay":  len(template.Items),
-		"total":   len(showtimes),
+		"message":   fmt.Sprintf("Tạo thành công %d suất chiếu", len(showtimes)),
+		"days":      len(applyDates),
+		"perDay":    len(template.Items),
+		"total":     len(showtimes),
+		"showtimes": showtimes,
 	})
-
 }
+
 func EditShowtime(c *fiber.Ctx) error {
 	db := database.DB
 	showtimeId := c.Locals("showtimeId").(uint)
@@ -926,16 +938,18 @@ func EditShowtime(c *fiber.Ctx) error {
 	}
 	if showtimeInput.StartTime != nil {
 		showtime.StartTime = *showtimeInput.StartTime
-		if showtime.Movie.ID != 0 {
-			showtime.EndTime = showtime.StartTime.Add(
-				time.Duration(showtime.Movie.Duration) * time.Minute,
-			)
-		}
+	}
+	// Giờ kết thúc đã được tính lại ở validate (quảng cáo theo loại phòng + thời lượng phim)
+	if showtimeInput.EndTime != nil {
+		showtime.EndTime = *showtimeInput.EndTime
 	}
 
 	if showtimeInput.Price != nil {
 		showtime.Price = *showtimeInput.Price
 	}
+	if showtimeInput.SaleOpensAt != nil {
+		showtime.SaleOpensAt = showtimeInput.SaleOpensAt
+	}
 	showtime.PublicCode = "ST-" + utils.RandomString(6)
 	if err := tx.Save(&showtime).Error; err != nil {
 		tx.Rollback()
@@ -1309,6 +1323,23 @@ func GetShowtimesByMovieAndProvince(c *fiber.Ctx) error {
 		})
 	}
 
+	// Nhãn sự kiện đặc biệt (công chiếu, suất fan...) theo rạp + ngày chiếu
+	eventsByDay := make(map[string][]model.SpecialEvent)
+	for i := range showtimes {
+		key := fmt.Sprintf("%d-%s", showtimes[i].Room.CinemaId, showtimes[i].StartTime.In(time.FixedZone("ICT", 7*3600)).Format("2006-01-02"))
+		events, ok := eventsByDay[key]
+		if !ok {
+			events = helper.GetShowtimeEvents(database.DB, showtimes[i])
+			eventsByDay[key] = events
+		}
+		showtimes[i].EventTags = helper.EventTags(events)
+		for _, event := range events {
+			if event.NonRefundable {
+				showtimes[i].NonRefundable = true
+			}
+		}
+	}
+
 	// ===== GROUP: chain → cinema → showtimes =====
 	type CinemaGroup struct {
 		Cinema    model.Cinema     `json:"cinema"`
@@ -1358,3 +1389,654 @@ func GetShowtimesByMovieAndProvince(c *fiber.Ctx) error {
 		"chains":  chains,
 	})
 }
+
+// PlanCinemaSchedule lập lịch tuần cho toàn bộ rạp, tối ưu doanh thu hoặc ghế-giờ kỳ vọng
+func PlanCinemaSchedule(c *fiber.Ctx) error {
+	input := c.Locals("input").(model.PlanCinemaScheduleInput)
+	rooms := c.Locals("rooms").([]model.Room)
+	movies := c.Locals("movies").([]model.Movie)
+	startDate := c.Locals("startDate").(time.Time)
+	endDate := c.Locals("endDate").(time.Time)
+	openTime, _ := c.Locals("openTime").(*time.Duration)
+	closeTime, _ := c.Locals("closeTime").(*time.Duration)
+
+	db := database.DB
+	location := time.FixedZone("ICT", 7*3600)
+
+	// Sức chứa phòng: ưu tiên Capacity, nếu chưa khai báo thì đếm ghế
+	plannerRooms := make([]helper.PlannerRoom, 0, len(rooms))
+	for _, room := range rooms {
+		capacity := 0
+		if room.Capacity != nil {
+			capacity = *room.Capacity
+		}
+		if capacity == 0 {
+			var count int64
+			db.Model(&model.Seat{}).Where("room_id = ?", room.ID).Count(&count)
+			capacity = int(count)
+		}
+		if capacity == 0 {
+			continue // phòng chưa có ghế thì không bán được vé
+		}
+		plannerRooms = append(plannerRooms, helper.PlannerRoom{
+			Room:       room,
+			Capacity:   capacity,
+			Turnaround: helper.GetRoomTurnaround(db, room.Type),
+		})
+	}
+	if len(plannerRooms) == 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Các phòng chưa có ghế", nil)
+	}
+
+	movieIDs := make([]uint, 0, len(movies))
+	for _, m := range movies {
+		movieIDs = append(movieIDs, m.ID)
+	}
+	occupancy := helper.LoadMovieOccupancy(db, input.CinemaId, movieIDs)
+	weights := make(map[uint]float64)
+	for _, w := range input.MovieWeights {
+		weights[w.MovieId] = w.Weight
+	}
+
+	plannerMovies := make([]helper.PlannerMovie, 0, len(movies))
+	for _, m := range movies {
+		occ, ok := occupancy[m.ID]
+		if !ok || occ <= 0 {
+			occ = helper.DefaultPlanOccupancy
+		}
+		weight, ok := weights[m.ID]
+		if !ok {
+			weight = 1
+		}
+		plannerMovies = append(plannerMovies, helper.PlannerMovie{
+			Movie:        m,
+			Weight:       weight,
+			Occupancy:    occ,
+			IsVietnamese: helper.IsVietnameseMovie(m),
+		})
+	}
+
+	opts := helper.PlannerOptions{
+		Objective: input.Objective,
+		CinemaId:  input.CinemaId,
+		OpenTime:  openTime,
+		CloseTime: closeTime,
+		Stagger:   10 * time.Minute,
+		Location:  location,
+	}
+	if opts.Objective == "" {
+		opts.Objective = helper.PlanObjectiveRevenue
+	}
+	if input.CleaningMinutes != nil {
+		cleaning := time.Duration(*input.CleaningMinutes) * time.Minute
+		opts.Cleaning = &cleaning
+	}
+	if input.StaggerMinutes != nil {
+		opts.Stagger = time.Duration(*input.StaggerMinutes) * time.Minute
+	}
+	languageType := model.LanguageType(input.LanguageType)
+	if languageType == "" {
+		languageType = model.LangViSub
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Lỗi DB", tx.Error)
+	}
+	plan, summary := helper.PlanCinemaSchedule(tx, plannerMovies, plannerRooms, startDate, endDate, opts)
+	summary.Options = fiber.Map{
+		"objective":       opts.Objective,
+		"openTime":        input.OpenTime,
+		"closeTime":       input.CloseTime,
+		"cleaningMinutes": input.CleaningMinutes,
+		"staggerMinutes":  int(opts.Stagger.Minutes()),
+	}
+
+	if input.DryRun || len(plan) == 0 {
+		tx.Rollback()
+		return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+			"message":   "Xem trước lịch chiếu",
+			"dryRun":    true,
+			"showtimes": plan,
+			"summary":   summary,
+		})
+	}
+
+	for _, p := range plan {
+		showtime := model.Showtime{
+			PublicCode:   "ST-" + utils.RandomString(6),
+			MovieId:      p.MovieId,
+			RoomId:       p.RoomId,
+			StartTime:    p.StartTime,
+			EndTime:      p.EndTime,
+			LanguageType: languageType,
+			Format:       p.Format,
+			Price:        p.Price,
+			Status:       "AVAILABLE",
+		}
+		if err := tx.Create(&showtime).Error; err != nil {
+			tx.Rollback()
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Lỗi tạo suất chiếu", err)
+		}
+		if err := helper.CreateShowtimeSeats(tx, showtime.ID, p.RoomId); err != nil {
+			tx.Rollback()
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không tạo được danh sách ghế", err)
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Lỗi commit", err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message":   fmt.Sprintf("Đã lập %d suất chiếu cho rạp", len(plan)),
+		"dryRun":    false,
+		"showtimes": plan,
+		"summary":   summary,
+	})
+}
+
+// CopySchedule sao chép lịch chiếu của rạp sang khoảng ngày mới theo thứ trong tuần: mỗi ngày đích
+// lấy lịch của ngày nguồn cùng thứ, nên có thể nhân một tuần ra nhiều tuần. Thứ không có trong
+// khoảng nguồn được báo trong skipped.
+func CopySchedule(c *fiber.Ctx) error {
+	input := c.Locals("input").(model.CopyScheduleInput)
+	sourceStart := c.Locals("sourceStart").(time.Time)
+	sourceEnd := c.Locals("sourceEnd").(time.Time)
+	targetStart := c.Locals("targetStart").(time.Time)
+	targetEnd := c.Locals("targetEnd").(time.Time)
+
+	db := database.DB
+	location := time.FixedZone("ICT", 7*3600)
+	now := time.Now().In(location)
+
+	query := db.Preload("Movie").Preload("Room").
+		Joins("JOIN rooms ON rooms.id = showtimes.room_id").
+		Where("rooms.cinema_id = ?", input.CinemaId).
+		Where("showtimes.start_time >= ? AND showtimes.start_time < ?", sourceStart, sourceEnd.AddDate(0, 0, 1)).
+		Where("showtimes.status <> ?", "CANCELLED")
+	if len(input.RoomIds) > 0 {
+		query = query.Where("showtimes.room_id IN ?", input.RoomIds)
+	}
+	if len(input.MovieIds) > 0 {
+		query = query.Where("showtimes.movie_id IN ?", input.MovieIds)
+	}
+	var sources []model.Showtime
+	if err := query.Order("showtimes.start_time").Find(&sources).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, constants.ERROR_INTERNAL_ERROR, err)
+	}
+	if len(sources) == 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Không có suất chiếu nào trong khoảng ngày nguồn", nil)
+	}
+
+	// Gom suất nguồn theo thứ trong tuần (thứ 2 nguồn → thứ 2 đích); nguồn dài hơn 1 tuần
+	// thì mỗi thứ lấy theo ngày xuất hiện đầu tiên để không nhân đôi lịch
+	byWeekday := make(map[time.Weekday][]model.Showtime)
+	firstDay := make(map[time.Weekday]time.Time)
+	for _, st := range sources {
+		local := st.StartTime.In(location)
+		day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+		first, ok := firstDay[day.Weekday()]
+		if !ok {
+			firstDay[day.Weekday()] = day
+			first = day
+		}
+		if day.Equal(first) {
+			byWeekday[day.Weekday()] = append(byWeekday[day.Weekday()], st)
+		}
+	}
+
+	type copyResult struct {
+		SourceId   uint      `json:"sourceId"`
+		MovieTitle string    `json:"movieTitle"`
+		RoomName   string    `json:"roomName"`
+		Start      time.Time `json:"start"`
+		Price      float64   `json:"price,omitempty"`
+		Reason     string    `json:"reason,omitempty"`
+	}
+	created := []copyResult{}
+	skipped := []copyResult{}
+	conflicts := []copyResult{}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Lỗi DB", tx.Error)
+	}
+	for i := 0; !targetStart.AddDate(0, 0, i).After(targetEnd); i++ {
+		targetDay := targetStart.AddDate(0, 0, i)
+		dayInfos := make(map[uint]*helper.DayInfo) // movieId → loại ngày (sự kiện theo phim)
+
+		if _, ok := firstDay[targetDay.Weekday()]; !ok {
+			skipped = append(skipped, copyResult{
+				Start:  targetDay,
+				Reason: fmt.Sprintf("Khoảng ngày nguồn không có suất chiếu %s", helper.WeekdayName(targetDay.Weekday())),
+			})
+			continue
+		}
+		for _, src := range byWeekday[targetDay.Weekday()] {
+			local := src.StartTime.In(location)
+			startTime := time.Date(targetDay.Year(), targetDay.Month(), targetDay.Day(),
+				local.Hour(), local.Minute(), 0, 0, location)
+			result := copyResult{SourceId: src.ID, MovieTitle: src.Movie.Title, RoomName: src.Room.Name, Start: startTime}
+
+			switch {
+			case startTime.Before(now):
+				result.Reason = "Thời gian đã qua"
+			case !src.Movie.IsAvailable || src.Movie.StatusMovie == "ENDED":
+				result.Reason = "Phim đã ngừng chiếu"
+			case !helper.IsValidShowDate(targetDay, &src.Movie):
+				result.Reason = "Ngoài thời gian công chiếu của phim"
+			case helper.CheckFilmLicense(tx, src.MovieId, src.Room.CinemaId, targetDay) != nil:
+				result.Reason = "Ngoài thời hạn hợp đồng phát hành của phim"
+			case src.Room.Status != "available":
+				result.Reason = "Phòng không hoạt động"
+			}
+			if result.Reason != "" {
+				skipped = append(skipped, result)
+				continue
+			}
+
+			turnaround := helper.GetRoomTurnaround(tx, src.Room.Type)
+			endTime := helper.ShowtimeEndTime(startTime, src.Movie.Duration, turnaround)
+			if err := helper.CheckShowtimeSlot(tx, src.Room, startTime, endTime, 0); err != nil {
+				result.Reason = err.Error()
+				conflicts = append(conflicts, result)
+				continue
+			}
+
+			dayInfo, ok := dayInfos[src.MovieId]
+			if !ok {
+				dayInfo = helper.ClassifyDayAt(targetDay, time.Time{}, input.CinemaId, src.MovieId)
+				dayInfos[src.MovieId] = dayInfo
+			}
+			showtime := model.Showtime{
+				PublicCode:   "ST-" + utils.RandomString(6),
+				MovieId:      src.MovieId,
+				RoomId:       src.RoomId,
+				StartTime:    startTime,
+				EndTime:      endTime,
+				LanguageType: src.LanguageType,
+				Format:       src.Format,
+				Price:        helper.CalculateDayTypePrice(startTime, src.Format, dayInfo, helper.IsVietnameseMovie(src.Movie)),
+				Status:       "AVAILABLE",
+			}
+			if err := tx.Create(&showtime).Error; err != nil {
+				tx.Rollback()
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Lỗi tạo suất chiếu", err)
+			}
+			if err := helper.CreateShowtimeSeats(tx, showtime.ID, showtime.RoomId); err != nil {
+				tx.Rollback()
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không tạo được danh sách ghế", err)
+			}
+			result.Price = showtime.Price
+			created = append(created, result)
+		}
+	}
+
+	if input.DryRun {
+		tx.Rollback()
+	} else if err := tx.Commit().Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Lỗi commit", err)
+	}
+
+	message := fmt.Sprintf("Đã sao chép %d suất chiếu", len(created))
+	if input.DryRun {
+		message = fmt.Sprintf("Xem trước: có thể sao chép %d suất chiếu", len(created))
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message":   message,
+		"dryRun":    input.DryRun,
+		"created":   created,
+		"skipped":   skipped,
+		"conflicts": conflicts,
+	})
+}
+
+// CancelShowtime hủy suất chiếu: hủy toàn bộ đơn/vé, hoàn 100%, giải phóng ghế và báo khách qua email
+func CancelShowtime(c *fiber.Ctx) error {
+	input := c.Locals("input").(model.CancelShowtimeInput)
+	showtimeId := c.Locals("showtimeId").(uint)
+	accountInfo, _, _, _, _ := helper.GetInfoAccountFromToken(c)
+
+	db := database.DB
+	now := time.Now()
+
+	var showtime model.Showtime
+	var orders []model.Order
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&showtime, showtimeId).Error; err != nil {
+			return err
+		}
+		if showtime.Status == "CANCELLED" {
+			return errors.New("suất chiếu đã bị hủy trước đó")
+		}
+
+		if err := tx.Preload("Tickets.ShowtimeSeat.Seat").
+			Where("showtime_id = ? AND status <> ?", showtimeId, "CANCELLED").
+			Find(&orders).Error; err != nil {
+			return err
+		}
+
+		for i := range orders {
+			// Chỉ giữ vé còn hiệu lực: vé đã hủy lẻ trước đó không tính vào số vé hủy / email
+			active := orders[i].Tickets[:0]
+			for _, ticket := range orders[i].Tickets {
+				if ticket.Status != "CANCELLED" {
+					active = append(active, ticket)
+				}
+			}
+			orders[i].Tickets = active
+
+			// Chỉ đơn đã thanh toán mới được hoàn tiền, phần còn lại sau các lần hoàn trước;
+			// đơn PENDING / đặt chỗ chưa thu tiền chỉ bị hủy
+			paid := orders[i].Status == "PAID"
+			refundAmount := 0.0
+			if paid {
+				refundAmount = math.Max(orders[i].TotalAmount-orders[i].RefundAmount, 0)
+			}
+			if err := tx.Model(&orders[i]).Updates(map[string]interface{}{
+				"status":         "CANCELLED",
+				"cancelled_at":   now,
+				"refund_amount":  orders[i].RefundAmount + refundAmount,
+				"actual_revenue": 0,
+			}).Error; err != nil {
+				return err
+			}
+			if err := helper.CancelOrderSideEffects(tx, orders[i].ID, accountInfo.AccountId); err != nil {
+				return err
+			}
+			if paid {
+				if _, err := helper.AllocateOrderRefund(tx, &orders[i], refundAmount, false, &accountInfo.AccountId); err != nil {
+					return err
+				}
+			}
+			orders[i].RefundAmount = refundAmount // số hoàn lần này, dùng cho email và tổng hợp
+		}
+
+		if err := tx.Model(&model.Ticket{}).
+			Where("showtime_id = ? AND status <> ?", showtimeId, "CANCELLED").
+			Updates(map[string]interface{}{"status": "CANCELLED", "cancelled_at": now}).Error; err != nil {
+			return err
+		}
+
+		// Giải phóng toàn bộ ghế (kể cả ghế đang giữ)
+		if err := tx.Model(&model.ShowtimeSeat{}).
+			Where("showtime_id = ?", showtimeId).
+			Updates(map[string]interface{}{"status": SeatAvailable, "held_by": "", "expired_at": nil}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&showtime).Updates(map[string]interface{}{
+			"status":        "CANCELLED",
+			"cancelled_at":  now,
+			"cancel_reason": input.Reason,
+		}).Error
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Hủy suất chiếu thất bại", err)
+	}
+
+	if err := db.Preload("Movie").Preload("Room.Cinema").First(&showtime, showtimeId).Error; err != nil {
+		log.Printf("Không tải lại được suất chiếu %d: %v", showtimeId, err)
+	}
+
+	// Tổng hợp cho quản lý + gửi email cho khách
+	type customerContact struct {
+		OrderCode    string  `json:"orderCode"`
+		CustomerName string  `json:"customerName"`
+		Phone        string  `json:"phone"`
+		Email        string  `json:"email,omitempty"`
+		Tickets      int     `json:"tickets"`
+		RefundAmount float64 `json:"refundAmount"`
+	}
+	var totalRefund float64
+	ticketCount := 0
+	emailed := 0
+	withoutEmail := []customerContact{}
+	contacts := []customerContact{}
+	for _, order := range orders {
+		contact := customerContact{
+			OrderCode:    order.PublicCode,
+			CustomerName: order.CustomerName,
+			Phone:        order.Phone,
+			Email:        order.Email,
+			Tickets:      len(order.Tickets),
+			RefundAmount: order.RefundAmount,
+		}
+		contacts = append(contacts, contact)
+		totalRefund += order.RefundAmount
+		ticketCount += len(order.Tickets)
+
+		if order.Email == "" {
+			withoutEmail = append(withoutEmail, contact)
+			continue
+		}
+		order.Showtime = showtime
+		go SendShowtimeCancelledEmail(order, input.Reason)
+		emailed++
+	}
+	go BroadcastShowtime(showtimeId)
+
+	log.Printf("Suất chiếu %d bị hủy bởi tài khoản %d: %d đơn, hoàn %.0fđ", showtimeId, accountInfo.AccountId, len(orders), totalRefund)
+
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message":          "Đã hủy suất chiếu",
+		"showtimeId":       showtimeId,
+		"movie":            showtime.Movie.Title,
+		"room":             showtime.Room.Name,
+		"start":            showtime.StartTime,
+		"reason":           input.Reason,
+		"ordersCancelled":  len(orders),
+		"ticketsCancelled": ticketCount,
+		"totalRefund":      totalRefund,
+		"emailsSent":       emailed,
+		"withoutEmail":     withoutEmail, // cần liên hệ qua điện thoại
+		"orders":           contacts,
+	})
+}
+
+// MoveShowtime dời suất chiếu sang phòng/giờ khác, chuyển vé đã bán sang ghế tương ứng ở phòng mới
+// và báo khách (kèm quyền hủy hoàn 100%).
+func MoveShowtime(c *fiber.Ctx) error {
+	input := c.Locals("input").(model.MoveShowtimeInput)
+	showtimeId := c.Locals("showtimeId").(uint)
+	targetRoom := c.Locals("targetRoom").(model.Room)
+	startTime := c.Locals("startTime").(time.Time)
+	endTime := c.Locals("endTime").(time.Time)
+
+	db := database.DB
+	var before model.Showtime
+	var tickets []model.Ticket
+	var unmapped []string
+	seatChanges := make(map[uint]model.Seat) // ticketId → ghế mới
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Room").First(&before, showtimeId).Error; err != nil {
+			return err
+		}
+		if err := tx.Preload("ShowtimeSeat").Preload("Seat").
+			Where("showtime_id = ? AND status <> ?", showtimeId, "CANCELLED").
+			Find(&tickets).Error; err != nil {
+			return err
+		}
+
+		if targetRoom.ID != before.RoomId {
+			var targetSeats []model.Seat
+			if err := tx.Where("room_id = ?", targetRoom.ID).Find(&targetSeats).Error; err != nil {
+				return err
+			}
+			sources := make([]model.Seat, 0, len(tickets))
+			for _, t := range tickets {
+				sources = append(sources, t.Seat)
+			}
+			mapping, missing := helper.MapSeatsToRoom(sources, targetSeats)
+			if len(missing) > 0 {
+				unmapped = missing
+				return errors.New("không thể ánh xạ ghế sang phòng mới")
+			}
+
+			// Tạo sơ đồ ghế mới trước, chuyển vé, rồi mới xóa sơ đồ cũ (vé đang tham chiếu)
+			var oldSeatIDs []uint
+			if err := tx.Model(&model.ShowtimeSeat{}).Where("showtime_id = ?", showtimeId).Pluck("id", &oldSeatIDs).Error; err != nil {
+				return err
+			}
+			if err := helper.CreateShowtimeSeats(tx, showtimeId, targetRoom.ID); err != nil {
+				return err
+			}
+			var newSeats []model.ShowtimeSeat
+			if err := tx.Where("showtime_id = ? AND id NOT IN ?", showtimeId, append(oldSeatIDs, 0)).Find(&newSeats).Error; err != nil {
+				return err
+			}
+			newBySeat := make(map[uint]model.ShowtimeSeat)
+			for _, ss := range newSeats {
+				newBySeat[ss.SeatId] = ss
+			}
+
+			for _, t := range tickets {
+				target := mapping[t.SeatId]
+				newSeat := newBySeat[target.ID]
+				if err := tx.Model(&model.ShowtimeSeat{}).Where("id = ?", newSeat.ID).Updates(map[string]interface{}{
+					"status":     t.ShowtimeSeat.Status,
+					"held_by":    t.ShowtimeSeat.HeldBy,
+					"expired_at": t.ShowtimeSeat.ExpiredAt,
+				}).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&model.Ticket{}).Where("id = ?", t.ID).Updates(map[string]interface{}{
+					"seat_id":          target.ID,
+					"showtime_seat_id": newSeat.ID,
+				}).Error; err != nil {
+					return err
+				}
+				seatChanges[t.ID] = target
+			}
+
+			// Vé đã hủy không chuyển ghế: bỏ liên kết sơ đồ cũ, giữ seat_id là ghế khách đã đặt ở phòng cũ
+			if err := tx.Model(&model.Ticket{}).
+				Where("showtime_id = ? AND status = ? AND showtime_seat_id IN ?", showtimeId, "CANCELLED", append(oldSeatIDs, 0)).
+				Update("showtime_seat_id", nil).Error; err != nil {
+				return err
+			}
+
+			if len(oldSeatIDs) > 0 {
+				if err := tx.Where("id IN ?", oldSeatIDs).Delete(&model.ShowtimeSeat{}).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.Model(&model.Showtime{}).Where("id = ?", showtimeId).Updates(map[string]interface{}{
+			"room_id":    targetRoom.ID,
+			"start_time": startTime,
+			"end_time":   endTime,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Đơn đặt chỗ chưa thanh toán: dời hạn giữ chỗ (đơn và ghế đang giữ) theo giờ chiếu mới,
+		// giữ nguyên số phút trước giờ chiếu của từng đơn
+		if shift := startTime.Sub(before.StartTime); shift != 0 {
+			var reservations []model.Order
+			if err := tx.Where("showtime_id = ? AND status = ? AND reserved_until IS NOT NULL", showtimeId, model.OrderReserved).
+				Find(&reservations).Error; err != nil {
+				return err
+			}
+			for _, order := range reservations {
+				cutoff := order.ReservedUntil.Add(shift)
+				if err := tx.Model(&model.Order{}).Where("id = ?", order.ID).Update("reserved_until", cutoff).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&model.ShowtimeSeat{}).
+					Where("showtime_id = ? AND status = ? AND held_by = ?", showtimeId, helper.SeatReserved, helper.ReservationHolder(order.PublicCode)).
+					Update("expired_at", cutoff).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// Khách được hủy hoàn 100% đến giờ chiếu mới
+		return tx.Model(&model.Order{}).
+			Where("showtime_id = ? AND status <> ?", showtimeId, "CANCELLED").
+			Update("full_refund_until", startTime).Error
+	})
+	if len(unmapped) > 0 {
+		return utils.ErrorResponseHaveKey(c, fiber.StatusConflict,
+			fmt.Sprintf("Phòng %s không có ghế tương đương cho: %s", targetRoom.Name, strings.Join(unmapped, ", ")),
+			err, "roomId")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Dời suất chiếu thất bại", err)
+	}
+
+	// Thông báo khách
+	var after model.Showtime
+	db.Preload("Movie").Preload("Room.Cinema").First(&after, showtimeId)
+	var orders []model.Order
+	db.Preload("Tickets.ShowtimeSeat.Seat").
+		Where("showtime_id = ? AND status <> ?", showtimeId, "CANCELLED").
+		Find(&orders)
+
+	oldSeatLabels := make(map[uint]string)
+	for _, t := range tickets {
+		oldSeatLabels[t.ID] = helper.SeatLabel(t.Seat)
+	}
+	loc := time.FixedZone("ICT", 7*3600)
+	notify := input.Notify == nil || *input.Notify
+	emailed := 0
+	withoutEmail := []fiber.Map{}
+	moved := []fiber.Map{}
+	for _, order := range orders {
+		oldSeats := make([]string, 0, len(order.Tickets))
+		for _, t := range order.Tickets {
+			oldSeats = append(oldSeats, oldSeatLabels[t.ID])
+			if target, ok := seatChanges[t.ID]; ok {
+				moved = append(moved, fiber.Map{
+					"orderCode":  order.PublicCode,
+					"ticketCode": t.TicketCode,
+					"from":       oldSeatLabels[t.ID],
+					"to":         helper.SeatLabel(target),
+				})
+			}
+		}
+		if order.Email == "" {
+			withoutEmail = append(withoutEmail, fiber.Map{
+				"orderCode":    order.PublicCode,
+				"customerName": order.CustomerName,
+				"phone":        order.Phone,
+			})
+			continue
+		}
+		if !notify {
+			continue
+		}
+		data := utils.ShowtimeChangeData{
+			CustomerName: order.CustomerName,
+			OrderCode:    order.PublicCode,
+			MovieName:    after.Movie.Title,
+			CinemaName:   after.Room.Cinema.Name,
+			RoomName:     after.Room.Name,
+			Showtime:     after.StartTime.In(loc).Format("15:04 - 02/01/2006"),
+			Seats:        orderSeatLabels(order),
+			TotalAmount:  order.TotalAmount,
+			RefundAmount: order.TotalAmount,
+			OldShowtime:  before.StartTime.In(loc).Format("15:04 - 02/01/2006"),
+			OldRoomName:  before.Room.Name,
+			OldSeats:     strings.Join(oldSeats, ", "),
+			DetailLink:   fmt.Sprintf("%s/don-hang/%s", os.Getenv("FRONTEND_URL"), order.PublicCode),
+			CancelLink:   fmt.Sprintf("%s/huy-don/%s", os.Getenv("FRONTEND_URL"), order.PublicCode),
+		}
+		go SendShowtimeMovedEmail(order.Email, data)
+		emailed++
+	}
+	go BroadcastShowtime(showtimeId)
+
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message":      "Đã dời suất chiếu",
+		"showtime":     after,
+		"from":         fiber.Map{"room": before.Room.Name, "start": before.StartTime},
+		"to":           fiber.Map{"room": after.Room.Name, "start": after.StartTime},
+		"ticketsMoved": moved,
+		"orders":       len(orders),
+		"emailsSent":   emailed,
+		"withoutEmail": withoutEmail,
+	})
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetSpecialEvents(c *fiber.Ctx) error {
+	accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	filter := new(model.FilterSpecialEventInput)
+	if err := c.QueryParser(filter); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+	}
+
+	db := database.DB.Model(&model.SpecialEvent{})
+	if isManager && accountInfo.CinemaId != nil {
+		// Manager thấy sự kiện toàn hệ thống, của chuỗi và của rạp mình
+		var cinema model.Cinema
+		database.DB.First(&cinema, *accountInfo.CinemaId)
+		db = db.Where("(cinema_id IS NULL OR cinema_id = ?) AND (chain_id IS NULL OR chain_id = ?)", cinema.ID, cinema.ChainId)
+	}
+	if filter.Type != "" {
+		db = db.Where("type = ?", filter.Type)
+	}
+	if filter.CinemaId != 0 {
+		db = db.Where("cinema_id = ?", filter.CinemaId)
+	}
+	if filter.MovieId != 0 {
+		db = db.Where("movie_id = ?", filter.MovieId)
+	}
+	if filter.From != "" {
+		db = db.Where("end_date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		db = db.Where("start_date <= ?", filter.To)
+	}
+
+	var total int64
+	db.Count(&total)
+	db = utils.ApplyPagination(db, filter.Limit, filter.Page)
+
+	var events []model.SpecialEvent
+	db.Order("start_date DESC").Find(&events)
+	return utils.SuccessResponse(c, fiber.StatusOK, &model.ResponseCustom{
+		Rows:       events,
+		Limit:      filter.Limit,
+		Page:       filter.Page,
+		TotalCount: total,
+	})
+}
+
+func GetSpecialEventById(c *fiber.Ctx) error {
+	event := c.Locals("specialEvent").(model.SpecialEvent)
+	database.DB.Preload("Cinema").Preload("Movie").First(&event, event.ID)
+	return utils.SuccessResponse(c, fiber.StatusOK, event)
+}
+
+func CreateSpecialEvent(c *fiber.Ctx) error {
+	event := c.Locals("specialEvent").(model.SpecialEvent)
+	if err := database.DB.Create(&event).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể tạo sự kiện", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message": "Tạo sự kiện thành công",
+		"data":    event,
+	})
+}
+
+func UpdateSpecialEvent(c *fiber.Ctx) error {
+	event := c.Locals("specialEvent").(model.SpecialEvent)
+	if err := database.DB.Save(&event).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật sự kiện", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Cập nhật sự kiện thành công",
+		"data":    event,
+	})
+}
+
+func DeleteSpecialEvent(c *fiber.Ctx) error {
+	event := c.Locals("specialEvent").(model.SpecialEvent)
+	if err := database.DB.Delete(&model.SpecialEvent{}, event.ID).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể xóa sự kiện", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Đã xóa sự kiện")
+}
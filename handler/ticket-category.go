@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTicketCategories: danh sách loại vé (kể cả ngừng áp dụng) cho trang quản trị
+func GetTicketCategories(c *fiber.Ctx) error {
+	_, isAdmin, isManager, _, isStaff := helper.GetInfoAccountFromToken(c)
+	if !isAdmin && !isManager && !isStaff {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+	}
+	var categories []model.TicketCategory
+	database.DB.Order("sort_order ASC, id ASC").Find(&categories)
+	return utils.SuccessResponse(c, fiber.StatusOK, categories)
+}
+
+func GetTicketCategoryById(c *fiber.Ctx) error {
+	category := c.Locals("ticketCategory").(model.TicketCategory)
+	return utils.SuccessResponse(c, fiber.StatusOK, category)
+}
+
+func CreateTicketCategory(c *fiber.Ctx) error {
+	category := c.Locals("ticketCategory").(model.TicketCategory)
+	if err := database.DB.Create(&category).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể tạo loại vé", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, fiber.Map{
+		"message": "Tạo loại vé thành công",
+		"data":    category,
+	})
+}
+
+func UpdateTicketCategory(c *fiber.Ctx) error {
+	category := c.Locals("ticketCategory").(model.TicketCategory)
+	if err := database.DB.Save(&category).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể cập nhật loại vé", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Cập nhật loại vé thành công",
+		"data":    category,
+	})
+}
+
+func DeleteTicketCategory(c *fiber.Ctx) error {
+	category := c.Locals("ticketCategory").(model.TicketCategory)
+	if err := database.DB.Delete(&model.TicketCategory{}, category.ID).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể xóa loại vé", err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Đã xóa loại vé")
+}
+
+// GetShowtimeTicketCategories: loại vé khách chọn được cho suất chiếu (theo phân loại độ tuổi của phim)
+// kèm giá từng loại ghế để hiển thị khi thanh toán
+func GetShowtimeTicketCategories(c *fiber.Ctx) error {
+	var showtime model.Showtime
+	if err := database.DB.Preload("Movie").Where("public_code = ?", c.Params("code")).First(&showtime).Error; err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Suất chiếu không tồn tại", err)
+	}
+	var seatTypes []model.SeatType
+	database.DB.Order("id ASC").Find(&seatTypes)
+	var categories []model.TicketCategory
+	database.DB.Where("is_active = ?", true).Order("sort_order ASC, id ASC").Find(&categories)
+
+	prices := func(category *model.TicketCategory) fiber.Map {
+		result := fiber.Map{}
+		for _, seatType := range seatTypes {
+			result[seatType.Type] = helper.TicketCategoryPrice(category, showtime.Price, seatType.PriceModifier)
+		}
+		return result
+	}
+	result := []fiber.Map{{
+		"code":            model.TicketCategoryAdult,
+		"name":            "Người lớn",
+		"requiresIdCheck": false,
+		"prices":          prices(nil),
+	}}
+	for i := range categories {
+		category := &categories[i]
+		if !helper.TicketCategoryAllows(*category, showtime.Movie.AgeRestriction) {
+			continue
+		}
+		result = append(result, fiber.Map{
+			"code":            category.Code,
+			"name":            category.Name,
+			"description":     category.Description,
+			"requiresIdCheck": category.RequiresIdCheck,
+			"prices":          prices(category),
+		})
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, result)
+}
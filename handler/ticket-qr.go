@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"cinema_manager/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetQRPublicKey: khóa công khai Ed25519 để máy soát vé tự kiểm tra QR khi mất kết nối.
+// Chữ ký ký trên phần payload (chuỗi base64url), payload gồm mã đơn, mã vé, suất chiếu và hạn dùng.
+func GetQRPublicKey(c *fiber.Ctx) error {
+	publicKey, keyId, err := utils.QRPublicKey()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error(), err)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, fiber.Map{
+		"algorithm": "Ed25519",
+		"keyId":     keyId,
+		"publicKey": publicKey,
+		"format":    utils.QRTokenPrefix + "<base64url(payload JSON)>.<base64url(chữ ký)>",
+		"fields": fiber.Map{
+			"o":   "mã đơn hàng",
+			"t":   "danh sách mã vé",
+			"s":   "mã suất chiếu",
+			"st":  "giờ chiếu (unix giây)",
+			"exp": "hạn dùng (unix giây)",
+			"kid": "mã khóa ký",
+		},
+	})
+}
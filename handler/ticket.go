@@ -7,6 +7,7 @@ import (
 	"cinema_manager/helper"
 	"cinema_manager/model"
 	"cinema_manager/utils"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"html/template"
@@ -112,7 +113,7 @@ func CreateTicket(c *fiber.Ctx) error {
 	for _, seat := range heldSeats {
 		ticket := model.Ticket{
 			OrderId:        order.ID,
-			ShowtimeSeatId: seat.ID,
+			ShowtimeSeatId: &seat.ID,
 			TicketCode:     "TKT-COUNTER-" + uuid.New().String()[:10],
 			Status:         "ISSUED",
 			IssuedAt:       now,
@@ -177,8 +178,12 @@ func CreateTicket(c *fiber.Ctx) error {
 
 			// Đính kèm QR code cho từng vé
 			for _, ticket := range tickets {
-				qrContent := fmt.Sprintf("https://yourdomain.com/checkin/%s", ticket.TicketCode) // Link check-in hoặc mã vé
-				qrBytes, err := utils.GenerateQRCode(qrContent, 256)                             // Kích thước 256x256
+				qrContent, err := helper.OrderQRContent(order, showtime, []string{ticket.TicketCode}) // QR đã ký cho từng vé
+				if err != nil {
+					log.Printf("Lỗi ký QR cho vé %s: %v", ticket.TicketCode, err)
+					continue
+				}
+				qrBytes, err := utils.GenerateQRCode(qrContent, 256) // Kích thước 256x256
 				if err != nil {
 					log.Printf("Lỗi tạo QR cho vé %s: %v", ticket.TicketCode, err)
 					continue
@@ -349,19 +354,27 @@ func GetTicketQRCode(c *fiber.Ctx) error {
 	ticketId, _ := c.ParamsInt("id")
 
 	var ticket model.Ticket
-	if err := database.DB.Where("id = ?", ticketId).First(&ticket).Error; err != nil {
+	if err := database.DB.Preload("Order").Preload("Showtime.Movie").Where("id = ?", ticketId).First(&ticket).Error; err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Vé không tồn tại"})
 	}
 
-	// Generate QR Code URL (giả lập)
-	qrUrl := fmt.Sprintf("https://api.qrserver.com/v1/create-qr-code/?size=200x200&data=TKT-%s", ticket.TicketCode)
+	// QR ký Ed25519 cho riêng vé này, tạo tại server (không gửi nội dung QR ra dịch vụ bên ngoài)
+	qrContent, err := helper.OrderQRContent(ticket.Order, ticket.Showtime, []string{ticket.TicketCode})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Không thể tạo QR Code"})
+	}
+	qrBytes, err := utils.GenerateQRCode(qrContent, 256)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Không thể tạo QR Code"})
+	}
 
 	return c.JSON(fiber.Map{
 		"message": "Lấy QR Code thành công",
 		"data": fiber.Map{
 			"ticketId":    ticket.ID,
 			"bookingCode": ticket.TicketCode,
-			"qrUrl":       qrUrl,
+			"qrCode":      "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrBytes),
+			"qrContent":   qrContent,
 		},
 	})
 }
@@ -0,0 +1,47 @@
+package helper
+
+import (
+	"fmt"
+	"time"
+)
+
+// MinimumAge: tuổi tối thiểu theo phân loại phim (P, K không giới hạn)
+func MinimumAge(rating string) int {
+	switch rating {
+	case "T13":
+		return 13
+	case "T16":
+		return 16
+	case "T18":
+		return 18
+	}
+	return 0
+}
+
+// AgeAt: số tuổi tròn tại thời điểm t
+func AgeAt(dob time.Time, t time.Time) int {
+	age := t.Year() - dob.Year()
+	if t.Month() < dob.Month() || (t.Month() == dob.Month() && t.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
+
+// CheckBuyerAge kiểm tra người mua online với phim giới hạn độ tuổi:
+// có ngày sinh thì chặn nếu chưa đủ tuổi vào giờ chiếu, chưa có thì bắt buộc xác nhận đủ tuổi.
+func CheckBuyerAge(rating string, dob *time.Time, startTime time.Time, confirmed bool) error {
+	minAge := MinimumAge(rating)
+	if minAge == 0 {
+		return nil
+	}
+	if dob != nil {
+		if AgeAt(*dob, startTime) < minAge {
+			return fmt.Errorf("phim %s chỉ dành cho khán giả từ %d tuổi trở lên", rating, minAge)
+		}
+		return nil
+	}
+	if !confirmed {
+		return fmt.Errorf("phim %s chỉ dành cho khán giả từ %d tuổi trở lên, vui lòng xác nhận người xem đủ tuổi và mang giấy tờ tùy thân", rating, minAge)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Lịch .ics cho khách (vé đã đặt) và cho đối tác (lịch chiếu của rạp). UID gắn với id đơn/suất chiếu
+// nên khi suất bị dời hoặc hủy, ứng dụng lịch cập nhật đúng sự kiện cũ thay vì tạo sự kiện mới
+
+func ShowtimeUID(id uint) string {
+	return fmt.Sprintf("showtime-%d@cinema-manager", id)
+}
+
+func OrderUID(id uint) string {
+	return fmt.Sprintf("order-%d@cinema-manager", id)
+}
+
+// CustomerFeedURL: đường dẫn lịch riêng của khách (token bí mật thay cho đăng nhập vì ứng dụng lịch không gửi JWT)
+func CustomerFeedURL(token string) string {
+	return fmt.Sprintf("%s/api/v1/khach-hang/lich-ve/%s", os.Getenv("APP_URL"), token)
+}
+
+// icalSequence: SEQUENCE phải tăng mỗi lần sự kiện đổi, dùng thời điểm cập nhật muộn nhất
+func icalSequence(times ...time.Time) (int, time.Time) {
+	var latest time.Time
+	for _, t := range times {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	if latest.IsZero() {
+		return 0, latest
+	}
+	return int(latest.Unix()), latest
+}
+
+func cinemaLocation(cinema model.Cinema) string {
+	if len(cinema.Addresses) > 0 && cinema.Addresses[0].FullAddress != "" {
+		return cinema.Name + ", " + cinema.Addresses[0].FullAddress
+	}
+	return cinema.Name
+}
+
+// ShowtimeICalEvent: suất chiếu cần preload Movie và Room
+func ShowtimeICalEvent(showtime model.Showtime, cinema model.Cinema) utils.ICalEvent {
+	sequence, modified := icalSequence(showtime.UpdatedAt)
+	event := utils.ICalEvent{
+		UID:          ShowtimeUID(showtime.ID),
+		Summary:      fmt.Sprintf("%s (%s - %s)", showtime.Movie.Title, showtime.Format, utils.GetLanguageLabel(utils.LanguageType(showtime.LanguageType))),
+		Description:  fmt.Sprintf("Phòng: %s\nGiá vé từ: %.0fđ\nMã suất chiếu: %s", showtime.Room.Name, showtime.Price, showtime.PublicCode),
+		Location:     cinemaLocation(cinema),
+		URL:          fmt.Sprintf("%s/dat-ve/%s", os.Getenv("FRONTEND_URL"), showtime.PublicCode),
+		Start:        showtime.StartTime,
+		End:          showtime.EndTime,
+		Status:       "CONFIRMED",
+		Sequence:     sequence,
+		LastModified: modified,
+	}
+	if showtime.Status == "CANCELLED" {
+		event.Status = "CANCELLED"
+	}
+	return event
+}
+
+// OrderICalEvent: một đơn = một sự kiện (một suất chiếu); đơn cần preload Showtime.Movie, Showtime.Room,
+// Tickets.ShowtimeSeat. Đơn hoặc suất bị hủy vẫn xuất với STATUS:CANCELLED để lịch của khách xóa theo
+func OrderICalEvent(order model.Order, cinema model.Cinema) utils.ICalEvent {
+	seats := []string{}
+	for _, ticket := range order.Tickets {
+		if ticket.Status == "CANCELLED" && order.Status == "PAID" {
+			continue
+		}
+		seats = append(seats, fmt.Sprintf("%s%d", ticket.ShowtimeSeat.SeatRow, ticket.ShowtimeSeat.SeatNumber))
+	}
+	sequence, modified := icalSequence(order.UpdatedAt, order.Showtime.UpdatedAt)
+	event := utils.ICalEvent{
+		UID:     OrderUID(order.ID),
+		Summary: fmt.Sprintf("%s - %s", order.Showtime.Movie.Title, cinema.Name),
+		Description: fmt.Sprintf("Mã đơn: %s\nPhòng: %s\nGhế: %s\nĐịnh dạng: %s",
+			order.PublicCode, order.Showtime.Room.Name, strings.Join(seats, ", "), order.Showtime.Format),
+		Location:     cinemaLocation(cinema),
+		URL:          fmt.Sprintf("%s/don-hang/%s", os.Getenv("FRONTEND_URL"), order.PublicCode),
+		Start:        order.Showtime.StartTime,
+		End:          order.Showtime.EndTime,
+		Status:       "CONFIRMED",
+		Sequence:     sequence,
+		LastModified: modified,
+	}
+	if order.Status != "PAID" || order.Showtime.Status == "CANCELLED" {
+		event.Status = "CANCELLED"
+	}
+	return event
+}
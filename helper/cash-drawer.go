@@ -0,0 +1,242 @@
+package helper
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoCashSession: nhân viên chưa mở ca thu ngân
+var ErrNoCashSession = errors.New("bạn chưa mở ca thu ngân")
+
+// ExpectedCash: số tiền mặt phải có trong két của ca
+func ExpectedCash(session model.CashDrawerSession) float64 {
+	return math.Round((session.OpeningFloat+session.CashSales-session.CashRefunds-session.Payouts)*100) / 100
+}
+
+// GetOpenCashSession: ca đang mở của nhân viên, khóa dòng trong tx
+func GetOpenCashSession(tx *gorm.DB, accountId uint) (model.CashDrawerSession, error) {
+	var session model.CashDrawerSession
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("account_id = ? AND status = ?", accountId, model.CashSessionOpen).
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return session, ErrNoCashSession
+	}
+	return session, err
+}
+
+// OpenCashSession mở ca mới; khóa dòng tài khoản để không mở trùng hai ca cùng lúc
+func OpenCashSession(tx *gorm.DB, accountId, cinemaId uint, input model.OpenCashDrawerInput) (model.CashDrawerSession, error) {
+	var account model.Account
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&account, accountId).Error; err != nil {
+		return model.CashDrawerSession{}, err
+	}
+	if _, err := GetOpenCashSession(tx, accountId); err == nil {
+		return model.CashDrawerSession{}, errors.New("bạn đang có ca thu ngân chưa đóng")
+	} else if !errors.Is(err, ErrNoCashSession) {
+		return model.CashDrawerSession{}, err
+	}
+	session := model.CashDrawerSession{
+		CinemaId:     cinemaId,
+		AccountId:    accountId,
+		Status:       model.CashSessionOpen,
+		OpenedAt:     time.Now(),
+		OpeningFloat: math.Round(input.OpeningFloat*100) / 100,
+		OpenNote:     input.Note,
+	}
+	session.ExpectedAmount = ExpectedCash(session)
+	err := tx.Create(&session).Error
+	return session, err
+}
+
+// RecordCashMovement ghi một khoản tiền mặt vào ca (đã khóa) và cập nhật các tổng của ca.
+// amount luôn dương; chiều tiền xác định theo loại.
+func RecordCashMovement(tx *gorm.DB, session *model.CashDrawerSession, movementType string, amount float64, orderId *uint, note string, createdBy uint) (model.CashMovement, error) {
+	amount = math.Round(amount*100) / 100
+	signed := amount
+	switch movementType {
+	case model.CashMovementSale:
+		session.CashSales += amount
+	case model.CashMovementRefund:
+		session.CashRefunds += amount
+		signed = -amount
+	case model.CashMovementPayout:
+		session.Payouts += amount
+		signed = -amount
+	default:
+		return model.CashMovement{}, fmt.Errorf("loại thu chi %q không hợp lệ", movementType)
+	}
+	session.ExpectedAmount = ExpectedCash(*session)
+	if signed < 0 && session.ExpectedAmount < 0 {
+		return model.CashMovement{}, fmt.Errorf("tiền mặt trong két không đủ (còn %.0fđ)", session.ExpectedAmount+amount)
+	}
+	if err := tx.Model(&model.CashDrawerSession{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+		"cash_sales":      session.CashSales,
+		"cash_refunds":    session.CashRefunds,
+		"payouts":         session.Payouts,
+		"expected_amount": session.ExpectedAmount,
+	}).Error; err != nil {
+		return model.CashMovement{}, err
+	}
+	movement := model.CashMovement{
+		SessionId: session.ID,
+		Type:      movementType,
+		Amount:    signed,
+		OrderId:   orderId,
+		Note:      note,
+		CreatedBy: createdBy,
+	}
+	err := tx.Create(&movement).Error
+	return movement, err
+}
+
+// RecordOrderCashSale ghi phần tiền mặt của đơn bán tại quầy vào ca đang mở của nhân viên.
+// Đơn có dòng tiền mặt mà nhân viên chưa mở ca thì báo lỗi.
+func RecordOrderCashSale(tx *gorm.DB, accountId uint, order *model.Order) error {
+	cash := 0.0
+	for _, line := range order.Payments {
+		if line.Method == model.TenderCash {
+			cash += line.Amount
+		}
+	}
+	if cash <= 0 {
+		return nil
+	}
+	session, err := GetOpenCashSession(tx, accountId)
+	if err != nil {
+		return err
+	}
+	_, err = RecordCashMovement(tx, &session, model.CashMovementSale, cash, &order.ID, "Bán vé đơn "+order.PublicCode, accountId)
+	return err
+}
+
+// CashRefundDue: tiền mặt còn phải trả cho khách của đơn đã hủy
+// (phần hoàn của các dòng tiền mặt không chuyển vào ví, trừ số đã trả qua két).
+// Khóa đơn và các dòng thanh toán của đơn (FOR UPDATE) trước khi tính để hai quầy không cùng chi
+// một khoản hoàn, nên phải gọi trong transaction và ghi khoản chi trong cùng transaction đó.
+func CashRefundDue(tx *gorm.DB, orderId uint) (float64, error) {
+	var order model.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&order, orderId).Error; err != nil {
+		return 0, err
+	}
+	var payments []model.OrderPayment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("order_id = ?", orderId).Order("id").Find(&payments).Error; err != nil {
+		return 0, err
+	}
+	var refunded float64
+	if err := tx.Model(&model.OrderPayment{}).
+		Where("order_id = ? AND method = ?", orderId, model.TenderCash).
+		Select("COALESCE(SUM(refunded_amount - refunded_to_wallet), 0)").
+		Scan(&refunded).Error; err != nil {
+		return 0, err
+	}
+	var paid float64
+	if err := tx.Model(&model.CashMovement{}).
+		Where("order_id = ? AND type = ?", orderId, model.CashMovementRefund).
+		Select("COALESCE(SUM(-amount), 0)").
+		Scan(&paid).Error; err != nil {
+		return 0, err
+	}
+	return math.Max(math.Round((refunded-paid)*100)/100, 0), nil
+}
+
+// RecordOrderCashRefund trả ngay phần hoàn tiền mặt của đơn vừa hủy qua két của nhân viên đang hủy đơn.
+// Gọi trong transaction hủy đơn, sau AllocateOrderRefund; trả về số tiền mặt đã chi.
+func RecordOrderCashRefund(tx *gorm.DB, accountId uint, order *model.Order) (float64, error) {
+	due, err := CashRefundDue(tx, order.ID)
+	if err != nil || due <= 0 {
+		return 0, err
+	}
+	session, err := GetOpenCashSession(tx, accountId)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := RecordCashMovement(tx, &session, model.CashMovementRefund, due, &order.ID, "Hoàn tiền hủy đơn "+order.PublicCode, accountId); err != nil {
+		return 0, err
+	}
+	return due, nil
+}
+
+// CloseCashSession đóng ca với số tiền đếm thực tế và tính chênh lệch
+func CloseCashSession(tx *gorm.DB, session *model.CashDrawerSession, input model.CloseCashDrawerInput) error {
+	now := time.Now()
+	counted := math.Round(*input.CountedAmount*100) / 100
+	session.ExpectedAmount = ExpectedCash(*session)
+	variance := math.Round((counted-session.ExpectedAmount)*100) / 100
+	session.Status = model.CashSessionClosed
+	session.CountedAmount = &counted
+	session.Variance = &variance
+	session.ClosedAt = &now
+	session.CloseNote = input.Note
+	if err := tx.Model(&model.CashDrawerSession{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+		"status":          session.Status,
+		"expected_amount": session.ExpectedAmount,
+		"counted_amount":  counted,
+		"variance":        variance,
+		"closed_at":       now,
+		"close_note":      input.Note,
+	}).Error; err != nil {
+		return err
+	}
+	if variance != 0 {
+		log.Printf("Lệch két: rạp %d - ca #%d của tài khoản %d lệch %.0fđ (dự kiến %.0fđ, đếm %.0fđ)",
+			session.CinemaId, session.ID, session.AccountId, variance, session.ExpectedAmount, counted)
+	}
+	return nil
+}
+
+// NotifyCashVariance gửi email báo lệch két của ca vừa đóng cho các quản lý của rạp (gọi sau khi commit)
+func NotifyCashVariance(db *gorm.DB, session model.CashDrawerSession) {
+	if session.Variance == nil || *session.Variance == 0 || session.CountedAmount == nil {
+		return
+	}
+	var emails []string
+	db.Table("staffs").
+		Joins("JOIN accounts ON accounts.id = staffs.account_id").
+		Where("accounts.role = ? AND accounts.cinema_id = ? AND accounts.active AND staffs.email <> ''", constants.ROLE_MANAGER, session.CinemaId).
+		Pluck("staffs.email", &emails)
+	if len(emails) == 0 {
+		log.Printf("Rạp %d chưa có email quản lý để gửi báo cáo lệch két ca #%d", session.CinemaId, session.ID)
+		return
+	}
+
+	var cinema model.Cinema
+	db.Select("id", "name").First(&cinema, session.CinemaId)
+	var account model.Account
+	db.Preload("Staff").First(&account, session.AccountId)
+	staffName := account.Username
+	if account.Staff != nil {
+		staffName = strings.TrimSpace(account.Staff.LastName + " " + account.Staff.FirstName)
+	}
+
+	loc := time.FixedZone("ICT", 7*3600)
+	data := utils.CashVarianceData{
+		CinemaName:     cinema.Name,
+		StaffName:      staffName,
+		SessionId:      session.ID,
+		OpenedAt:       session.OpenedAt.In(loc).Format("15:04 - 02/01/2006"),
+		OpeningFloat:   session.OpeningFloat,
+		CashSales:      session.CashSales,
+		CashRefunds:    session.CashRefunds,
+		Payouts:        session.Payouts,
+		ExpectedAmount: session.ExpectedAmount,
+		CountedAmount:  *session.CountedAmount,
+		Variance:       *session.Variance,
+		CloseNote:      session.CloseNote,
+	}
+	if session.ClosedAt != nil {
+		data.ClosedAt = session.ClosedAt.In(loc).Format("15:04 - 02/01/2006")
+	}
+	utils.SendCashVarianceEmail(emails, data)
+}
@@ -0,0 +1,117 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetCompPolicy: hạn mức vé mời của rạp, chưa cấu hình thì dùng mặc định
+func GetCompPolicy(db *gorm.DB, cinemaId uint) model.CompPolicy {
+	var policy model.CompPolicy
+	if err := db.Where("cinema_id = ?", cinemaId).First(&policy).Error; err != nil {
+		return model.CompPolicy{
+			CinemaId:          cinemaId,
+			MonthlyQuota:      model.DefaultCompMonthlyQuota,
+			ApprovalThreshold: model.DefaultCompApprovalThreshold,
+		}
+	}
+	return policy
+}
+
+// monthRange: [đầu tháng, đầu tháng sau) chứa t
+func monthRange(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+// compUsageByReason: vé mời còn hiệu lực (không tính vé đã hủy) của rạp trong tháng chứa t
+func compUsageByReason(db *gorm.DB, cinemaId uint, t time.Time) ([]model.CompUsageByReason, error) {
+	from, to := monthRange(t)
+	var rows []model.CompUsageByReason
+	// Giá trị theo giá bán chia đều theo số vé của đơn, chỉ tính phần vé chưa hủy
+	err := db.Raw(`
+SELECT
+    o.comp_reason AS reason_code,
+    SUM(tc.active) AS tickets,
+    COALESCE(SUM(o.comp_value * tc.active / NULLIF(tc.total, 0)), 0) AS face_value
+FROM orders o
+JOIN showtimes st ON st.id = o.showtime_id
+JOIN rooms r ON r.id = st.room_id
+JOIN (
+    SELECT order_id, COUNT(*) AS total, COUNT(*) FILTER (WHERE status <> 'CANCELLED') AS active
+    FROM tickets
+    GROUP BY order_id
+) tc ON tc.order_id = o.id
+WHERE o.is_comp = TRUE
+  AND r.cinema_id = ?
+  AND o.created_at >= ?
+  AND o.created_at < ?
+GROUP BY o.comp_reason
+HAVING SUM(tc.active) > 0
+ORDER BY tickets DESC`, cinemaId, from, to).Scan(&rows).Error
+	return rows, err
+}
+
+// GetCompUsage: hạn mức và số vé mời đã dùng của rạp trong tháng chứa t
+func GetCompUsage(db *gorm.DB, cinemaId uint, t time.Time) (model.CompUsage, error) {
+	policy := GetCompPolicy(db, cinemaId)
+	usage := model.CompUsage{
+		CinemaId:          cinemaId,
+		Month:             t.Format("2006-01"),
+		MonthlyQuota:      policy.MonthlyQuota,
+		ApprovalThreshold: policy.ApprovalThreshold,
+		ByReason:          []model.CompUsageByReason{},
+	}
+	rows, err := compUsageByReason(db, cinemaId, t)
+	if err != nil {
+		return usage, err
+	}
+	usage.ByReason = append(usage.ByReason, rows...)
+	for _, row := range rows {
+		usage.Used += row.Tickets
+	}
+	usage.Remaining = policy.MonthlyQuota - usage.Used
+	if usage.Remaining < 0 {
+		usage.Remaining = 0
+	}
+	return usage, nil
+}
+
+// CheckCompQuota khóa dòng rạp (tuần tự hóa việc xuất vé mời) và kiểm tra còn đủ hạn mức tháng cho quantity vé
+func CheckCompQuota(tx *gorm.DB, cinemaId uint, quantity int, now time.Time) (model.CompUsage, error) {
+	var cinema model.Cinema
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&cinema, cinemaId).Error; err != nil {
+		return model.CompUsage{}, err
+	}
+	usage, err := GetCompUsage(tx, cinemaId, now)
+	if err != nil {
+		return usage, err
+	}
+	if usage.Used+quantity > usage.MonthlyQuota {
+		return usage, fmt.Errorf("vượt hạn mức vé mời tháng %s (đã dùng %d/%d)", usage.Month, usage.Used, usage.MonthlyQuota)
+	}
+	return usage, nil
+}
+
+// UseCompRequest khóa yêu cầu đã duyệt và kiểm tra khớp suất chiếu, đủ số lượng trước khi xuất vé
+func UseCompRequest(tx *gorm.DB, requestId, showtimeId uint, quantity int) (model.CompTicketRequest, error) {
+	var request model.CompTicketRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, requestId).Error; err != nil {
+		return request, errors.New("yêu cầu vé mời không tồn tại")
+	}
+	if request.Status != model.CompRequestApproved {
+		return request, fmt.Errorf("yêu cầu vé mời đang ở trạng thái %s", request.Status)
+	}
+	if request.ShowtimeId != showtimeId {
+		return request, errors.New("yêu cầu vé mời không thuộc suất chiếu này")
+	}
+	if quantity > request.Quantity {
+		return request, fmt.Errorf("yêu cầu chỉ được duyệt %d vé", request.Quantity)
+	}
+	return request, nil
+}
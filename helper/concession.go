@@ -0,0 +1,124 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// BuildOrderConcessions kiểm tra các dòng bắp nước khách chọn (thuộc rạp, đang bán) và trả về
+// dòng đơn hàng kèm tổng tiền. Sản phẩm trùng được gộp số lượng.
+func BuildOrderConcessions(db *gorm.DB, cinemaId uint, lines []model.ConcessionLineInput) ([]model.OrderConcession, float64, error) {
+	if len(lines) == 0 {
+		return nil, 0, nil
+	}
+	quantities := make(map[uint]int)
+	productIds := make([]uint, 0, len(lines))
+	for _, line := range lines {
+		if line.ProductId == 0 || line.Quantity <= 0 {
+			return nil, 0, errors.New("dòng bắp nước không hợp lệ")
+		}
+		if _, ok := quantities[line.ProductId]; !ok {
+			productIds = append(productIds, line.ProductId)
+		}
+		quantities[line.ProductId] += line.Quantity
+	}
+
+	var products []model.ConcessionProduct
+	if err := db.Where("id IN ? AND cinema_id = ?", productIds, cinemaId).Find(&products).Error; err != nil {
+		return nil, 0, err
+	}
+	productMap := make(map[uint]model.ConcessionProduct, len(products))
+	for _, p := range products {
+		productMap[p.ID] = p
+	}
+
+	result := make([]model.OrderConcession, 0, len(productIds))
+	total := 0.0
+	for _, id := range productIds {
+		product, ok := productMap[id]
+		if !ok {
+			return nil, 0, fmt.Errorf("sản phẩm %d không thuộc rạp của suất chiếu", id)
+		}
+		if !product.IsAvailable {
+			return nil, 0, fmt.Errorf("sản phẩm \"%s\" tạm ngừng bán", product.Name)
+		}
+		amount := product.Price * float64(quantities[id])
+		result = append(result, model.OrderConcession{
+			ProductId: product.ID,
+			Name:      product.Name,
+			Category:  product.Category,
+			Quantity:  quantities[id],
+			UnitPrice: product.Price,
+			Amount:    amount,
+			Status:    "ACTIVE",
+		})
+		total += amount
+	}
+	return result, total, nil
+}
+
+// CreateOrderConcessions lưu các dòng bắp nước cho đơn hàng vừa tạo
+func CreateOrderConcessions(tx *gorm.DB, orderId uint, lines []model.OrderConcession) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	for i := range lines {
+		lines[i].OrderId = orderId
+	}
+	return tx.Create(&lines).Error
+}
+
+// CancelOrderConcessions đánh dấu hủy các dòng bắp nước của đơn hàng (khi hủy cả đơn) và hoàn kho.
+// cancelledBy = 0 khi khách tự hủy.
+func CancelOrderConcessions(tx *gorm.DB, orderId uint, cancelledBy uint) error {
+	if err := tx.Model(&model.OrderConcession{}).
+		Where("order_id = ? AND status = ?", orderId, "ACTIVE").
+		Update("status", "CANCELLED").Error; err != nil {
+		return err
+	}
+	return RestoreOrderStock(tx, orderId, cancelledBy)
+}
+
+// ConcessionLabels mô tả ngắn các dòng bắp nước, ví dụ "2 x Combo Couple, 1 x Pepsi L"
+func ConcessionLabels(lines []model.OrderConcession) string {
+	labels := make([]string, 0, len(lines))
+	for _, line := range lines {
+		labels = append(labels, fmt.Sprintf("%d x %s", line.Quantity, line.Name))
+	}
+	return strings.Join(labels, ", ")
+}
+
+// ValidateComboItems: combo phải có thành phần, thành phần là sản phẩm lẻ cùng rạp;
+// sản phẩm lẻ thì không được khai báo thành phần
+func ValidateComboItems(db *gorm.DB, cinemaId uint, category string, items []model.ConcessionComboItemInput) error {
+	if category != model.ConcessionCombo {
+		if len(items) > 0 {
+			return errors.New("chỉ sản phẩm loại COMBO mới có thành phần")
+		}
+		return nil
+	}
+	if len(items) == 0 {
+		return errors.New("combo phải có ít nhất một thành phần")
+	}
+	seen := make(map[uint]bool)
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		if seen[item.ProductId] {
+			return fmt.Errorf("thành phần %d bị trùng", item.ProductId)
+		}
+		seen[item.ProductId] = true
+		ids = append(ids, item.ProductId)
+	}
+	var count int64
+	db.Model(&model.ConcessionProduct{}).
+		Where("id IN ? AND cinema_id = ? AND category <> ?", ids, cinemaId, model.ConcessionCombo).
+		Count(&count)
+	if int(count) != len(ids) {
+		return errors.New("thành phần combo phải là sản phẩm lẻ của cùng rạp")
+	}
+	return nil
+}
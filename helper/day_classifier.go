@@ -3,23 +3,33 @@ package helper
 import (
 	"cinema_manager/database"
 	"cinema_manager/model"
+	"cinema_manager/utils"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type DayInfo struct {
-	Date           time.Time
-	Weekday        time.Weekday
-	IsWeekend      bool
-	IsFriday       bool
-	IsHoliday      bool
-	IsLunarHoliday bool
-	IsEarly        bool
-	IsSpecial      bool
-	DayTypes       []string
-	HolidayName    string
+	Date           time.Time            `json:"date"`
+	Weekday        time.Weekday         `json:"weekday"`
+	IsWeekend      bool                 `json:"isWeekend"`
+	IsFriday       bool                 `json:"isFriday"`
+	IsHoliday      bool                 `json:"isHoliday"`
+	IsLunarHoliday bool                 `json:"isLunarHoliday"`
+	IsEarly        bool                 `json:"isEarly"`
+	IsSpecial      bool                 `json:"isSpecial"`
+	DayTypes       []string             `json:"dayTypes"`
+	HolidayName    string               `json:"holidayName"`
+	Events         []model.SpecialEvent `json:"events"` // sự kiện đặc biệt áp dụng trong ngày
 }
 
+// ClassifyDay phân loại ngày, chỉ xét sự kiện đặc biệt áp dụng toàn hệ thống
 func ClassifyDay(date time.Time, movieReleaseDate time.Time) *DayInfo {
+	return ClassifyDayAt(date, movieReleaseDate, 0, 0)
+}
+
+// ClassifyDayAt phân loại ngày cho một rạp/phim cụ thể (0 = không giới hạn) để lấy đúng sự kiện đặc biệt
+func ClassifyDayAt(date time.Time, movieReleaseDate time.Time, cinemaID, movieID uint) *DayInfo {
 	info := &DayInfo{
 		Date:     date,
 		Weekday:  date.Weekday(),
@@ -38,8 +48,8 @@ func ClassifyDay(date time.Time, movieReleaseDate time.Time) *DayInfo {
 	// 4. Kiểm tra suất chiếu sớm
 	checkEarlyShow(info, movieReleaseDate)
 
-	// 5. (Tùy chọn) Kiểm tra sự kiện đặc biệt từ DB
-	// checkSpecialEvent(info)
+	// 5. Kiểm tra sự kiện đặc biệt từ DB
+	checkSpecialEvent(info, cinemaID, movieID)
 
 	return info
 }
@@ -61,78 +71,78 @@ func classifyWeekday(info *DayInfo) {
 	}
 }
 
-// === 2. Ngày lễ dương lịch ===
+// === 2. Ngày lễ dương lịch (lặp lại hằng năm theo ngày/tháng) ===
 func checkSolarHoliday(info *DayInfo) {
 	var holiday model.Holiday
-	err := database.DB.
-		Where("date = ? AND type = 'solar' AND is_recurring = true", info.Date.Format("2006-01-02")).
-		Or("date = ? AND type = 'solar' AND is_recurring = false", info.Date.Format("2006-01-02")).
-		First(&holiday).Error
-
-	if err == nil {
+	if err := solarHolidayQuery(info.Date).First(&holiday).Error; err == nil {
 		info.IsHoliday = true
 		info.DayTypes = append(info.DayTypes, "holiday")
 		info.HolidayName = holiday.Name
 	}
 }
 
-// === 3. Tết âm lịch ===
+func solarHolidayQuery(date time.Time) *gorm.DB {
+	return database.DB.
+		Where("type = 'solar' AND is_recurring = true AND EXTRACT(MONTH FROM date) = ? AND EXTRACT(DAY FROM date) = ?",
+			int(date.Month()), date.Day()).
+		Or("type = 'solar' AND is_recurring = false AND date = ?", date.Format("2006-01-02"))
+}
+
+// === 3. Tết âm lịch + ngày lễ âm lịch (lặp lại theo ngày âm) ===
 func checkLunarHoliday(info *DayInfo) {
 	year := info.Date.Year()
 	if year < 1900 || year > 2100 {
 		return
 	}
 
-	solar := info.Date
-	_, lunarMonth, lunarDay, _ := SolarToLunar(solar)
+	_, lunarMonth, lunarDay, isLeap := utils.SolarToLunar(info.Date)
 
-	// Tết âm lịch
-	if lunarMonth == 1 && (lunarDay == 1 || lunarDay == 2 || lunarDay == 3) {
+	// Tết âm lịch (không tính tháng Giêng nhuận)
+	if !isLeap && lunarMonth == 1 && (lunarDay == 1 || lunarDay == 2 || lunarDay == 3) {
 		info.IsLunarHoliday = true
 		info.DayTypes = append(info.DayTypes, "lunar_holiday")
 		info.HolidayName = "Tết Âm lịch"
 	}
-}
-func SolarToLunar(t time.Time) (lunarYear, lunarMonth, lunarDay int, isLeap bool) {
-	lunarYear = t.Year()
-	lunarMonth = int(t.Month())
-	lunarDay = t.Day()
-
-	// Tính offset từ 1900-01-31
-	offset := (t.YearDay() - 31) + (t.Year()-1900)*365 + (t.Year()-1900)/4
-	if t.Month() < 3 {
-		offset -= (t.Year() - 1900) / 4
+
+	var holiday model.Holiday
+	if err := lunarHolidayQuery(info.Date).First(&holiday).Error; err != nil {
+		return
 	}
+	if !info.IsHoliday {
+		info.IsHoliday = true
+		info.DayTypes = append(info.DayTypes, "holiday")
+	}
+	if info.HolidayName == "" {
+		info.HolidayName = holiday.Name
+	}
+}
 
-	// Lấy dữ liệu năm âm
-	info := lunarOffset[t.Year()-1900]
-	leap := 0
-	if info&0x0000F != 0 {
-		leap = int(info & 0x0000F)
+func lunarHolidayQuery(date time.Time) *gorm.DB {
+	_, lunarMonth, lunarDay, isLeap := utils.SolarToLunar(date)
+
+	// Ngày lễ âm lịch trong DB: ngày 30 khớp cả ngày cuối của tháng thiếu (VD 30 Tết năm tháng Chạp chỉ 29 ngày)
+	days := []int{lunarDay}
+	if lunarDay == 29 && utils.IsLastLunarDay(date) {
+		days = append(days, 30)
+	}
+	// Tháng nhuận không lặp lại ngày lễ của tháng chính
+	query := database.DB.Where("type = 'lunar' AND is_recurring = false AND date = ?", date.Format("2006-01-02"))
+	if !isLeap {
+		query = query.Or("type = 'lunar' AND is_recurring = true AND lunar_month = ? AND lunar_day IN ?", lunarMonth, days)
 	}
+	return query
+}
 
-	for m := 1; m <= 12; m++ {
-		days := 29
-		if info&0x10000 != 0 {
-			days = 30
-		}
-		info <<= 1
-
-		if offset < days {
-			if leap > 0 && m > leap {
-				lunarMonth = m
-			} else if leap > 0 && m == leap+1 {
-				isLeap = true
-				lunarMonth = m - 1
-			} else {
-				lunarMonth = m
-			}
-			lunarDay = offset + 1
-			return
-		}
-		offset -= days
+// FindHolidays trả về mọi ngày lễ (dương + âm lịch) trong bảng Holiday rơi vào ngày date
+func FindHolidays(date time.Time) []model.Holiday {
+	holidays := []model.Holiday{}
+	solarHolidayQuery(date).Find(&holidays)
+	if year := date.Year(); year >= 1900 && year <= 2100 {
+		var lunar []model.Holiday
+		lunarHolidayQuery(date).Find(&lunar)
+		holidays = append(holidays, lunar...)
 	}
-	return
+	return holidays
 }
 
 // === 4. Suất chiếu sớm (7 ngày trước công chiếu) ===
@@ -145,3 +155,19 @@ func checkEarlyShow(info *DayInfo, releaseDate time.Time) {
 		info.DayTypes = append(info.DayTypes, "early")
 	}
 }
+
+// === 5. Sự kiện đặc biệt (công chiếu, liên hoan phim, suất fan, marathon) ===
+func checkSpecialEvent(info *DayInfo, cinemaID, movieID uint) {
+	events := FindSpecialEvents(database.DB, info.Date, cinemaID, movieID)
+	if len(events) == 0 {
+		return
+	}
+	info.IsSpecial = true
+	info.Events = events
+	info.DayTypes = append(info.DayTypes, "special")
+	for _, event := range events {
+		if !Contains(info.DayTypes, event.Type) {
+			info.DayTypes = append(info.DayTypes, event.Type)
+		}
+	}
+}
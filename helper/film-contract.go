@@ -0,0 +1,224 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CheckFilmLicense: phim chưa có hợp đồng phát hành nào thì không giới hạn (phim nhập trước khi có hợp đồng);
+// đã có hợp đồng thì ngày chiếu phải thuộc một hợp đồng đang hiệu lực áp dụng cho chuỗi của rạp
+func CheckFilmLicense(db *gorm.DB, movieID, cinemaID uint, date time.Time) error {
+	var contracts []model.FilmContract
+	db.Where("movie_id = ?", movieID).Find(&contracts)
+	if len(contracts) == 0 {
+		return nil
+	}
+	var chainID uint
+	db.Model(&model.Cinema{}).Select("chain_id").Where("id = ?", cinemaID).Scan(&chainID)
+
+	day := date.Format("2006-01-02")
+	for _, contract := range contracts {
+		if contract.Status != "active" {
+			continue
+		}
+		if contract.ChainId != nil && *contract.ChainId != chainID {
+			continue
+		}
+		if day >= contract.StartDate.Format("2006-01-02") && day <= contract.EndDate.Format("2006-01-02") {
+			return nil
+		}
+	}
+	return fmt.Errorf("Ngày %s nằm ngoài thời hạn hợp đồng phát hành của phim", date.Format("02/01/2006"))
+}
+
+// CheckFilmLicenseRange kiểm tra hợp đồng cho mọi ngày trong [from, to] tại các rạp
+func CheckFilmLicenseRange(db *gorm.DB, movieID uint, cinemaIDs []uint, from, to time.Time) error {
+	checked := map[uint]bool{}
+	for _, cinemaID := range cinemaIDs {
+		if checked[cinemaID] {
+			continue
+		}
+		checked[cinemaID] = true
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			if err := CheckFilmLicense(db, movieID, cinemaID, day); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateRevenueShareTiers: các bậc không trùng tuần và phải có bậc bắt đầu từ tuần 1
+func ValidateRevenueShareTiers(tiers []model.RevenueShareTier) error {
+	seen := map[int]bool{}
+	for _, tier := range tiers {
+		if seen[tier.FromWeek] {
+			return fmt.Errorf("Bậc chia doanh thu tuần %d bị trùng", tier.FromWeek)
+		}
+		seen[tier.FromWeek] = true
+	}
+	if !seen[1] {
+		return fmt.Errorf("Cần có bậc chia doanh thu bắt đầu từ tuần 1")
+	}
+	return nil
+}
+
+// RevenueSharePercent: tỷ lệ của bậc có FromWeek lớn nhất không vượt quá week
+func RevenueSharePercent(tiers []model.RevenueShareTier, week int) float64 {
+	percent, from := 0.0, 0
+	for _, tier := range tiers {
+		if tier.FromWeek <= week && tier.FromWeek > from {
+			percent, from = tier.Percent, tier.FromWeek
+		}
+	}
+	return percent
+}
+
+// ReleaseWeek: tuần công chiếu của ngày date (suất chiếu sớm trước ngày khởi chiếu tính vào tuần 1)
+func ReleaseWeek(release, date time.Time) int {
+	releaseDay := time.Date(release.Year(), release.Month(), release.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(day.Sub(releaseDay).Hours() / 24)
+	if days < 0 {
+		return 1
+	}
+	return days/7 + 1
+}
+
+// BuildFilmSettlement tính số tiền trả nhà phát hành theo từng tuần công chiếu từ doanh thu vé của đơn PAID
+// (ActualRevenue trừ tiền bắp nước, nhà phát hành không được chia phần đồ ăn), trong phần giao giữa [from, to] và thời hạn hợp đồng. Contract cần preload Movie và Distributor.
+func BuildFilmSettlement(db *gorm.DB, contract model.FilmContract, from, to time.Time) model.FilmSettlement {
+	loc := time.FixedZone("ICT", 7*3600)
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	if contractStart := time.Date(contract.StartDate.Year(), contract.StartDate.Month(), contract.StartDate.Day(), 0, 0, 0, 0, loc); contractStart.After(start) {
+		start = contractStart
+	}
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+	if contractEnd := time.Date(contract.EndDate.Year(), contract.EndDate.Month(), contract.EndDate.Day(), 0, 0, 0, 0, loc); contractEnd.Before(end) {
+		end = contractEnd
+	}
+
+	settlement := model.FilmSettlement{
+		ContractId:    contract.ID,
+		ContractCode:  contract.Code,
+		DistributorId: contract.DistributorId,
+		MovieId:       contract.MovieId,
+		From:          start.Format("2006-01-02"),
+		To:            end.Format("2006-01-02"),
+		Weeks:         []model.FilmSettlementWeek{},
+		ShortfallDays: []model.FilmShortfallDay{},
+	}
+	if contract.Distributor != nil {
+		settlement.DistributorName = contract.Distributor.Name
+	}
+	var release time.Time
+	if contract.Movie != nil {
+		settlement.MovieTitle = contract.Movie.Title
+		release = contract.Movie.DateRelease.Time
+	}
+	if end.Before(start) {
+		return settlement
+	}
+	endExclusive := end.AddDate(0, 0, 1)
+
+	scope := func(query *gorm.DB) *gorm.DB {
+		query = query.
+			Joins("JOIN rooms ON rooms.id = showtimes.room_id").
+			Joins("JOIN cinemas ON cinemas.id = rooms.cinema_id").
+			Where("showtimes.movie_id = ? AND showtimes.start_time >= ? AND showtimes.start_time < ?", contract.MovieId, start, endExclusive)
+		if contract.ChainId != nil {
+			query = query.Where("cinemas.chain_id = ?", *contract.ChainId)
+		}
+		return query
+	}
+
+	// Doanh thu vé thực theo suất chiếu (không gồm bắp nước), gom theo tuần công chiếu ở Go để dùng cùng múi giờ ICT
+	var rows []struct {
+		StartTime time.Time
+		Orders    int64
+		Revenue   float64
+	}
+	scope(db.Table("orders").Joins("JOIN showtimes ON showtimes.id = orders.showtime_id")).
+		Select("showtimes.start_time, COUNT(orders.id) AS orders, COALESCE(SUM(GREATEST(orders.actual_revenue - orders.concession_amount, 0)), 0) AS revenue").
+		Where("orders.status = ?", "PAID").
+		Group("showtimes.id, showtimes.start_time").
+		Scan(&rows)
+
+	weeks := map[int]*model.FilmSettlementWeek{}
+	for _, row := range rows {
+		week := ReleaseWeek(release, row.StartTime.In(loc))
+		item, ok := weeks[week]
+		if !ok {
+			weekStart := time.Date(release.Year(), release.Month(), release.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, (week-1)*7)
+			item = &model.FilmSettlementWeek{
+				Week:    week,
+				From:    weekStart.Format("2006-01-02"),
+				To:      weekStart.AddDate(0, 0, 6).Format("2006-01-02"),
+				Percent: RevenueSharePercent(contract.RevenueShareTiers, week),
+			}
+			weeks[week] = item
+		}
+		item.Orders += row.Orders
+		item.Revenue += row.Revenue
+	}
+	for _, item := range weeks {
+		item.AmountOwed = math.Round(item.Revenue * item.Percent / 100)
+		settlement.Revenue += item.Revenue
+		settlement.AmountOwed += item.AmountOwed
+		settlement.Weeks = append(settlement.Weeks, *item)
+	}
+	sort.Slice(settlement.Weeks, func(i, j int) bool { return settlement.Weeks[i].Week < settlement.Weeks[j].Week })
+
+	// Số suất tối thiểu mỗi ngày: xét các rạp đã xếp phim trong kỳ, đến hết hôm qua
+	if contract.MinShowtimesPerDay > 0 {
+		today := time.Now().In(loc)
+		checkEnd := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+		if endExclusive.Before(checkEnd) {
+			checkEnd = endExclusive
+		}
+		var counts []struct {
+			CinemaId   uint
+			CinemaName string
+			StartTime  time.Time
+		}
+		scope(db.Table("showtimes")).
+			Select("cinemas.id AS cinema_id, cinemas.name AS cinema_name, showtimes.start_time").
+			Where("showtimes.status <> ?", "CANCELLED").
+			Scan(&counts)
+
+		cinemaNames := map[uint]string{}
+		perDay := map[uint]map[string]int64{}
+		for _, row := range counts {
+			cinemaNames[row.CinemaId] = row.CinemaName
+			if perDay[row.CinemaId] == nil {
+				perDay[row.CinemaId] = map[string]int64{}
+			}
+			perDay[row.CinemaId][row.StartTime.In(loc).Format("2006-01-02")]++
+		}
+		cinemaIDs := make([]uint, 0, len(perDay))
+		for id := range perDay {
+			cinemaIDs = append(cinemaIDs, id)
+		}
+		sort.Slice(cinemaIDs, func(i, j int) bool { return cinemaIDs[i] < cinemaIDs[j] })
+		for day := start; day.Before(checkEnd); day = day.AddDate(0, 0, 1) {
+			key := day.Format("2006-01-02")
+			for _, id := range cinemaIDs {
+				if count := perDay[id][key]; count < int64(contract.MinShowtimesPerDay) {
+					settlement.ShortfallDays = append(settlement.ShortfallDays, model.FilmShortfallDay{
+						Date:       key,
+						CinemaId:   id,
+						CinemaName: cinemaNames[id],
+						Showtimes:  count,
+						Required:   contract.MinShowtimesPerDay,
+					})
+				}
+			}
+		}
+	}
+	return settlement
+}
@@ -0,0 +1,178 @@
+package helper
+
+import (
+	"cinema_manager/database"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GiftCardMaxPinAttempts: nhập sai PIN quá số lần này thẻ bị khóa
+const GiftCardMaxPinAttempts = 5
+
+// GenerateGiftCardPin: PIN 6 chữ số sinh bằng crypto/rand
+func GenerateGiftCardPin() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// GenerateGiftCardCode: mã thẻ dạng GC-XXXX-XXXX-XXXX, không trùng
+func GenerateGiftCardCode(db *gorm.DB) string {
+	for {
+		raw := utils.RandomString(12)
+		code := fmt.Sprintf("GC-%s-%s-%s", raw[:4], raw[4:8], raw[8:])
+		var count int64
+		db.Model(&model.GiftCard{}).Where("code = ?", code).Count(&count)
+		if count == 0 {
+			return code
+		}
+	}
+}
+
+// IssueGiftCard tạo thẻ với số dư ban đầu và ghi sổ phát hành; trả về PIN (chỉ hiển thị một lần)
+func IssueGiftCard(tx *gorm.DB, card *model.GiftCard, createdBy *uint) (string, error) {
+	pin, err := GenerateGiftCardPin()
+	if err != nil {
+		return "", err
+	}
+	pinHash, err := HashPassword(pin)
+	if err != nil {
+		return "", err
+	}
+	card.Code = GenerateGiftCardCode(tx)
+	card.PinHash = pinHash
+	card.Balance = card.InitialValue
+	card.Status = model.GiftCardActive
+	if err := tx.Create(card).Error; err != nil {
+		return "", err
+	}
+	err = tx.Create(&model.StoredValueTransaction{
+		GiftCardId:   &card.ID,
+		Type:         model.StoredValueIssue,
+		Amount:       card.InitialValue,
+		BalanceAfter: card.Balance,
+		Note:         "Phát hành thẻ quà tặng",
+		CreatedBy:    createdBy,
+	}).Error
+	return pin, err
+}
+
+// LockGiftCard kiểm tra mã/PIN rồi khóa dòng thẻ trong tx, kiểm tra trạng thái và hạn dùng.
+// PIN được kiểm tra ngoài transaction (trước khi khóa) để số lần nhập sai không bị rollback.
+func LockGiftCard(tx *gorm.DB, code, pin string) (model.GiftCard, error) {
+	var card model.GiftCard
+	if err := database.DB.Select("id", "pin_hash", "failed_attempts", "status").Where("code = ?", code).First(&card).Error; err != nil {
+		return card, errors.New("mã thẻ hoặc PIN không đúng")
+	}
+	if card.Status != model.GiftCardActive {
+		return card, errors.New("thẻ quà tặng đang bị khóa")
+	}
+	if !CheckPasswordHash(pin, card.PinHash) {
+		attempts := card.FailedAttempts + 1
+		updates := map[string]interface{}{"failed_attempts": attempts}
+		if attempts >= GiftCardMaxPinAttempts {
+			updates["status"] = model.GiftCardBlocked
+		}
+		database.DB.Model(&model.GiftCard{}).Where("id = ?", card.ID).Updates(updates)
+		return card, errors.New("mã thẻ hoặc PIN không đúng")
+	}
+	if card.FailedAttempts > 0 {
+		database.DB.Model(&model.GiftCard{}).Where("id = ?", card.ID).Update("failed_attempts", 0)
+	}
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, card.ID).Error; err != nil {
+		return card, err
+	}
+	if card.Status != model.GiftCardActive {
+		return card, errors.New("thẻ quà tặng đang bị khóa")
+	}
+	if card.ExpiresAt != nil && time.Now().After(*card.ExpiresAt) {
+		return card, errors.New("thẻ quà tặng đã hết hạn")
+	}
+	return card, nil
+}
+
+// applyGiftCardEntry cộng/trừ số dư thẻ (thẻ đã được khóa) và ghi sổ
+func applyGiftCardEntry(tx *gorm.DB, card *model.GiftCard, entryType string, amount float64, orderId *uint, note string, createdBy *uint) error {
+	balance := math.Round((card.Balance+amount)*100) / 100
+	if balance < 0 {
+		return fmt.Errorf("số dư thẻ không đủ (còn %.0fđ)", card.Balance)
+	}
+	if err := tx.Model(&model.GiftCard{}).Where("id = ?", card.ID).Update("balance", balance).Error; err != nil {
+		return err
+	}
+	card.Balance = balance
+	return tx.Create(&model.StoredValueTransaction{
+		GiftCardId:   &card.ID,
+		OrderId:      orderId,
+		Type:         entryType,
+		Amount:       amount,
+		BalanceAfter: balance,
+		Note:         note,
+		CreatedBy:    createdBy,
+	}).Error
+}
+
+// applyWalletEntry cộng/trừ số dư ví của khách (khóa dòng khách) và ghi sổ
+func applyWalletEntry(tx *gorm.DB, customerId uint, entryType string, amount float64, orderId *uint, note string, createdBy *uint) error {
+	var customer model.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "wallet_balance").First(&customer, customerId).Error; err != nil {
+		return err
+	}
+	balance := math.Round((customer.WalletBalance+amount)*100) / 100
+	if balance < 0 {
+		return fmt.Errorf("số dư ví không đủ (còn %.0fđ)", customer.WalletBalance)
+	}
+	if err := tx.Model(&model.Customer{}).Where("id = ?", customerId).Update("wallet_balance", balance).Error; err != nil {
+		return err
+	}
+	return tx.Create(&model.StoredValueTransaction{
+		CustomerId:   &customerId,
+		OrderId:      orderId,
+		Type:         entryType,
+		Amount:       amount,
+		BalanceAfter: balance,
+		Note:         note,
+		CreatedBy:    createdBy,
+	}).Error
+}
+
+// PayOrderWithGiftCard trừ thẻ cho đơn hàng
+func PayOrderWithGiftCard(tx *gorm.DB, card *model.GiftCard, orderId uint, amount float64) error {
+	if amount <= 0 {
+		return nil
+	}
+	return applyGiftCardEntry(tx, card, model.StoredValueRedeem, -amount, &orderId, fmt.Sprintf("Thanh toán đơn #%d", orderId), nil)
+}
+
+// PayOrderWithWallet trừ ví của khách cho đơn hàng
+func PayOrderWithWallet(tx *gorm.DB, customerId, orderId uint, amount float64) error {
+	if amount <= 0 {
+		return nil
+	}
+	return applyWalletEntry(tx, customerId, model.StoredValueRedeem, -amount, &orderId, fmt.Sprintf("Thanh toán đơn #%d", orderId), nil)
+}
+
+// LoadGiftCardToWallet chuyển toàn bộ số dư thẻ vào ví của khách, trả về số tiền đã chuyển
+func LoadGiftCardToWallet(tx *gorm.DB, card *model.GiftCard, customerId uint) (float64, error) {
+	amount := card.Balance
+	if amount <= 0 {
+		return 0, errors.New("thẻ quà tặng đã hết số dư")
+	}
+	if err := applyGiftCardEntry(tx, card, model.StoredValueLoad, -amount, nil, fmt.Sprintf("Nạp vào ví khách #%d", customerId), nil); err != nil {
+		return 0, err
+	}
+	err := applyWalletEntry(tx, customerId, model.StoredValueLoad, amount, nil, "Nạp từ thẻ quà tặng "+card.Code, nil)
+	return amount, err
+}
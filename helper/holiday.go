@@ -0,0 +1,196 @@
+package helper
+
+import (
+	"cinema_manager/database"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UID ổn định để file xuất ra nhập lại được đúng ngày lễ cũ
+func HolidayUID(id uint) string {
+	return fmt.Sprintf("holiday-%d@cinema-manager", id)
+}
+
+// HolidayICalEvents chuyển bảng Holiday sang VEVENT. Ngày lễ dương lặp lại dùng RRULE hằng năm; ngày lễ âm lặp lại
+// không biểu diễn được bằng RRULE thông dụng nên xuất ngày dương tương ứng trong năm year kèm X-LUNAR-MONTH/DAY
+func HolidayICalEvents(holidays []model.Holiday, year int) []utils.ICalEvent {
+	events := []utils.ICalEvent{}
+	for _, h := range holidays {
+		event := utils.ICalEvent{
+			UID:          HolidayUID(h.ID),
+			Summary:      h.Name,
+			Start:        h.Date,
+			AllDay:       true,
+			Status:       "CONFIRMED",
+			LastModified: h.UpdatedAt,
+			Extra: map[string]string{
+				"X-HOLIDAY-TYPE":      h.Type,
+				"X-HOLIDAY-RECURRING": strconv.FormatBool(h.IsRecurring),
+			},
+		}
+		if h.Type == "lunar" {
+			event.Extra["X-LUNAR-MONTH"] = strconv.Itoa(h.LunarMonth)
+			event.Extra["X-LUNAR-DAY"] = strconv.Itoa(h.LunarDay)
+		}
+
+		switch {
+		case h.IsRecurring && h.Type == "solar":
+			event.RRule = "FREQ=YEARLY"
+			events = append(events, event)
+		case h.IsRecurring && h.Type == "lunar":
+			// Tháng Chạp của năm âm year-1 có thể rơi vào đầu năm dương year
+			for _, lunarYear := range []int{year - 1, year} {
+				date, ok := lunarOccurrence(h.LunarMonth, h.LunarDay, lunarYear)
+				if !ok || date.Year() != year {
+					continue
+				}
+				occurrence := event
+				occurrence.UID = fmt.Sprintf("holiday-%d-%d@cinema-manager", h.ID, lunarYear)
+				occurrence.Start = date
+				events = append(events, occurrence)
+			}
+		default:
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// lunarOccurrence: ngày dương của ngày âm month/day trong năm âm lunarYear; ngày 30 của tháng thiếu lấy ngày 29
+func lunarOccurrence(month, day, lunarYear int) (time.Time, bool) {
+	date, ok := utils.LunarToSolar(lunarYear, month, day, false, time.UTC)
+	if !ok && day == 30 {
+		date, ok = utils.LunarToSolar(lunarYear, month, 29, false, time.UTC)
+	}
+	return date, ok
+}
+
+// BuildHolidayImportPlan đối chiếu VEVENT với bảng Holiday: khớp theo UID đã xuất, rồi theo tên + loại;
+// sự kiện mới trùng ngày với ngày lễ đã có (khác tên) coi là trùng lặp để không phân loại ngày hai lần
+func BuildHolidayImportPlan(events []utils.ICalEvent) model.HolidayImportPlan {
+	plan := model.HolidayImportPlan{
+		Creates:    []model.Holiday{},
+		Updates:    []model.HolidayImportUpdate{},
+		Duplicates: []model.HolidayImportSkip{},
+		Errors:     []model.HolidayImportSkip{},
+	}
+	seenKeys := map[string]bool{}
+	seenIDs := map[uint]bool{}
+
+	for _, event := range events {
+		skip := model.HolidayImportSkip{UID: event.UID, Name: event.Summary}
+		if !event.Start.IsZero() {
+			skip.Date = event.Start.Format("2006-01-02")
+		}
+		holiday, err := holidayFromICalEvent(event)
+		if err != nil {
+			skip.Message = err.Error()
+			plan.Errors = append(plan.Errors, skip)
+			continue
+		}
+
+		key := strings.ToLower(holiday.Name) + "|" + holiday.Type
+		if seenKeys[key] {
+			skip.Message = "Trùng với sự kiện khác trong file"
+			plan.Duplicates = append(plan.Duplicates, skip)
+			continue
+		}
+		seenKeys[key] = true
+
+		existing, found := findImportedHoliday(event.UID, holiday)
+		if found {
+			if seenIDs[existing.ID] {
+				skip.Message = "Trùng với sự kiện khác trong file"
+				plan.Duplicates = append(plan.Duplicates, skip)
+				continue
+			}
+			seenIDs[existing.ID] = true
+			if sameHoliday(existing, holiday) {
+				skip.Message = "Ngày lễ đã tồn tại"
+				plan.Duplicates = append(plan.Duplicates, skip)
+				continue
+			}
+			after := existing
+			after.Name, after.Date, after.Type, after.IsRecurring = holiday.Name, holiday.Date, holiday.Type, holiday.IsRecurring
+			after.LunarMonth, after.LunarDay = holiday.LunarMonth, holiday.LunarDay
+			plan.Updates = append(plan.Updates, model.HolidayImportUpdate{Before: existing, After: after})
+			continue
+		}
+
+		if sameDay := FindHolidays(holiday.Date); len(sameDay) > 0 {
+			skip.Message = fmt.Sprintf("Trùng ngày với ngày lễ '%s'", sameDay[0].Name)
+			plan.Duplicates = append(plan.Duplicates, skip)
+			continue
+		}
+		plan.Creates = append(plan.Creates, holiday)
+	}
+	return plan
+}
+
+func holidayFromICalEvent(event utils.ICalEvent) (model.Holiday, error) {
+	name := strings.TrimSpace(event.Summary)
+	if len(name) < 2 || len(name) > 100 {
+		return model.Holiday{}, fmt.Errorf("SUMMARY phải từ 2 đến 100 ký tự")
+	}
+	if event.Start.IsZero() {
+		return model.Holiday{}, fmt.Errorf("DTSTART không hợp lệ")
+	}
+	rrule := utils.ICalRRuleParts(event.RRule)
+	if freq, ok := rrule["FREQ"]; ok && freq != "YEARLY" {
+		return model.Holiday{}, fmt.Errorf("chỉ hỗ trợ RRULE lặp hằng năm (FREQ=YEARLY)")
+	}
+	if interval, ok := rrule["INTERVAL"]; ok && interval != "1" {
+		return model.Holiday{}, fmt.Errorf("chỉ hỗ trợ RRULE lặp mỗi năm (INTERVAL=1)")
+	}
+
+	holiday := model.Holiday{
+		Name:        name,
+		Date:        time.Date(event.Start.Year(), event.Start.Month(), event.Start.Day(), 0, 0, 0, 0, time.UTC),
+		Type:        "solar",
+		IsRecurring: rrule["FREQ"] == "YEARLY" || event.Extra["X-HOLIDAY-RECURRING"] == "true",
+	}
+	// Âm lịch: file xuất từ hệ thống (X-HOLIDAY-TYPE / X-LUNAR-*) hoặc RRULE theo lịch âm (RSCALE=CHINESE, RFC 7529)
+	if strings.EqualFold(event.Extra["X-HOLIDAY-TYPE"], "lunar") || rrule["RSCALE"] == "CHINESE" || event.Extra["X-LUNAR-MONTH"] != "" {
+		holiday.Type = "lunar"
+		month, _ := strconv.Atoi(event.Extra["X-LUNAR-MONTH"])
+		day, _ := strconv.Atoi(event.Extra["X-LUNAR-DAY"])
+		if month >= 1 && month <= 12 && day >= 1 && day <= 30 {
+			holiday.LunarMonth, holiday.LunarDay = month, day
+		}
+	}
+	holiday.FillLunarDate()
+	return holiday, nil
+}
+
+func findImportedHoliday(uid string, holiday model.Holiday) (model.Holiday, bool) {
+	var existing model.Holiday
+	var id uint
+	if _, err := fmt.Sscanf(uid, "holiday-%d", &id); err == nil && id > 0 {
+		if database.DB.First(&existing, id).Error == nil {
+			return existing, true
+		}
+	}
+	if database.DB.Where("LOWER(name) = LOWER(?) AND type = ?", holiday.Name, holiday.Type).First(&existing).Error == nil {
+		return existing, true
+	}
+	return existing, false
+}
+
+// sameHoliday: lặp lại dương lịch chỉ so ngày/tháng, lặp lại âm lịch so ngày/tháng âm, còn lại so đúng ngày
+func sameHoliday(a, b model.Holiday) bool {
+	if a.Name != b.Name || a.Type != b.Type || a.IsRecurring != b.IsRecurring {
+		return false
+	}
+	switch {
+	case a.IsRecurring && a.Type == "lunar":
+		return a.LunarMonth == b.LunarMonth && a.LunarDay == b.LunarDay
+	case a.IsRecurring:
+		return a.Date.Month() == b.Date.Month() && a.Date.Day() == b.Date.Day()
+	default:
+		return a.Date.Format("2006-01-02") == b.Date.Format("2006-01-02")
+	}
+}
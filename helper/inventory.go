@@ -0,0 +1,249 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"fmt"
+	"log"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IsLowStock: nguyên liệu đang bật và tồn không vượt ngưỡng cảnh báo
+func IsLowStock(item model.InventoryItem) bool {
+	return item.IsActive && item.Quantity <= item.LowStockThreshold
+}
+
+// ApplyStockMovement cộng/trừ tồn kho của nguyên liệu (đã khóa dòng trong tx) và ghi sổ kho.
+// quantity mang dấu: dương là nhập, âm là xuất.
+func ApplyStockMovement(tx *gorm.DB, item *model.InventoryItem, movementType string, quantity float64, orderId *uint, note string, createdBy uint) (model.StockMovement, error) {
+	wasLow := IsLowStock(*item)
+	item.Quantity += quantity
+	if err := tx.Model(item).Update("quantity", item.Quantity).Error; err != nil {
+		return model.StockMovement{}, err
+	}
+	movement := model.StockMovement{
+		CinemaId:        item.CinemaId,
+		InventoryItemId: item.ID,
+		Type:            movementType,
+		Quantity:        quantity,
+		BalanceAfter:    item.Quantity,
+		OrderId:         orderId,
+		Note:            note,
+		CreatedBy:       createdBy,
+	}
+	if err := tx.Create(&movement).Error; err != nil {
+		return model.StockMovement{}, err
+	}
+	if !wasLow && IsLowStock(*item) {
+		log.Printf("Cảnh báo tồn kho thấp: rạp %d - %s còn %.2f %s (ngưỡng %.2f)",
+			item.CinemaId, item.Name, item.Quantity, item.Unit, item.LowStockThreshold)
+	}
+	return movement, nil
+}
+
+// OrderStockUsage tính lượng nguyên liệu các dòng bắp nước còn hiệu lực của đơn tiêu hao,
+// combo được tách theo thành phần
+func OrderStockUsage(db *gorm.DB, orderId uint) (map[uint]float64, error) {
+	var lines []model.OrderConcession
+	if err := db.Where("order_id = ? AND status = ?", orderId, "ACTIVE").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	// Số đơn vị cần xuất của từng sản phẩm (kể cả thành phần combo)
+	units := make(map[uint]float64)
+	comboIds := make([]uint, 0)
+	for _, line := range lines {
+		units[line.ProductId] += float64(line.Quantity)
+		if line.Category == model.ConcessionCombo {
+			comboIds = append(comboIds, line.ProductId)
+		}
+	}
+	if len(comboIds) > 0 {
+		var comboItems []model.ConcessionComboItem
+		if err := db.Where("combo_id IN ?", comboIds).Find(&comboItems).Error; err != nil {
+			return nil, err
+		}
+		comboQty := make(map[uint]float64)
+		for _, line := range lines {
+			if line.Category == model.ConcessionCombo {
+				comboQty[line.ProductId] += float64(line.Quantity)
+			}
+		}
+		for _, item := range comboItems {
+			units[item.ProductId] += comboQty[item.ComboId] * float64(item.Quantity)
+		}
+	}
+
+	productIds := make([]uint, 0, len(units))
+	for id := range units {
+		productIds = append(productIds, id)
+	}
+	var recipes []model.ConcessionRecipe
+	if err := db.Where("product_id IN ?", productIds).Find(&recipes).Error; err != nil {
+		return nil, err
+	}
+	usage := make(map[uint]float64)
+	for _, r := range recipes {
+		usage[r.InventoryItemId] += r.Quantity * units[r.ProductId]
+	}
+	return usage, nil
+}
+
+// lockInventoryItems khóa các nguyên liệu theo thứ tự id để tránh deadlock giữa các đơn đồng thời
+func lockInventoryItems(tx *gorm.DB, ids []uint) ([]model.InventoryItem, error) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	var items []model.InventoryItem
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Order("id").Find(&items).Error
+	return items, err
+}
+
+// DeductOrderStock trừ kho cho đơn đã thanh toán. Gọi lại nhiều lần cho cùng đơn chỉ trừ một lần.
+// allowNegative = false: từ chối khi không đủ tồn (bán tại quầy / checkout);
+// true: vẫn trừ và để tồn âm (xác nhận thanh toán từ cổng thanh toán, tiền đã thu).
+func DeductOrderStock(tx *gorm.DB, orderId uint, createdBy uint, allowNegative bool) error {
+	var count int64
+	tx.Model(&model.StockMovement{}).Where("order_id = ? AND type = ?", orderId, model.StockSale).Count(&count)
+	if count > 0 {
+		return nil
+	}
+	usage, err := OrderStockUsage(tx, orderId)
+	if err != nil || len(usage) == 0 {
+		return err
+	}
+	ids := make([]uint, 0, len(usage))
+	for id := range usage {
+		ids = append(ids, id)
+	}
+	items, err := lockInventoryItems(tx, ids)
+	if err != nil {
+		return err
+	}
+	if !allowNegative {
+		for _, item := range items {
+			if item.Quantity < usage[item.ID] {
+				return fmt.Errorf("không đủ \"%s\" trong kho (còn %.2f %s)", item.Name, item.Quantity, item.Unit)
+			}
+		}
+	}
+	for i := range items {
+		note := fmt.Sprintf("Bán theo đơn #%d", orderId)
+		if _, err := ApplyStockMovement(tx, &items[i], model.StockSale, -usage[items[i].ID], &orderId, note, createdBy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreOrderStock hoàn lại kho phần đã trừ cho đơn bị hủy (không hoàn trùng nếu gọi lại)
+func RestoreOrderStock(tx *gorm.DB, orderId uint, createdBy uint) error {
+	type netRow struct {
+		InventoryItemId uint
+		Net             float64
+	}
+	var rows []netRow
+	if err := tx.Model(&model.StockMovement{}).
+		Select("inventory_item_id, SUM(quantity) AS net").
+		Where("order_id = ? AND type IN ?", orderId, []string{model.StockSale, model.StockReturn}).
+		Group("inventory_item_id").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+	restore := make(map[uint]float64)
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		if row.Net < 0 {
+			restore[row.InventoryItemId] = -row.Net
+			ids = append(ids, row.InventoryItemId)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	items, err := lockInventoryItems(tx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		note := fmt.Sprintf("Hoàn kho do hủy đơn #%d", orderId)
+		if _, err := ApplyStockMovement(tx, &items[i], model.StockReturn, restore[items[i].ID], &orderId, note, createdBy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildStockReport tổng hợp xuất nhập tồn theo nguyên liệu trong kỳ [from, to)
+func BuildStockReport(db *gorm.DB, cinemaId uint, from, to string) ([]model.StockReportItem, error) {
+	var items []model.InventoryItem
+	if err := db.Where("cinema_id = ?", cinemaId).Order("name ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	type sumRow struct {
+		InventoryItemId uint
+		Type            string
+		Total           float64
+	}
+	var inPeriod []sumRow
+	if err := db.Model(&model.StockMovement{}).
+		Select("inventory_item_id, type, SUM(quantity) AS total").
+		Where("cinema_id = ? AND created_at >= ? AND created_at < ?", cinemaId, from, to).
+		Group("inventory_item_id, type").
+		Scan(&inPeriod).Error; err != nil {
+		return nil, err
+	}
+	var afterPeriod []sumRow
+	if err := db.Model(&model.StockMovement{}).
+		Select("inventory_item_id, SUM(quantity) AS total").
+		Where("cinema_id = ? AND created_at >= ?", cinemaId, to).
+		Group("inventory_item_id").
+		Scan(&afterPeriod).Error; err != nil {
+		return nil, err
+	}
+	after := make(map[uint]float64)
+	for _, row := range afterPeriod {
+		after[row.InventoryItemId] = row.Total
+	}
+
+	report := make([]model.StockReportItem, 0, len(items))
+	index := make(map[uint]int, len(items))
+	for _, item := range items {
+		index[item.ID] = len(report)
+		report = append(report, model.StockReportItem{
+			InventoryItemId: item.ID,
+			Code:            item.Code,
+			Name:            item.Name,
+			Unit:            item.Unit,
+			Closing:         item.Quantity - after[item.ID],
+			Current:         item.Quantity,
+			IsLowStock:      IsLowStock(item),
+		})
+	}
+	for _, row := range inPeriod {
+		i, ok := index[row.InventoryItemId]
+		if !ok {
+			continue
+		}
+		switch row.Type {
+		case model.StockReceive:
+			report[i].Received += row.Total
+		case model.StockSale:
+			report[i].Sold += -row.Total
+		case model.StockReturn:
+			report[i].Returned += row.Total
+		case model.StockWaste:
+			report[i].Wasted += -row.Total
+		case model.StockAdjustment:
+			report[i].Adjusted += row.Total
+		}
+	}
+	for i := range report {
+		r := &report[i]
+		r.Opening = r.Closing - r.Received + r.Sold - r.Returned + r.Wasted - r.Adjusted
+	}
+	return report, nil
+}
@@ -0,0 +1,353 @@
+package helper
+
+import (
+	"cinema_manager/database"
+	"cinema_manager/model"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var loyaltyScheduler gocron.Scheduler
+
+// DefaultLoyaltySetting: 10.000đ = 1 điểm, 1 điểm = 1.000đ, hết hạn sau 12 tháng, giảm tối đa 50% đơn
+func DefaultLoyaltySetting() model.LoyaltySetting {
+	return model.LoyaltySetting{
+		AmountPerPoint:   10000,
+		PointValue:       1000,
+		ExpiryMonths:     12,
+		MaxRedeemPercent: 50,
+		MinRedeemPoints:  10,
+		IsActive:         true,
+	}
+}
+
+// GetLoyaltySetting lấy cấu hình đã lưu, chưa có thì dùng mặc định
+func GetLoyaltySetting(db *gorm.DB) model.LoyaltySetting {
+	var setting model.LoyaltySetting
+	if err := db.Order("id").First(&setting).Error; err != nil {
+		return DefaultLoyaltySetting()
+	}
+	return setting
+}
+
+// LoyaltyRedeemDiscount tính số tiền giảm khi dùng points điểm cho đơn có giá trị subtotal
+func LoyaltyRedeemDiscount(setting model.LoyaltySetting, points int, subtotal float64) (float64, error) {
+	if points <= 0 {
+		return 0, nil
+	}
+	if !setting.IsActive {
+		return 0, fmt.Errorf("chương trình điểm thành viên đang tạm dừng")
+	}
+	if points < setting.MinRedeemPoints {
+		return 0, fmt.Errorf("cần dùng tối thiểu %d điểm", setting.MinRedeemPoints)
+	}
+	discount := float64(points) * setting.PointValue
+	maxDiscount := subtotal * setting.MaxRedeemPercent / 100
+	if discount > maxDiscount {
+		return 0, fmt.Errorf("chỉ được dùng điểm giảm tối đa %.0f%% giá trị đơn (%.0fđ)", setting.MaxRedeemPercent, maxDiscount)
+	}
+	return discount, nil
+}
+
+// lockCustomer khóa dòng khách hàng để cập nhật số dư điểm
+func lockCustomer(tx *gorm.DB, customerId uint) (model.Customer, error) {
+	var customer model.Customer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "loyalty_points").First(&customer, customerId).Error
+	return customer, err
+}
+
+// addLoyaltyEntry ghi một dòng sổ điểm và cập nhật số dư khách (khách đã được khóa)
+func addLoyaltyEntry(tx *gorm.DB, customer *model.Customer, entry model.LoyaltyTransaction) (model.LoyaltyTransaction, error) {
+	customer.LoyaltyPoints += entry.Points
+	if err := tx.Model(&model.Customer{}).Where("id = ?", customer.ID).Update("loyalty_points", customer.LoyaltyPoints).Error; err != nil {
+		return entry, err
+	}
+	entry.CustomerId = customer.ID
+	entry.BalanceAfter = customer.LoyaltyPoints
+	err := tx.Create(&entry).Error
+	return entry, err
+}
+
+// consumeLoyaltyLots trừ points khỏi các lô điểm còn hạn, lô sắp hết hạn dùng trước.
+// preferLotId (nếu có) được trừ trước tiên. Trả về số điểm thực sự trừ được.
+func consumeLoyaltyLots(tx *gorm.DB, customerId uint, points int, preferLotId uint) (int, error) {
+	var lots []model.LoyaltyTransaction
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("customer_id = ? AND remaining > 0", customerId).
+		Order("expires_at ASC, id ASC").
+		Find(&lots).Error; err != nil {
+		return 0, err
+	}
+	if preferLotId != 0 {
+		for i := range lots {
+			if lots[i].ID == preferLotId && i > 0 {
+				lot := lots[i]
+				copy(lots[1:i+1], lots[:i])
+				lots[0] = lot
+				break
+			}
+		}
+	}
+	consumed := 0
+	for _, lot := range lots {
+		if consumed >= points {
+			break
+		}
+		take := lot.Remaining
+		if take > points-consumed {
+			take = points - consumed
+		}
+		if err := tx.Model(&model.LoyaltyTransaction{}).Where("id = ?", lot.ID).
+			Update("remaining", lot.Remaining-take).Error; err != nil {
+			return consumed, err
+		}
+		consumed += take
+	}
+	return consumed, nil
+}
+
+// RedeemOrderPoints trừ điểm khách dùng cho đơn hàng
+func RedeemOrderPoints(tx *gorm.DB, customerId, orderId uint, points int) error {
+	if points <= 0 {
+		return nil
+	}
+	customer, err := lockCustomer(tx, customerId)
+	if err != nil {
+		return err
+	}
+	if customer.LoyaltyPoints < points {
+		return fmt.Errorf("không đủ điểm (hiện có %d điểm)", customer.LoyaltyPoints)
+	}
+	if _, err := consumeLoyaltyLots(tx, customerId, points, 0); err != nil {
+		return err
+	}
+	_, err = addLoyaltyEntry(tx, &customer, model.LoyaltyTransaction{
+		OrderId: &orderId,
+		Type:    model.LoyaltyRedeem,
+		Points:  -points,
+		Note:    fmt.Sprintf("Dùng điểm cho đơn #%d", orderId),
+	})
+	return err
+}
+
+// EarnOrderPoints tích điểm cho đơn PAID của khách đăng nhập, trả về số điểm vừa tích;
+// gọi lại nhiều lần chỉ tích một lần
+func EarnOrderPoints(tx *gorm.DB, orderId uint) (int, error) {
+	var order model.Order
+	if err := tx.Select("id", "customer_id", "status", "total_amount", "public_code").First(&order, orderId).Error; err != nil {
+		return 0, err
+	}
+	if order.CustomerID == nil || order.Status != "PAID" {
+		return 0, nil
+	}
+	setting := GetLoyaltySetting(tx)
+	if !setting.IsActive || setting.AmountPerPoint <= 0 {
+		return 0, nil
+	}
+	var count int64
+	tx.Model(&model.LoyaltyTransaction{}).Where("order_id = ? AND type = ?", orderId, model.LoyaltyEarn).Count(&count)
+	if count > 0 {
+		return 0, nil
+	}
+	points := int(math.Floor(order.TotalAmount / setting.AmountPerPoint))
+	if points <= 0 {
+		return 0, nil
+	}
+	customer, err := lockCustomer(tx, *order.CustomerID)
+	if err != nil {
+		return 0, err
+	}
+	expiresAt := time.Now().AddDate(0, setting.ExpiryMonths, 0)
+	if _, err := addLoyaltyEntry(tx, &customer, model.LoyaltyTransaction{
+		OrderId:   &orderId,
+		Type:      model.LoyaltyEarn,
+		Points:    points,
+		Remaining: points,
+		ExpiresAt: &expiresAt,
+		Note:      fmt.Sprintf("Tích điểm đơn %s", order.PublicCode),
+	}); err != nil {
+		return 0, err
+	}
+	return points, tx.Model(&model.Order{}).Where("id = ?", orderId).Update("points_earned", points).Error
+}
+
+// ReverseOrderPoints khi hủy đơn: thu hồi điểm đã tích (không làm số dư âm) và trả lại điểm đã dùng.
+// Gọi lại nhiều lần không thu hồi/trả trùng.
+func ReverseOrderPoints(tx *gorm.DB, orderId uint) error {
+	var entries []model.LoyaltyTransaction
+	if err := tx.Where("order_id = ?", orderId).Order("id").Find(&entries).Error; err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	var earn, redeem *model.LoyaltyTransaction
+	reversed, refunded := false, false
+	for i := range entries {
+		switch entries[i].Type {
+		case model.LoyaltyEarn:
+			earn = &entries[i]
+		case model.LoyaltyRedeem:
+			redeem = &entries[i]
+		case model.LoyaltyReverse:
+			reversed = true
+		case model.LoyaltyRefund:
+			refunded = true
+		}
+	}
+	customerId := entries[0].CustomerId
+	customer, err := lockCustomer(tx, customerId)
+	if err != nil {
+		return err
+	}
+
+	if earn != nil && !reversed {
+		points := earn.Points
+		if points > customer.LoyaltyPoints {
+			points = customer.LoyaltyPoints
+		}
+		if points > 0 {
+			consumed, err := consumeLoyaltyLots(tx, customerId, points, earn.ID)
+			if err != nil {
+				return err
+			}
+			if _, err := addLoyaltyEntry(tx, &customer, model.LoyaltyTransaction{
+				OrderId: &orderId,
+				Type:    model.LoyaltyReverse,
+				Points:  -consumed,
+				Note:    fmt.Sprintf("Thu hồi điểm do hủy đơn #%d", orderId),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if redeem != nil && !refunded {
+		setting := GetLoyaltySetting(tx)
+		expiresAt := time.Now().AddDate(0, setting.ExpiryMonths, 0)
+		if _, err := addLoyaltyEntry(tx, &customer, model.LoyaltyTransaction{
+			OrderId:   &orderId,
+			Type:      model.LoyaltyRefund,
+			Points:    -redeem.Points,
+			Remaining: -redeem.Points,
+			ExpiresAt: &expiresAt,
+			Note:      fmt.Sprintf("Trả lại điểm do hủy đơn #%d", orderId),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLoyaltySummary: số dư, điểm sắp hết hạn trong 30 ngày và lịch sử gần nhất của khách
+func GetLoyaltySummary(db *gorm.DB, customer model.Customer) model.LoyaltySummary {
+	setting := GetLoyaltySetting(db)
+	summary := model.LoyaltySummary{
+		Balance:          customer.LoyaltyPoints,
+		BalanceValue:     float64(customer.LoyaltyPoints) * setting.PointValue,
+		PointValue:       setting.PointValue,
+		AmountPerPoint:   setting.AmountPerPoint,
+		MaxRedeemPercent: setting.MaxRedeemPercent,
+	}
+
+	var expiring struct {
+		Points     int
+		ExpiringAt *time.Time
+	}
+	db.Model(&model.LoyaltyTransaction{}).
+		Select("COALESCE(SUM(remaining), 0) AS points, MIN(expires_at) AS expiring_at").
+		Where("customer_id = ? AND remaining > 0 AND expires_at <= ?", customer.ID, time.Now().AddDate(0, 0, 30)).
+		Scan(&expiring)
+	summary.ExpiringPoints = expiring.Points
+	summary.ExpiringAt = expiring.ExpiringAt
+
+	db.Where("customer_id = ?", customer.ID).Order("id DESC").Limit(20).Find(&summary.RecentHistory)
+	return summary
+}
+
+// ExpireLoyaltyPoints hủy phần điểm còn lại của các lô đã quá hạn, mỗi khách một dòng sổ EXPIRE
+func ExpireLoyaltyPoints() {
+	log.Println("[CRON] ExpireLoyaltyPoints triggered")
+	db := database.DB
+	now := time.Now()
+
+	var customerIds []uint
+	if err := db.Model(&model.LoyaltyTransaction{}).
+		Where("remaining > 0 AND expires_at < ?", now).
+		Distinct().Pluck("customer_id", &customerIds).Error; err != nil {
+		log.Printf("Lỗi quét điểm hết hạn: %v", err)
+		return
+	}
+
+	for _, customerId := range customerIds {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			customer, err := lockCustomer(tx, customerId)
+			if err != nil {
+				return err
+			}
+			var lots []model.LoyaltyTransaction
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("customer_id = ? AND remaining > 0 AND expires_at < ?", customerId, now).
+				Find(&lots).Error; err != nil {
+				return err
+			}
+			expired := 0
+			for _, lot := range lots {
+				expired += lot.Remaining
+			}
+			if expired > customer.LoyaltyPoints {
+				expired = customer.LoyaltyPoints
+			}
+			if err := tx.Model(&model.LoyaltyTransaction{}).
+				Where("customer_id = ? AND remaining > 0 AND expires_at < ?", customerId, now).
+				Update("remaining", 0).Error; err != nil {
+				return err
+			}
+			if expired <= 0 {
+				return nil
+			}
+			_, err = addLoyaltyEntry(tx, &customer, model.LoyaltyTransaction{
+				Type:   model.LoyaltyExpire,
+				Points: -expired,
+				Note:   "Điểm hết hạn",
+			})
+			return err
+		})
+		if err != nil {
+			log.Printf("Lỗi hết hạn điểm của khách %d: %v", customerId, err)
+		}
+	}
+}
+
+func StartLoyaltyScheduler() {
+	s, err := gocron.NewScheduler(
+		gocron.WithLocation(time.FixedZone("ICT", 7*3600)),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	loyaltyScheduler = s
+
+	_, err = s.NewJob(
+		gocron.DailyJob(
+			1,
+			gocron.NewAtTimes(
+				gocron.NewAtTime(0, 15, 0),
+			),
+		),
+		gocron.NewTask(ExpireLoyaltyPoints),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s.Start()
+	log.Println("✅ Loyalty expiry scheduler started (00:15 ICT)")
+}
@@ -0,0 +1,316 @@
+package helper
+
+import (
+	"cinema_manager/database"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var membershipScheduler gocron.Scheduler
+
+// BirthdayVoucherDays: voucher sinh nhật dùng được trong 30 ngày kể từ ngày phát
+const BirthdayVoucherDays = 30
+
+// RollingSpend: tổng chi tiêu đơn PAID của khách trong 12 tháng tính đến now
+func RollingSpend(db *gorm.DB, customerId uint, now time.Time) float64 {
+	var spend float64
+	db.Model(&model.Order{}).
+		Select("COALESCE(SUM(total_amount), 0)").
+		Where("customer_id = ? AND status = ? AND paid_at >= ? AND paid_at <= ?", customerId, "PAID", now.AddDate(-1, 0, 0), now).
+		Scan(&spend)
+	return spend
+}
+
+// ActiveTiers: các hạng đang áp dụng, thấp đến cao
+func ActiveTiers(db *gorm.DB) []model.MembershipTier {
+	var tiers []model.MembershipTier
+	db.Where("is_active = ?", true).Order("rank ASC").Find(&tiers)
+	return tiers
+}
+
+// TierForSpend: hạng cao nhất khách đạt được với mức chi tiêu spend (nil nếu chưa đạt hạng nào)
+func TierForSpend(tiers []model.MembershipTier, spend float64) *model.MembershipTier {
+	var result *model.MembershipTier
+	for i := range tiers {
+		if spend >= tiers[i].MinSpend {
+			result = &tiers[i]
+		}
+	}
+	return result
+}
+
+// GetCustomerTier: hạng hiện tại của khách (nil nếu chưa có hoặc hạng đã ngừng áp dụng)
+func GetCustomerTier(db *gorm.DB, customer *model.Customer) *model.MembershipTier {
+	if customer == nil || customer.TierId == nil {
+		return nil
+	}
+	var tier model.MembershipTier
+	if err := db.Where("id = ? AND is_active = ?", *customer.TierId, true).First(&tier).Error; err != nil {
+		return nil
+	}
+	return &tier
+}
+
+func sameTier(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// SetCustomerTier đổi hạng của khách và ghi lịch sử
+func SetCustomerTier(tx *gorm.DB, customer *model.Customer, tierId *uint, locked bool, source, note string, changedBy *uint, spend float64) error {
+	now := time.Now()
+	fromTierId := customer.TierId
+	if err := tx.Model(&model.Customer{}).Where("id = ?", customer.ID).Updates(map[string]interface{}{
+		"tier_id":           tierId,
+		"tier_locked":       locked,
+		"tier_evaluated_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	customer.TierId = tierId
+	customer.TierLocked = locked
+	customer.TierEvaluatedAt = &now
+	return tx.Create(&model.TierChangeLog{
+		CustomerId:   customer.ID,
+		FromTierId:   fromTierId,
+		ToTierId:     tierId,
+		Source:       source,
+		RollingSpend: spend,
+		Note:         note,
+		ChangedBy:    changedBy,
+	}).Error
+}
+
+// EvaluateCustomerTier xét lại hạng theo chi tiêu 12 tháng; bỏ qua khách đang bị admin khóa hạng
+func EvaluateCustomerTier(db *gorm.DB, customer *model.Customer, tiers []model.MembershipTier, now time.Time) error {
+	if customer.TierLocked {
+		return nil
+	}
+	spend := RollingSpend(db, customer.ID, now)
+	var tierId *uint
+	if tier := TierForSpend(tiers, spend); tier != nil {
+		tierId = &tier.ID
+	}
+	if sameTier(customer.TierId, tierId) {
+		return db.Model(&model.Customer{}).Where("id = ?", customer.ID).Update("tier_evaluated_at", now).Error
+	}
+	return SetCustomerTier(db, customer, tierId, false, model.TierChangeAuto, "Xét hạng theo chi tiêu 12 tháng", nil, spend)
+}
+
+// CheckPresaleAccess: trước giờ mở bán chỉ khách có hạng được mua trước mới giữ được ghế
+func CheckPresaleAccess(db *gorm.DB, customer *model.Customer, showtime model.Showtime, now time.Time) error {
+	if showtime.SaleOpensAt == nil || !now.Before(*showtime.SaleOpensAt) {
+		return nil
+	}
+	if tier := GetCustomerTier(db, customer); tier != nil && tier.PresaleHours > 0 {
+		if !now.Before(showtime.SaleOpensAt.Add(-time.Duration(tier.PresaleHours) * time.Hour)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("suất chiếu mở bán lúc %s", showtime.SaleOpensAt.In(time.FixedZone("ICT", 7*3600)).Format("15:04 02/01/2006"))
+}
+
+// CalculateMemberDiscount: quyền lợi hạng trên tiền vé của đơn.
+// FreeVipUpgrades ghế VIP đầu tiên tính theo giá ghế thường (trả về danh sách ghế được nâng hạng),
+// sau đó giảm DiscountPercent trên tiền vé còn lại.
+func CalculateMemberDiscount(db *gorm.DB, tier *model.MembershipTier, seats []model.ShowtimeSeat, ticketSubtotal float64) (float64, map[uint]bool) {
+	upgraded := make(map[uint]bool)
+	if tier == nil {
+		return 0, upgraded
+	}
+	upgradeSaving := 0.0
+	if tier.FreeVipUpgrades > 0 {
+		normalModifier := 1.0
+		var normal model.SeatType
+		if err := db.Where("type = ?", "NORMAL").First(&normal).Error; err == nil {
+			normalModifier = normal.PriceModifier
+		}
+		for _, s := range seats {
+			if len(upgraded) >= tier.FreeVipUpgrades {
+				break
+			}
+			if s.Seat.SeatType.Type != "VIP" || s.Seat.SeatType.PriceModifier <= normalModifier {
+				continue
+			}
+			upgraded[s.ID] = true
+			upgradeSaving += s.Showtime.Price * (s.Seat.SeatType.PriceModifier - normalModifier)
+		}
+	}
+	percentDiscount := (ticketSubtotal - upgradeSaving) * tier.DiscountPercent / 100
+	return math.Round(upgradeSaving + percentDiscount), upgraded
+}
+
+// LockMemberVoucher kiểm tra voucher của khách còn dùng được; trả về số tiền giảm trên subtotal
+func LockMemberVoucher(tx *gorm.DB, customerId uint, code string, subtotal float64) (model.MemberVoucher, float64, error) {
+	var voucher model.MemberVoucher
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ? AND customer_id = ?", code, customerId).
+		First(&voucher).Error; err != nil {
+		return voucher, 0, errors.New("voucher không tồn tại")
+	}
+	if voucher.UsedAt != nil {
+		return voucher, 0, errors.New("voucher đã được sử dụng")
+	}
+	if time.Now().After(voucher.ExpiresAt) {
+		return voucher, 0, errors.New("voucher đã hết hạn")
+	}
+	return voucher, math.Min(voucher.Amount, subtotal), nil
+}
+
+// UseMemberVoucher đánh dấu voucher đã dùng cho đơn
+func UseMemberVoucher(tx *gorm.DB, voucherId, orderId uint) error {
+	now := time.Now()
+	return tx.Model(&model.MemberVoucher{}).Where("id = ?", voucherId).
+		Updates(map[string]interface{}{"used_at": now, "order_id": orderId}).Error
+}
+
+// RestoreOrderVoucher trả lại voucher khi đơn bị hủy (voucher hết hạn thì vẫn hết hạn)
+func RestoreOrderVoucher(tx *gorm.DB, orderId uint) error {
+	return tx.Model(&model.MemberVoucher{}).Where("order_id = ?", orderId).
+		Updates(map[string]interface{}{"used_at": nil, "order_id": nil}).Error
+}
+
+// IssueBirthdayVouchers phát voucher sinh nhật cho khách có sinh nhật hôm nay (29/2 phát vào 28/2 năm thường)
+func IssueBirthdayVouchers(db *gorm.DB, now time.Time) {
+	month, day := int(now.Month()), now.Day()
+	isLeap := now.Year()%4 == 0 && (now.Year()%100 != 0 || now.Year()%400 == 0)
+	query := db.Preload("Tier").
+		Where("tier_id IS NOT NULL AND is_active = ? AND date_of_birth IS NOT NULL", true)
+	if month == 2 && day == 28 && !isLeap {
+		query = query.Where("EXTRACT(MONTH FROM date_of_birth) = 2 AND EXTRACT(DAY FROM date_of_birth) IN (28, 29)")
+	} else {
+		query = query.Where("EXTRACT(MONTH FROM date_of_birth) = ? AND EXTRACT(DAY FROM date_of_birth) = ?", month, day)
+	}
+	var customers []model.Customer
+	query.Find(&customers)
+
+	for _, customer := range customers {
+		if customer.Tier == nil || !customer.Tier.IsActive || customer.Tier.BirthdayVoucherAmount <= 0 {
+			continue
+		}
+		var count int64
+		db.Model(&model.MemberVoucher{}).
+			Where("customer_id = ? AND type = ? AND year = ?", customer.ID, model.VoucherBirthday, now.Year()).
+			Count(&count)
+		if count > 0 {
+			continue
+		}
+		voucher := model.MemberVoucher{
+			CustomerId: customer.ID,
+			Code:       "BD-" + utils.RandomString(8),
+			Type:       model.VoucherBirthday,
+			Year:       now.Year(),
+			Amount:     customer.Tier.BirthdayVoucherAmount,
+			ExpiresAt:  now.AddDate(0, 0, BirthdayVoucherDays),
+		}
+		if err := db.Create(&voucher).Error; err != nil {
+			log.Printf("Lỗi phát voucher sinh nhật cho khách %d: %v", customer.ID, err)
+		}
+	}
+}
+
+// EvaluateMembershipTiers: job hằng đêm xét lại hạng của khách có chi tiêu trong 12 tháng
+// hoặc đang có hạng, sau đó phát voucher sinh nhật
+func EvaluateMembershipTiers() {
+	log.Println("[CRON] EvaluateMembershipTiers triggered")
+	db := database.DB
+	now := time.Now()
+	tiers := ActiveTiers(db)
+
+	var customers []model.Customer
+	db.Select("id", "tier_id", "tier_locked").
+		Where("tier_locked = ?", false).
+		Where("tier_id IS NOT NULL OR id IN (?)",
+			db.Model(&model.Order{}).Select("customer_id").
+				Where("customer_id IS NOT NULL AND status = ? AND paid_at >= ?", "PAID", now.AddDate(-1, 0, 0))).
+		Find(&customers)
+
+	changed := 0
+	for i := range customers {
+		before := customers[i].TierId
+		if err := EvaluateCustomerTier(db, &customers[i], tiers, now); err != nil {
+			log.Printf("Lỗi xét hạng khách %d: %v", customers[i].ID, err)
+			continue
+		}
+		if !sameTier(before, customers[i].TierId) {
+			changed++
+		}
+	}
+	log.Printf("Đã xét hạng %d khách, %d khách thay đổi hạng", len(customers), changed)
+
+	IssueBirthdayVouchers(db, now.In(time.FixedZone("ICT", 7*3600)))
+}
+
+// BuildTierProgress: hạng hiện tại, chi tiêu 12 tháng và khoảng cách lên hạng kế tiếp
+func BuildTierProgress(db *gorm.DB, customer *model.Customer) model.TierProgress {
+	now := time.Now()
+	tiers := ActiveTiers(db)
+	progress := model.TierProgress{
+		Tier:          GetCustomerTier(db, customer),
+		RollingSpend:  RollingSpend(db, customer.ID, now),
+		Locked:        customer.TierLocked,
+		EvaluatedAt:   customer.TierEvaluatedAt,
+		WindowStartAt: now.AddDate(-1, 0, 0),
+	}
+	currentRank := -1
+	if progress.Tier != nil {
+		currentRank = progress.Tier.Rank
+	}
+	for i := range tiers {
+		if tiers[i].Rank > currentRank {
+			progress.NextTier = &tiers[i]
+			break
+		}
+	}
+	if progress.NextTier != nil {
+		progress.AmountToNext = math.Max(progress.NextTier.MinSpend-progress.RollingSpend, 0)
+		if progress.NextTier.MinSpend > 0 {
+			progress.Percent = math.Min(math.Round(progress.RollingSpend/progress.NextTier.MinSpend*10000)/100, 100)
+		} else {
+			progress.Percent = 100
+		}
+	} else {
+		progress.Percent = 100
+	}
+	db.Where("customer_id = ? AND used_at IS NULL AND expires_at > ?", customer.ID, now).
+		Order("expires_at ASC").Find(&progress.Vouchers)
+	return progress
+}
+
+func StartMembershipScheduler() {
+	s, err := gocron.NewScheduler(
+		gocron.WithLocation(time.FixedZone("ICT", 7*3600)),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	membershipScheduler = s
+
+	_, err = s.NewJob(
+		gocron.DailyJob(
+			1,
+			gocron.NewAtTimes(
+				gocron.NewAtTime(0, 30, 0),
+			),
+		),
+		gocron.NewTask(EvaluateMembershipTiers),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s.Start()
+	log.Println("✅ Membership tier scheduler started (00:30 ICT)")
+}
@@ -0,0 +1,238 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// paymentTolerance: sai lệch làm tròn cho phép khi so tổng các dòng thanh toán với tổng đơn
+const paymentTolerance = 0.5
+
+// counterTenders: các hình thức được nhận tại quầy
+var counterTenders = map[string]bool{
+	model.TenderCash:         true,
+	model.TenderCard:         true,
+	model.TenderBankTransfer: true,
+	model.TenderMomo:         true,
+	model.TenderVNPay:        true,
+	model.TenderGiftCard:     true,
+}
+
+// BuildCounterPaymentLines kiểm tra các dòng thanh toán tại quầy: tổng bằng total,
+// dòng thẻ quà tặng phải đúng mã/PIN và đủ số dư (thẻ được khóa trong tx).
+func BuildCounterPaymentLines(tx *gorm.DB, inputs []model.PaymentLineInput, total float64, createdBy uint) ([]model.OrderPayment, error) {
+	lines := make([]model.OrderPayment, 0, len(inputs))
+	sum := 0.0
+	giftCardUse := make(map[uint]float64)
+	for i, input := range inputs {
+		if !counterTenders[input.Method] {
+			return nil, fmt.Errorf("dòng %d: hình thức thanh toán %q không hợp lệ", i+1, input.Method)
+		}
+		if input.Amount <= 0 {
+			return nil, fmt.Errorf("dòng %d: số tiền phải lớn hơn 0", i+1)
+		}
+		line := model.OrderPayment{
+			Method:    input.Method,
+			Amount:    math.Round(input.Amount*100) / 100,
+			Reference: input.Reference,
+			CreatedBy: createdBy,
+		}
+		if input.Method == model.TenderGiftCard {
+			if input.GiftCardCode == "" || input.GiftCardPin == "" {
+				return nil, fmt.Errorf("dòng %d: vui lòng nhập mã thẻ và PIN", i+1)
+			}
+			card, err := LockGiftCard(tx, input.GiftCardCode, input.GiftCardPin)
+			if err != nil {
+				return nil, fmt.Errorf("dòng %d: %v", i+1, err)
+			}
+			giftCardUse[card.ID] += line.Amount
+			if giftCardUse[card.ID] > card.Balance {
+				return nil, fmt.Errorf("dòng %d: số dư thẻ không đủ (còn %.0fđ)", i+1, card.Balance)
+			}
+			line.GiftCardId = &card.ID
+			line.Reference = card.Code
+		}
+		sum += line.Amount
+		lines = append(lines, line)
+	}
+	if math.Abs(sum-total) > paymentTolerance {
+		return nil, fmt.Errorf("tổng các dòng thanh toán (%.0fđ) phải bằng tổng đơn (%.0fđ)", sum, total)
+	}
+	return lines, nil
+}
+
+// PaymentMethodLabel: hình thức ghi trên đơn, SPLIT nếu có nhiều hình thức
+func PaymentMethodLabel(lines []model.OrderPayment) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	for _, line := range lines[1:] {
+		if line.Method != lines[0].Method {
+			return model.PaymentMethodSplit
+		}
+	}
+	return lines[0].Method
+}
+
+// SaveOrderPayments lưu các dòng thanh toán (bỏ dòng 0đ), trừ số dư thẻ quà tặng / ví tương ứng
+// và cập nhật PaymentMethod của đơn (SPLIT nếu nhiều hình thức)
+func SaveOrderPayments(tx *gorm.DB, order *model.Order, lines []model.OrderPayment) error {
+	saved := make([]model.OrderPayment, 0, len(lines))
+	for i := range lines {
+		if lines[i].Amount <= 0 {
+			continue
+		}
+		lines[i].OrderId = order.ID
+		switch lines[i].Method {
+		case model.TenderGiftCard:
+			if lines[i].GiftCardId == nil {
+				return errors.New("thiếu thẻ quà tặng")
+			}
+			var card model.GiftCard
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, *lines[i].GiftCardId).Error; err != nil {
+				return err
+			}
+			if err := PayOrderWithGiftCard(tx, &card, order.ID, lines[i].Amount); err != nil {
+				return err
+			}
+		case model.TenderWallet:
+			if order.CustomerID == nil {
+				return errors.New("chỉ khách có tài khoản mới thanh toán bằng ví")
+			}
+			if err := PayOrderWithWallet(tx, *order.CustomerID, order.ID, lines[i].Amount); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&lines[i]).Error; err != nil {
+			return err
+		}
+		saved = append(saved, lines[i])
+	}
+	order.Payments = saved
+	if label := PaymentMethodLabel(saved); label != "" && label != order.PaymentMethod {
+		order.PaymentMethod = label
+		return tx.Model(&model.Order{}).Where("id = ?", order.ID).Update("payment_method", label).Error
+	}
+	return nil
+}
+
+// orderPaymentLines: dòng thanh toán của đơn; đơn cũ chưa có dòng thì tạo từ các trường tổng hợp trên đơn
+func orderPaymentLines(tx *gorm.DB, order *model.Order) ([]model.OrderPayment, error) {
+	var lines []model.OrderPayment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", order.ID).Order("id").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	if len(lines) > 0 {
+		return lines, nil
+	}
+	remaining := order.TotalAmount
+	if order.GiftCardAmount > 0 && order.GiftCardId != nil {
+		lines = append(lines, model.OrderPayment{OrderId: order.ID, Method: model.TenderGiftCard, Amount: order.GiftCardAmount, GiftCardId: order.GiftCardId})
+		remaining -= order.GiftCardAmount
+	}
+	if order.WalletAmount > 0 {
+		lines = append(lines, model.OrderPayment{OrderId: order.ID, Method: model.TenderWallet, Amount: order.WalletAmount})
+		remaining -= order.WalletAmount
+	}
+	if remaining > 0 {
+		lines = append(lines, model.OrderPayment{OrderId: order.ID, Method: order.PaymentMethod, Amount: remaining})
+	}
+	for i := range lines {
+		if err := tx.Create(&lines[i]).Error; err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+// AllocateOrderRefund phân bổ refundAmount về từng dòng thanh toán khi hủy đơn:
+// thẻ quà tặng và ví được hoàn trước (về đúng thẻ/ví đã dùng), sau đó các hình thức còn lại theo thứ tự
+// ngược lại lúc thanh toán. Nếu khách chọn toWallet (đơn có tài khoản), phần hoàn của hình thức khác
+// được cộng vào ví thay vì hoàn qua hình thức gốc. Mỗi dòng không hoàn quá số đã trả.
+func AllocateOrderRefund(tx *gorm.DB, order *model.Order, refundAmount float64, toWallet bool, createdBy *uint) (model.OrderRefundResult, error) {
+	result := model.OrderRefundResult{Lines: []model.RefundLine{}}
+	if refundAmount <= 0 {
+		return result, nil
+	}
+	lines, err := orderPaymentLines(tx, order)
+	if err != nil {
+		return result, err
+	}
+	priority := func(method string) int {
+		switch method {
+		case model.TenderGiftCard:
+			return 0
+		case model.TenderWallet:
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		pi, pj := priority(lines[i].Method), priority(lines[j].Method)
+		if pi != pj {
+			return pi < pj
+		}
+		return lines[i].ID > lines[j].ID
+	})
+
+	note := fmt.Sprintf("Hoàn tiền hủy đơn %s", order.PublicCode)
+	remaining := math.Round(refundAmount*100) / 100
+	for i := range lines {
+		if remaining <= 0 {
+			break
+		}
+		line := &lines[i]
+		amount := math.Min(line.Amount-line.RefundedAmount, remaining)
+		if amount <= 0 {
+			continue
+		}
+		refundLine := model.RefundLine{PaymentId: line.ID, Method: line.Method, Amount: amount}
+		switch {
+		case line.Method == model.TenderGiftCard && line.GiftCardId != nil:
+			var card model.GiftCard
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, *line.GiftCardId).Error; err != nil {
+				return result, err
+			}
+			if err := applyGiftCardEntry(tx, &card, model.StoredValueRefund, amount, &order.ID, note, createdBy); err != nil {
+				return result, err
+			}
+			result.GiftCard += amount
+		case line.Method == model.TenderWallet || (toWallet && order.CustomerID != nil):
+			if order.CustomerID == nil {
+				return result, errors.New("đơn không có tài khoản để hoàn vào ví")
+			}
+			if err := applyWalletEntry(tx, *order.CustomerID, model.StoredValueRefund, amount, &order.ID, note, createdBy); err != nil {
+				return result, err
+			}
+			result.Wallet += amount
+			if line.Method != model.TenderWallet {
+				line.RefundedToWallet += amount
+				refundLine.ToWallet = true
+			}
+		default:
+			result.Original += amount
+		}
+		line.RefundedAmount += amount
+		if err := tx.Model(&model.OrderPayment{}).Where("id = ?", line.ID).Updates(map[string]interface{}{
+			"refunded_amount":    line.RefundedAmount,
+			"refunded_to_wallet": line.RefundedToWallet,
+		}).Error; err != nil {
+			return result, err
+		}
+		result.Lines = append(result.Lines, refundLine)
+		remaining -= amount
+	}
+	if result.Wallet > 0 {
+		order.WalletRefund += result.Wallet
+		if err := tx.Model(&model.Order{}).Where("id = ?", order.ID).Update("wallet_refund", order.WalletRefund).Error; err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,15 @@
+package helper
+
+import "gorm.io/gorm"
+
+// CancelOrderSideEffects hoàn tác các phần phát sinh theo đơn khi đơn bị hủy:
+// bắp nước (kèm hoàn kho), điểm thành viên và voucher. Gọi trong cùng transaction hủy đơn.
+func CancelOrderSideEffects(tx *gorm.DB, orderId uint, cancelledBy uint) error {
+	if err := CancelOrderConcessions(tx, orderId, cancelledBy); err != nil {
+		return err
+	}
+	if err := ReverseOrderPoints(tx, orderId); err != nil {
+		return err
+	}
+	return RestoreOrderVoucher(tx, orderId)
+}
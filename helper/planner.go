@@ -0,0 +1,449 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Mục tiêu tối ưu của bộ lập lịch
+const (
+	PlanObjectiveRevenue   = "revenue"
+	PlanObjectiveSeatHours = "seat_hours"
+)
+
+const (
+	DefaultPlanOccupancy = 0.3 // tỉ lệ lấp đầy mặc định khi phim chưa có dữ liệu
+	planStep             = 5 * time.Minute
+	planRepeatDecay      = 0.85 // mỗi suất lặp lại trong ngày giảm sức hút của phim
+)
+
+// Thứ tự ưu tiên định dạng: phòng hỗ trợ định dạng cao cấp thì dùng định dạng đó
+var formatRank = map[string]int{"IMAX": 4, "4DX": 3, "3D": 2, "2D": 1}
+
+type PlannerMovie struct {
+	Movie        model.Movie
+	Weight       float64 // trọng số mục tiêu do quản lý nhập (mặc định 1)
+	Occupancy    float64 // tỉ lệ lấp đầy lịch sử
+	IsVietnamese bool
+}
+
+type PlannerRoom struct {
+	Room       model.Room
+	Capacity   int
+	Turnaround Turnaround // dọn phòng + quảng cáo theo loại phòng
+}
+
+type PlannerOptions struct {
+	Objective string
+	CinemaId  uint
+	// Ghi đè giờ hoạt động / dọn phòng đã cấu hình (nil = dùng cấu hình của rạp, loại phòng)
+	OpenTime  *time.Duration // tính từ 00:00 của ngày
+	CloseTime *time.Duration // không sau giờ mở cửa = đóng cửa sau nửa đêm
+	Cleaning  *time.Duration
+	Stagger   time.Duration
+	Location  *time.Location
+}
+
+type PlannedShowtime struct {
+	MovieId         uint      `json:"movieId"`
+	MovieTitle      string    `json:"movieTitle"`
+	RoomId          uint      `json:"roomId"`
+	RoomName        string    `json:"roomName"`
+	Format          string    `json:"format"`
+	StartTime       time.Time `json:"start"`
+	EndTime         time.Time `json:"end"`
+	Price           float64   `json:"price"`
+	ExpectedSeats   float64   `json:"expectedSeats"`
+	ExpectedRevenue float64   `json:"expectedRevenue"`
+	SeatHours       float64   `json:"seatHours"`
+}
+
+type PlanSummary struct {
+	Showtimes       int                    `json:"showtimes"`
+	ExpectedSeats   float64                `json:"expectedSeats"`
+	ExpectedRevenue float64                `json:"expectedRevenue"`
+	SeatHours       float64                `json:"seatHours"`
+	ByMovie         map[string]int         `json:"byMovie"`
+	Utilization     map[string]float64     `json:"utilization"` // % thời gian mở cửa phòng được sử dụng
+	Skipped         []string               `json:"skipped"`
+	Occupancy       map[string]float64     `json:"occupancy"`
+	Options         map[string]interface{} `json:"options"`
+}
+
+// LoadMovieOccupancy lấy tỉ lệ lấp đầy trung bình của từng phim trong 30 ngày gần nhất.
+// Ưu tiên số liệu tại rạp, nếu không có thì dùng số liệu toàn chuỗi.
+func LoadMovieOccupancy(db *gorm.DB, cinemaID uint, movieIDs []uint) map[uint]float64 {
+	result := make(map[uint]float64)
+	if len(movieIDs) == 0 {
+		return result
+	}
+	since := time.Now().AddDate(0, 0, -30)
+
+	type row struct {
+		MovieId   uint
+		Occupancy float64
+	}
+	query := func(scoped bool) []row {
+		var rows []row
+		q := db.Table("showtime_seats AS ss").
+			Select(`st.movie_id AS movie_id,
+				COUNT(*) FILTER (WHERE ss.status IN ('SOLD', 'BOOKED'))::float / NULLIF(COUNT(*), 0) AS occupancy`).
+			Joins("JOIN showtimes st ON st.id = ss.showtime_id").
+			Joins("JOIN rooms r ON r.id = st.room_id").
+			Where("st.movie_id IN ? AND st.start_time BETWEEN ? AND ?", movieIDs, since, time.Now())
+		if scoped {
+			q = q.Where("r.cinema_id = ?", cinemaID)
+		}
+		q.Group("st.movie_id").Scan(&rows)
+		return rows
+	}
+
+	for _, r := range query(false) {
+		result[r.MovieId] = r.Occupancy
+	}
+	for _, r := range query(true) {
+		result[r.MovieId] = r.Occupancy
+	}
+	return result
+}
+
+// hourDemandFactor: hệ số nhu cầu theo khung giờ bắt đầu
+func hourDemandFactor(hour int) float64 {
+	switch {
+	case hour < 12:
+		return 0.55
+	case hour < 17:
+		return 0.8
+	case hour < 22:
+		return 1.0
+	default:
+		return 0.7
+	}
+}
+
+// dayDemandFactor: hệ số nhu cầu theo loại ngày (cuối tuần, ngày lễ...)
+func dayDemandFactor(info *DayInfo) float64 {
+	factor := 1.0
+	if info.IsFriday {
+		factor = 1.1
+	}
+	if info.IsWeekend {
+		factor = 1.25
+	}
+	if info.IsHoliday || info.IsLunarHoliday {
+		factor = 1.35
+	}
+	// Sự kiện đặc biệt toàn rạp (liên hoan phim, marathon...) kéo thêm khách
+	if info.IsSpecial {
+		factor *= 1.15
+	}
+	return factor
+}
+
+// bestFormat chọn định dạng cao cấp nhất mà cả phòng và phim cùng hỗ trợ
+func bestFormat(room model.Room, movie model.Movie) string {
+	best := ""
+	for _, rf := range room.Formats {
+		for _, mf := range movie.Formats {
+			if strings.EqualFold(rf.Name, mf.Name) && formatRank[rf.Name] > formatRank[best] {
+				best = rf.Name
+			}
+		}
+	}
+	return best
+}
+
+type planInterval struct {
+	start, end time.Time
+}
+
+// PlanCinemaSchedule lập lịch tham lam theo từng ngày: phòng nào rảnh sớm nhất sẽ được xếp
+// phim có giá trị kỳ vọng trên mỗi phút sử dụng phòng cao nhất. Các suất giữa các phòng
+// được giãn cách tối thiểu opts.Stagger, không trùng với lịch đã có (kể cả thời gian dọn phòng).
+func PlanCinemaSchedule(tx *gorm.DB, movies []PlannerMovie, rooms []PlannerRoom, startDate, endDate time.Time, opts PlannerOptions) ([]PlannedShowtime, PlanSummary) {
+	summary := PlanSummary{
+		ByMovie:     map[string]int{},
+		Utilization: map[string]float64{},
+		Skipped:     []string{},
+		Occupancy:   map[string]float64{},
+	}
+	planned := []PlannedShowtime{}
+
+	// Phòng lớn được ưu tiên xếp trước để phim hot vào phòng lớn
+	sort.SliceStable(rooms, func(i, j int) bool { return rooms[i].Capacity > rooms[j].Capacity })
+
+	avgWeight := 0.0
+	for _, m := range movies {
+		avgWeight += m.Weight
+		summary.Occupancy[m.Movie.Title] = math.Round(m.Occupancy*1000) / 1000
+	}
+	if len(movies) > 0 {
+		avgWeight /= float64(len(movies))
+	}
+
+	now := time.Now().In(opts.Location)
+	usedMinutes := map[string]float64{}
+	openMinutes := 0.0
+
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, opts.Location)
+		window := GetOperatingWindow(tx, opts.CinemaId, dayStart)
+		openAt, closeAt := window.Open, window.Close
+		if opts.OpenTime != nil {
+			openAt = dayStart.Add(*opts.OpenTime)
+		}
+		if opts.CloseTime != nil {
+			closeAt = dayStart.Add(*opts.CloseTime)
+		}
+		// Đóng cửa sau nửa đêm: suất cuối có thể kết thúc sang ngày hôm sau
+		closeAt = CloseAfterOpen(openAt, closeAt)
+		openMinutes += closeAt.Sub(openAt).Minutes()
+		dayFactor := dayDemandFactor(ClassifyDayAt(dayStart, time.Time{}, opts.CinemaId, 0))
+
+		// Phim được phép chiếu trong ngày
+		dayMovies := []PlannerMovie{}
+		for _, m := range movies {
+			if IsValidShowDate(dayStart, &m.Movie) && CheckFilmLicense(tx, m.Movie.ID, opts.CinemaId, dayStart) == nil {
+				dayMovies = append(dayMovies, m)
+			}
+		}
+		if len(dayMovies) == 0 {
+			summary.Skipped = append(summary.Skipped, fmt.Sprintf("%s: không có phim hợp lệ", dayStart.Format("02/01")))
+			continue
+		}
+
+		// Lịch đã có trong các phòng → khoảng thời gian bị chặn
+		blocked := make(map[uint][]planInterval)
+		cleaning := make(map[uint]time.Duration)
+		for _, r := range rooms {
+			cleaning[r.Room.ID] = r.Turnaround.Cleaning
+			if opts.Cleaning != nil {
+				cleaning[r.Room.ID] = *opts.Cleaning
+			}
+			buffer := cleaning[r.Room.ID]
+			var existing []model.Showtime
+			tx.Where("room_id = ? AND start_time < ? AND end_time > ?", r.Room.ID, closeAt.Add(buffer), openAt.Add(-buffer)).
+				Where("status <> ?", "CANCELLED").
+				Order("start_time").Find(&existing)
+			for _, e := range existing {
+				blocked[r.Room.ID] = append(blocked[r.Room.ID], planInterval{
+					start: e.StartTime.Add(-buffer),
+					end:   e.EndTime.Add(buffer),
+				})
+			}
+		}
+
+		// Con trỏ thời gian của từng phòng, khởi đầu so le theo opts.Stagger
+		cursor := make(map[uint]time.Time)
+		done := make(map[uint]bool)
+		for i, r := range rooms {
+			cursor[r.Room.ID] = openAt.Add(time.Duration(i) * opts.Stagger)
+		}
+		dayStarts := []time.Time{}
+		repeatCount := make(map[uint]int)
+
+		for {
+			// Chọn phòng có con trỏ sớm nhất
+			var room *PlannerRoom
+			for i := range rooms {
+				r := &rooms[i]
+				if done[r.Room.ID] {
+					continue
+				}
+				if room == nil || cursor[r.Room.ID].Before(cursor[room.Room.ID]) {
+					room = r
+				}
+			}
+			if room == nil {
+				break
+			}
+			t := cursor[room.Room.ID]
+			if !t.Before(closeAt) {
+				done[room.Room.ID] = true
+				continue
+			}
+			// Không xếp suất trong quá khứ
+			if t.Before(now) {
+				cursor[room.Room.ID] = now.Truncate(planStep).Add(planStep)
+				continue
+			}
+			// Giãn cách giờ bắt đầu giữa các phòng
+			if staggerConflict(dayStarts, t, opts.Stagger) {
+				cursor[room.Room.ID] = t.Add(planStep)
+				continue
+			}
+
+			var best *PlannerMovie
+			var bestFmt string
+			var bestEnd time.Time
+			bestDensity := 0.0
+			for i := range dayMovies {
+				m := &dayMovies[i]
+				format := bestFormat(room.Room, m.Movie)
+				if format == "" {
+					continue
+				}
+				end := ShowtimeEndTime(t, m.Movie.Duration, room.Turnaround)
+				if end.After(closeAt) {
+					continue
+				}
+				if overlapsBlocked(blocked[room.Room.ID], t, end) {
+					continue
+				}
+				seats, value := expectedValue(m, room.Capacity, format, t, dayStart, dayFactor, avgWeight, repeatCount[m.Movie.ID], opts.Objective)
+				if seats <= 0 {
+					continue
+				}
+				density := value / end.Add(cleaning[room.Room.ID]).Sub(t).Minutes()
+				if best == nil || density > bestDensity {
+					best, bestFmt, bestEnd, bestDensity = m, format, end, density
+				}
+			}
+
+			if best == nil {
+				// Không còn đủ thời gian cho phim ngắn nhất → phòng kết thúc ngày
+				if ShowtimeEndTime(t, minDuration(dayMovies), room.Turnaround).After(closeAt) {
+					done[room.Room.ID] = true
+					continue
+				}
+				// Đang nằm trong khoảng bị chặn thì nhảy qua, nếu không tiến 1 bước
+				next := t.Add(planStep)
+				for _, b := range blocked[room.Room.ID] {
+					if !t.Before(b.start) && t.Before(b.end) && b.end.After(next) {
+						next = b.end
+					}
+				}
+				cursor[room.Room.ID] = roundUp(next, planStep)
+				continue
+			}
+
+			price := ApplyEventPricing(CalculateDynamicPrice(t, bestFmt, dayStart, best.IsVietnamese),
+				FindSpecialEvents(tx, dayStart, opts.CinemaId, best.Movie.ID))
+			seats, _ := expectedValue(best, room.Capacity, bestFmt, t, dayStart, dayFactor, avgWeight, repeatCount[best.Movie.ID], opts.Objective)
+			hours := float64(best.Movie.Duration) / 60
+			planned = append(planned, PlannedShowtime{
+				MovieId:         best.Movie.ID,
+				MovieTitle:      best.Movie.Title,
+				RoomId:          room.Room.ID,
+				RoomName:        room.Room.Name,
+				Format:          bestFmt,
+				StartTime:       t,
+				EndTime:         bestEnd,
+				Price:           price,
+				ExpectedSeats:   math.Round(seats*10) / 10,
+				ExpectedRevenue: math.Round(seats * price),
+				SeatHours:       math.Round(seats*hours*10) / 10,
+			})
+			repeatCount[best.Movie.ID]++
+			dayStarts = append(dayStarts, t)
+			blocked[room.Room.ID] = append(blocked[room.Room.ID], planInterval{start: t.Add(-cleaning[room.Room.ID]), end: bestEnd.Add(cleaning[room.Room.ID])})
+			usedMinutes[room.Room.Name] += bestEnd.Sub(t).Minutes()
+			cursor[room.Room.ID] = roundUp(bestEnd.Add(cleaning[room.Room.ID]), planStep)
+		}
+	}
+
+	for _, p := range planned {
+		summary.Showtimes++
+		summary.ExpectedSeats += p.ExpectedSeats
+		summary.ExpectedRevenue += p.ExpectedRevenue
+		summary.SeatHours += p.SeatHours
+		summary.ByMovie[p.MovieTitle]++
+	}
+	if openMinutes > 0 {
+		for _, r := range rooms {
+			summary.Utilization[r.Room.Name] = math.Round(usedMinutes[r.Room.Name]/openMinutes*1000) / 10
+		}
+	}
+	summary.ExpectedSeats = math.Round(summary.ExpectedSeats)
+	summary.SeatHours = math.Round(summary.SeatHours)
+	return planned, summary
+}
+
+// expectedValue trả về số ghế kỳ vọng và giá trị theo mục tiêu (doanh thu hoặc ghế-giờ)
+func expectedValue(m *PlannerMovie, capacity int, format string, start, date time.Time, dayFactor, avgWeight float64, repeats int, objective string) (float64, float64) {
+	weight := 1.0
+	if avgWeight > 0 {
+		weight = m.Weight / avgWeight
+	}
+	fill := m.Occupancy * weight * hourDemandFactor(start.Hour()) * dayFactor * math.Pow(planRepeatDecay, float64(repeats))
+	fill = math.Min(fill, 1)
+	seats := fill * float64(capacity)
+
+	if objective == PlanObjectiveSeatHours {
+		return seats, seats * float64(m.Movie.Duration) / 60
+	}
+	return seats, seats * CalculateDynamicPrice(start, format, date, m.IsVietnamese)
+}
+
+func staggerConflict(starts []time.Time, t time.Time, stagger time.Duration) bool {
+	if stagger <= 0 {
+		return false
+	}
+	for _, s := range starts {
+		diff := t.Sub(s)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff < stagger {
+			return true
+		}
+	}
+	return false
+}
+
+func overlapsBlocked(blocked []planInterval, start, end time.Time) bool {
+	for _, b := range blocked {
+		if start.Before(b.end) && end.After(b.start) {
+			return true
+		}
+	}
+	return false
+}
+
+func minDuration(movies []PlannerMovie) int {
+	min := 0
+	for _, m := range movies {
+		if min == 0 || m.Movie.Duration < min {
+			min = m.Movie.Duration
+		}
+	}
+	return min
+}
+
+func roundUp(t time.Time, step time.Duration) time.Time {
+	r := t.Truncate(step)
+	if r.Before(t) {
+		r = r.Add(step)
+	}
+	return r
+}
+
+// ParseClockOffset đổi "HH:MM" thành khoảng thời gian tính từ 00:00 (luôn < 24h).
+// Việc dời giờ đóng cửa sang ngày hôm sau do CloseAfterOpen đảm nhận.
+func ParseClockOffset(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("giờ không hợp lệ: %s", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// CloseAfterOpen: giờ đóng cửa không sau giờ mở cửa (vd 08:00 - 01:00) được hiểu là sang ngày hôm sau
+func CloseAfterOpen(openAt, closeAt time.Time) time.Time {
+	for !closeAt.After(openAt) {
+		closeAt = closeAt.Add(24 * time.Hour)
+	}
+	return closeAt
+}
+
+// IsVietnameseMovie: phim Việt được cộng giá giờ vàng
+func IsVietnameseMovie(movie model.Movie) bool {
+	country := strings.ToLower(strings.TrimSpace(movie.Country))
+	return country == "việt nam" || country == "vietnam" || country == "vn"
+}
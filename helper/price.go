@@ -64,6 +64,9 @@ func Contains(arr []string, val string) bool {
 	return false
 }
 
+// weekendSurcharge: phụ thu cuối tuần, ngày lễ rơi vào ngày thường cũng tính như cuối tuần
+const weekendSurcharge = 20000.0
+
 func CalculateDynamicPrice(startTime time.Time, format string, date time.Time, isVietnamese bool) float64 {
 	base := 50000.0
 	hour := startTime.Hour()
@@ -88,8 +91,21 @@ func CalculateDynamicPrice(startTime time.Time, format string, date time.Time, i
 
 	// Cuối tuần
 	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
-		base += 20000
+		base += weekendSurcharge
 	}
 
 	return base
 }
+
+// CalculateDayTypePrice tính giá theo loại ngày lấy từ ClassifyDay: ngày lễ (dương / âm lịch)
+// tính như cuối tuần, sau đó áp điều chỉnh giá của sự kiện đặc biệt.
+func CalculateDayTypePrice(startTime time.Time, format string, day *DayInfo, isVietnamese bool) float64 {
+	price := CalculateDynamicPrice(startTime, format, day.Date, isVietnamese)
+	if (day.IsHoliday || day.IsLunarHoliday) && !day.IsWeekend {
+		price += weekendSurcharge
+	}
+	if day.IsSpecial {
+		price = ApplyEventPricing(price, day.Events)
+	}
+	return price
+}
@@ -0,0 +1,64 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SeatReserved: ghế thuộc đơn đặt chỗ chưa thanh toán (không bị worker giữ ghế giải phóng)
+const SeatReserved = "RESERVED"
+
+// ReservationHolder: giá trị held_by của ghế thuộc đơn đặt chỗ
+func ReservationHolder(orderCode string) string {
+	return "RESV_" + orderCode
+}
+
+// ReservationCutoff: hạn giữ chỗ, cutoffMinutes phút trước giờ chiếu (mặc định 30 phút)
+func ReservationCutoff(start time.Time, cutoffMinutes int) time.Time {
+	if cutoffMinutes <= 0 {
+		cutoffMinutes = model.DefaultReservationCutoff
+	}
+	return start.Add(-time.Duration(cutoffMinutes) * time.Minute)
+}
+
+// ReleaseReservation hủy đơn đặt chỗ chưa thanh toán: hủy vé và trả ghế về AVAILABLE.
+// Trả về các ghế vừa giải phóng (đã nạp Seat.SeatType) để broadcast.
+func ReleaseReservation(tx *gorm.DB, order *model.Order, now time.Time) ([]model.ShowtimeSeat, error) {
+	if err := tx.Model(&model.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"status":         "CANCELLED",
+		"cancelled_at":   now,
+		"actual_revenue": 0,
+	}).Error; err != nil {
+		return nil, err
+	}
+	order.Status = "CANCELLED"
+	order.CancelledAt = &now
+
+	if err := tx.Model(&model.Ticket{}).
+		Where("order_id = ? AND status = ?", order.ID, SeatReserved).
+		Updates(map[string]interface{}{"status": "CANCELLED", "cancelled_at": now}).Error; err != nil {
+		return nil, err
+	}
+
+	var seats []model.ShowtimeSeat
+	if err := tx.Preload("Seat.SeatType").
+		Where("showtime_id = ? AND status = ? AND held_by = ?", order.ShowtimeID, SeatReserved, ReservationHolder(order.PublicCode)).
+		Find(&seats).Error; err != nil {
+		return nil, err
+	}
+	if len(seats) == 0 {
+		return seats, nil
+	}
+	ids := make([]uint, 0, len(seats))
+	for i := range seats {
+		ids = append(ids, seats[i].ID)
+		seats[i].Status = "AVAILABLE"
+		seats[i].HeldBy = ""
+		seats[i].ExpiredAt = nil
+	}
+	err := tx.Model(&model.ShowtimeSeat{}).Where("id IN ?", ids).
+		Updates(map[string]interface{}{"status": "AVAILABLE", "held_by": "", "expired_at": nil}).Error
+	return seats, err
+}
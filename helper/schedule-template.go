@@ -11,10 +11,29 @@ import (
 )
 
 func SuggestTemplate(movie *model.Movie, startDate, endDate time.Time, isVietnamese *bool) *model.ScheduleTemplate {
-	isWeekend := startDate.Weekday() >= time.Friday
+	return SuggestTemplateAt(movie, startDate, 0, isVietnamese)
+}
+
+// SuggestTemplateAt chọn mẫu lịch cho phim vào ngày date tại một rạp (0 = chỉ xét sự kiện toàn hệ thống)
+func SuggestTemplateAt(movie *model.Movie, date time.Time, cinemaID uint, isVietnamese *bool) *model.ScheduleTemplate {
 	viet := isVietnamese != nil && *isVietnamese
 
 	var templates []model.ScheduleTemplate
+
+	// Ngày có sự kiện đặc biệt (công chiếu, liên hoan phim...) ưu tiên mẫu gắn loại sự kiện đó
+	day := ClassifyDayAt(date, movie.DateRelease.Time, cinemaID, movie.ID)
+	if day.IsSpecial {
+		for _, event := range day.Events {
+			database.DB.Where("deleted_at IS NULL").
+				Where("day_types::jsonb @> ?", fmt.Sprintf(`["%s"]`, event.Type)).
+				Order("priority DESC").Limit(1).Find(&templates)
+			if len(templates) > 0 {
+				return &templates[0]
+			}
+		}
+	}
+	isWeekend := day.IsWeekend || day.IsFriday
+
 	query := database.DB.Where("deleted_at IS NULL")
 
 	if viet {
@@ -81,17 +100,7 @@ func IsValidShowDate(date time.Time, movie *model.Movie) bool {
 }
 
 func GetMaxPerDay(format string, t *model.ScheduleTemplate) int {
-	if t != nil {
-		return t.MaxPerDay
-	}
-	switch format {
-	case "3D":
-		return 4
-	case "4DX", "IMAX":
-		return 3
-	default:
-		return 8
-	}
+	return GetFormatConfig(format, t).MaxPerDay
 }
 
 const (
@@ -2,49 +2,90 @@ package helper
 
 import (
 	"cinema_manager/model"
+	"fmt"
 	"strconv"
 	"strings"
 )
 
-type FormatConfig struct {
-	MaxPerDay     int
-	StartHour     int // 18 → 18:00
-	EndHour       int // 22 → 22:00
-	OffsetMinutes int // offset giữa các phòng
-}
-
-var SpecialFormatConfig = map[string]FormatConfig{
+// DefaultFormatRules: quy tắc mặc định khi mẫu lịch không khai báo cho định dạng.
+// Giữ nguyên hành vi cũ của AutoGenerateShowtimeSchedule: khung giờ lấy từ bộ lọc khung giờ cũ
+// (3D 12h-21h, 4DX từ 14h, IMAX từ 18h, 2D không giới hạn), số suất/ngày và lệch giờ từ SpecialFormatConfig,
+// tối đa 6 phòng (2 phòng với định dạng đặc biệt), IMAX chỉ 1 suất sau 22h.
+var DefaultFormatRules = map[string]model.FormatRule{
+	"2D": {
+		Format:        "2D",
+		MaxPerDay:     8,
+		StartHour:     0,
+		EndHour:       24,
+		OffsetMinutes: 15,
+		MaxRooms:      6,
+	},
 	"3D": {
+		Format:        "3D",
 		MaxPerDay:     4,
-		StartHour:     18,
-		EndHour:       22,
+		StartHour:     12,
+		EndHour:       21,
 		OffsetMinutes: 15,
+		MaxRooms:      2,
 	},
 	"4DX": {
+		Format:        "4DX",
 		MaxPerDay:     3,
-		StartHour:     18,
-		EndHour:       22,
+		StartHour:     14,
+		EndHour:       24,
 		OffsetMinutes: 20,
+		MaxRooms:      2,
 	},
 	"IMAX": {
+		Format:        "IMAX",
 		MaxPerDay:     3,
-		StartHour:     19,
-		EndHour:       23,
+		StartHour:     18,
+		EndHour:       24,
 		OffsetMinutes: 20,
+		MaxRooms:      2,
+		LateHour:      22,
+		MaxLatePerDay: 1,
 	},
 }
 
-func GetFormatConfig(format string, template *model.ScheduleTemplate) FormatConfig {
-	if config, exists := SpecialFormatConfig[format]; exists {
-		return config
+// GetFormatConfig trả về quy tắc của định dạng: ưu tiên quy tắc trong mẫu lịch, sau đó mặc định hệ thống
+func GetFormatConfig(format string, template *model.ScheduleTemplate) model.FormatRule {
+	if template != nil {
+		for _, rule := range template.FormatRules {
+			if strings.EqualFold(rule.Format, format) {
+				return rule
+			}
+		}
 	}
-	// Mặc định cho 2D
-	return FormatConfig{
-		MaxPerDay:     8,
-		StartHour:     10,
-		EndHour:       23,
-		OffsetMinutes: 15,
+	rule, exists := DefaultFormatRules[strings.ToUpper(format)]
+	if !exists {
+		rule = DefaultFormatRules["2D"]
+		rule.Format = format
+	}
+	// Mẫu cũ chưa có quy tắc định dạng: giữ giới hạn suất/ngày chung của mẫu
+	if template != nil && template.MaxPerDay > 0 {
+		rule.MaxPerDay = template.MaxPerDay
+	}
+	return rule
+}
+
+// ValidateFormatRules kiểm tra khung giờ hợp lệ và mỗi định dạng chỉ khai báo một lần
+func ValidateFormatRules(rules []model.FormatRule) error {
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		key := strings.ToUpper(rule.Format)
+		if seen[key] {
+			return fmt.Errorf("Định dạng %s bị khai báo nhiều lần", rule.Format)
+		}
+		seen[key] = true
+		if rule.StartHour >= rule.EndHour {
+			return fmt.Errorf("Định dạng %s: giờ bắt đầu (%d) phải trước giờ kết thúc (%d)", rule.Format, rule.StartHour, rule.EndHour)
+		}
+		if rule.LateHour > 0 && (rule.LateHour <= rule.StartHour || rule.LateHour >= rule.EndHour) {
+			return fmt.Errorf("Định dạng %s: giờ tính suất muộn (%d) phải nằm trong khung %d–%d", rule.Format, rule.LateHour, rule.StartHour, rule.EndHour)
+		}
 	}
+	return nil
 }
 func ParseHour(timeStr string) int {
 	parts := strings.Split(timeStr, ":")
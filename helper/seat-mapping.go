@@ -0,0 +1,152 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SeatLabel: nhãn ghế dạng "A1"
+func SeatLabel(seat model.Seat) string {
+	return fmt.Sprintf("%s%d", seat.Row, seat.Column)
+}
+
+// rowIndex đổi tên hàng ("A", "B", ..., "AA") thành số thứ tự để tính khoảng cách
+func rowIndex(row string) int {
+	idx := 0
+	for _, ch := range strings.ToUpper(row) {
+		if ch < 'A' || ch > 'Z' {
+			continue
+		}
+		idx = idx*26 + int(ch-'A'+1)
+	}
+	return idx
+}
+
+func seatDistance(a, b model.Seat) int {
+	dr := rowIndex(a.Row) - rowIndex(b.Row)
+	dc := a.Column - b.Column
+	if dr < 0 {
+		dr = -dr
+	}
+	if dc < 0 {
+		dc = -dc
+	}
+	// Lệch hàng khó chịu hơn lệch cột
+	return dr*3 + dc
+}
+
+// MapSeatsToRoom ánh xạ các ghế đã bán sang phòng đích: ưu tiên ghế cùng nhãn và cùng loại,
+// sau đó ghế cùng loại gần nhất. Ghế đôi được ánh xạ theo cặp. Trả về map ghế nguồn → ghế đích
+// và danh sách nhãn ghế không thể ánh xạ.
+func MapSeatsToRoom(sources []model.Seat, targets []model.Seat) (map[uint]model.Seat, []string) {
+	result := make(map[uint]model.Seat)
+	unmapped := []string{}
+
+	targetByID := make(map[uint]model.Seat)
+	targetByLabel := make(map[string]model.Seat)
+	for _, t := range targets {
+		if !t.IsAvailable {
+			continue
+		}
+		targetByID[t.ID] = t
+		targetByLabel[SeatLabel(t)] = t
+	}
+	used := make(map[uint]bool)
+
+	// Gom ghế nguồn thành đơn vị: ghế đơn hoặc cặp ghế đôi cùng được bán
+	sourceByID := make(map[uint]model.Seat)
+	for _, s := range sources {
+		sourceByID[s.ID] = s
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].Row != sources[j].Row {
+			return rowIndex(sources[i].Row) < rowIndex(sources[j].Row)
+		}
+		return sources[i].Column < sources[j].Column
+	})
+	var units [][]model.Seat
+	grouped := make(map[uint]bool)
+	for _, s := range sources {
+		if grouped[s.ID] {
+			continue
+		}
+		grouped[s.ID] = true
+		unit := []model.Seat{s}
+		if s.CoupleId != nil {
+			if partner, ok := sourceByID[*s.CoupleId]; ok && !grouped[partner.ID] {
+				grouped[partner.ID] = true
+				unit = append(unit, partner)
+			}
+		}
+		units = append(units, unit)
+	}
+
+	// candidate trả về các ghế đích cho đơn vị nếu bắt đầu từ ghế t
+	candidate := func(unit []model.Seat, t model.Seat) []model.Seat {
+		if used[t.ID] || t.SeatTypeId != unit[0].SeatTypeId {
+			return nil
+		}
+		if len(unit) == 1 {
+			return []model.Seat{t}
+		}
+		if t.CoupleId == nil {
+			return nil
+		}
+		partner, ok := targetByID[*t.CoupleId]
+		if !ok || used[partner.ID] || partner.SeatTypeId != unit[1].SeatTypeId {
+			return nil
+		}
+		// Giữ thứ tự trái/phải của cặp ghế
+		if (unit[0].Column < unit[1].Column) != (t.Column < partner.Column) {
+			t, partner = partner, t
+		}
+		return []model.Seat{t, partner}
+	}
+	assign := func(unit []model.Seat, seats []model.Seat) {
+		for i := range unit {
+			result[unit[i].ID] = seats[i]
+			used[seats[i].ID] = true
+		}
+	}
+
+	// Lượt 1: cùng nhãn
+	pending := [][]model.Seat{}
+	for _, unit := range units {
+		if t, ok := targetByLabel[SeatLabel(unit[0])]; ok {
+			if seats := candidate(unit, t); seats != nil && SeatLabel(seats[0]) == SeatLabel(unit[0]) {
+				assign(unit, seats)
+				continue
+			}
+		}
+		pending = append(pending, unit)
+	}
+
+	// Lượt 2: ghế cùng loại gần nhất
+	for _, unit := range pending {
+		var best []model.Seat
+		bestDistance := 0
+		for _, t := range targets {
+			if _, ok := targetByID[t.ID]; !ok {
+				continue
+			}
+			seats := candidate(unit, t)
+			if seats == nil {
+				continue
+			}
+			distance := seatDistance(unit[0], seats[0])
+			if best == nil || distance < bestDistance {
+				best, bestDistance = seats, distance
+			}
+		}
+		if best == nil {
+			for _, s := range unit {
+				unmapped = append(unmapped, SeatLabel(s))
+			}
+			continue
+		}
+		assign(unit, best)
+	}
+	return result, unmapped
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
 )
 
 var scheduler *cron.Cron
@@ -62,39 +63,19 @@ func StopShowtimeScheduler() {
 	}
 }
 
-// FilterSlotsByFormat lọc danh sách khung giờ chiếu phù hợp với từng định dạng phim
-func FilterSlotsByFormatAndTime(slots []string, format string, movieDuration int, currentDate time.Time, loc *time.Location) []string {
+// FilterSlotsByFormatAndTime lọc các khung giờ "15:04" nằm trong khung giờ của quy tắc định dạng
+func FilterSlotsByFormatAndTime(slots []string, rule model.FormatRule) []string {
 	var valid []string
 	for _, slot := range slots {
 		parts := strings.Split(slot, ":")
 		if len(parts) != 2 {
 			continue
 		}
-		hour, _ := strconv.Atoi(parts[0])
-		minute, _ := strconv.Atoi(parts[1])
-
-		// Tạo startTime giả để tính endTime
-		startTime := time.Date(currentDate.Year(), currentDate.Month(), currentDate.Day(), hour, minute, 0, 0, loc)
-		endTime := startTime.Add(time.Minute * time.Duration(movieDuration))
-
-		switch format {
-		case "3D":
-			// Trưa - tối: >=12:00 và <21:00 (bắt đầu trước 21:00)
-			if hour >= 12 && hour < 21 {
-				valid = append(valid, slot)
-			}
-		case "4DX":
-			// Chiều - đêm: >=14:00
-			if hour >= 14 {
-				valid = append(valid, slot)
-			}
-		case "IMAX":
-			// Tối - đêm, không quá 1h (giả định: bắt đầu >=18:00, kết thúc <=23:59 để tránh muộn)
-			if hour >= 18 && endTime.Hour() < 24 { // hoặc endTime.Before(startTime.Add(24*time.Hour)) nhưng chặt hơn
-				valid = append(valid, slot)
-			}
-		case "2D":
-			// 2D: không giới hạn, trả hết
+		hour, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		if hour >= rule.StartHour && hour < rule.EndHour {
 			valid = append(valid, slot)
 		}
 	}
@@ -105,3 +86,15 @@ func FilterSlotsByFormatAndTime(slots []string, format string, movieDuration int
 func FormatTime(hour, minute int) string {
 	return time.Date(0, 0, 0, hour, minute, 0, 0, time.Local).Format("15:04")
 }
+
+// ShowtimeCinemaId lấy rạp của suất chiếu qua phòng chiếu
+func ShowtimeCinemaId(db *gorm.DB, showtime model.Showtime) uint {
+	if showtime.Room.CinemaId != 0 {
+		return showtime.Room.CinemaId
+	}
+	var room model.Room
+	if err := db.Select("id", "cinema_id").First(&room, showtime.RoomId).Error; err != nil {
+		return 0
+	}
+	return room.CinemaId
+}
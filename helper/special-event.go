@@ -0,0 +1,79 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FindSpecialEvents lấy các sự kiện đang bật áp dụng cho ngày date tại rạp/phim.
+// cinemaID = 0 hoặc movieID = 0: chỉ lấy sự kiện không giới hạn theo rạp/phim đó.
+func FindSpecialEvents(db *gorm.DB, date time.Time, cinemaID, movieID uint) []model.SpecialEvent {
+	day := date.Format("2006-01-02")
+	query := db.Where("is_active = ? AND start_date <= ? AND end_date >= ?", true, day, day)
+
+	if cinemaID != 0 {
+		var cinema model.Cinema
+		chainID := uint(0)
+		if err := db.Select("id", "chain_id").First(&cinema, cinemaID).Error; err == nil {
+			chainID = cinema.ChainId
+		}
+		query = query.Where("(cinema_id IS NULL OR cinema_id = ?) AND (chain_id IS NULL OR chain_id = ?)", cinemaID, chainID)
+	} else {
+		query = query.Where("cinema_id IS NULL AND chain_id IS NULL")
+	}
+	if movieID != 0 {
+		query = query.Where("movie_id IS NULL OR movie_id = ?", movieID)
+	} else {
+		query = query.Where("movie_id IS NULL")
+	}
+
+	var events []model.SpecialEvent
+	query.Order("start_date, id").Find(&events)
+	return events
+}
+
+// ApplyEventPricing áp các điều chỉnh giá của sự kiện: cộng dồn phần trăm rồi cộng số tiền cố định
+func ApplyEventPricing(price float64, events []model.SpecialEvent) float64 {
+	percent, amount := 0.0, 0.0
+	for _, event := range events {
+		percent += event.PricePercent
+		amount += event.PriceAmount
+	}
+	price = price*(1+percent/100) + amount
+	if price < 0 {
+		return 0
+	}
+	return price
+}
+
+// GetShowtimeEvents lấy sự kiện đặc biệt áp dụng cho suất chiếu (theo rạp của phòng, phim và ngày chiếu)
+func GetShowtimeEvents(db *gorm.DB, showtime model.Showtime) []model.SpecialEvent {
+	cinemaID := ShowtimeCinemaId(db, showtime)
+	date := showtime.StartTime.In(time.FixedZone("ICT", 7*3600))
+	return FindSpecialEvents(db, date, cinemaID, showtime.MovieId)
+}
+
+// FindNonRefundableEvent trả về sự kiện khiến vé của suất chiếu không được hoàn (nil nếu không có)
+func FindNonRefundableEvent(db *gorm.DB, showtime model.Showtime) *model.SpecialEvent {
+	for _, event := range GetShowtimeEvents(db, showtime) {
+		if event.NonRefundable {
+			return &event
+		}
+	}
+	return nil
+}
+
+// EventTags gom nhãn hiển thị của các sự kiện (không trùng)
+func EventTags(events []model.SpecialEvent) []string {
+	tags := []string{}
+	for _, event := range events {
+		for _, tag := range event.Tags {
+			if !Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
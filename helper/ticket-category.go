@@ -0,0 +1,110 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// TicketCategoryAllows: loại vé có áp dụng cho phim có phân loại độ tuổi rating không
+func TicketCategoryAllows(category model.TicketCategory, rating string) bool {
+	if category.AgeRatings == "" {
+		return true
+	}
+	for _, item := range strings.Split(category.AgeRatings, ",") {
+		if strings.TrimSpace(item) == rating {
+			return true
+		}
+	}
+	return false
+}
+
+// TicketCategoryPrice: giá một ghế theo loại vé; category nil tính giá thường
+func TicketCategoryPrice(category *model.TicketCategory, basePrice, modifier float64) float64 {
+	if category == nil {
+		return basePrice * modifier
+	}
+	if category.FixedPrice != nil {
+		return *category.FixedPrice * modifier
+	}
+	return basePrice * modifier * (100 - category.DiscountPercent) / 100
+}
+
+// ResolveSeatCategories kiểm tra loại vé khách chọn cho các ghế seatIds và trả về map theo seat_id.
+// Ghế chọn ADULT hoặc không khai báo không có trong map (tính giá thường).
+func ResolveSeatCategories(db *gorm.DB, movie model.Movie, seatIds []uint, inputs []model.SeatCategoryInput) (map[uint]model.TicketCategory, error) {
+	result := make(map[uint]model.TicketCategory)
+	if len(inputs) == 0 {
+		return result, nil
+	}
+	selected := make(map[uint]bool, len(seatIds))
+	for _, id := range seatIds {
+		selected[id] = true
+	}
+	codes := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		codes = append(codes, input.CategoryCode)
+	}
+	var categories []model.TicketCategory
+	if err := db.Where("code IN ? AND is_active = ?", codes, true).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]model.TicketCategory, len(categories))
+	for _, category := range categories {
+		byCode[category.Code] = category
+	}
+
+	seen := make(map[uint]bool, len(inputs))
+	for _, input := range inputs {
+		if !selected[input.SeatId] {
+			return nil, fmt.Errorf("ghế %d không nằm trong danh sách ghế thanh toán", input.SeatId)
+		}
+		if seen[input.SeatId] {
+			return nil, fmt.Errorf("ghế %d được chọn loại vé nhiều lần", input.SeatId)
+		}
+		seen[input.SeatId] = true
+		if input.CategoryCode == model.TicketCategoryAdult {
+			continue
+		}
+		category, ok := byCode[input.CategoryCode]
+		if !ok {
+			return nil, fmt.Errorf("loại vé %s không tồn tại hoặc đã ngừng áp dụng", input.CategoryCode)
+		}
+		if !TicketCategoryAllows(category, movie.AgeRestriction) {
+			return nil, fmt.Errorf("loại vé %s không áp dụng cho phim phân loại %s", category.Name, movie.AgeRestriction)
+		}
+		result[input.SeatId] = category
+	}
+	return result, nil
+}
+
+// SeatCategory: loại vé của ghế trong map, nil nếu tính giá thường
+func SeatCategory(categories map[uint]model.TicketCategory, seatId uint) *model.TicketCategory {
+	if category, ok := categories[seatId]; ok {
+		return &category
+	}
+	return nil
+}
+
+// ApplyTicketCategory ghi loại vé lên vé để in và soát vé
+func ApplyTicketCategory(ticket *model.Ticket, category *model.TicketCategory) {
+	if category == nil {
+		ticket.CategoryCode = model.TicketCategoryAdult
+		return
+	}
+	ticket.CategoryCode = category.Code
+	ticket.CategoryName = category.Name
+	ticket.RequiresIdCheck = category.RequiresIdCheck
+}
+
+// TicketSeatLabel: nhãn ghế in trên vé, kèm tên loại vé nếu không phải vé thường
+func TicketSeatLabel(ticket model.Ticket) string {
+	seat := ticket.ShowtimeSeat.Seat
+	label := fmt.Sprintf("%s%d", seat.Row, seat.Column)
+	if ticket.CategoryName != "" {
+		label += " (" + ticket.CategoryName + ")"
+	}
+	return label
+}
@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"time"
+)
+
+// ShowtimeCheckinEnd: hạn check-in = giờ chiếu + thời lượng phim + 15 phút dọn phòng
+func ShowtimeCheckinEnd(showtime model.Showtime) time.Time {
+	return showtime.StartTime.Add(time.Duration(showtime.Movie.Duration+15) * time.Minute)
+}
+
+// OrderQRContent: nội dung QR check-in đã ký của đơn; ticketCodes rỗng = mọi vé chưa hủy của đơn.
+// showtime cần nạp kèm Movie để tính hạn dùng.
+func OrderQRContent(order model.Order, showtime model.Showtime, ticketCodes []string) (string, error) {
+	if len(ticketCodes) == 0 {
+		for _, ticket := range order.Tickets {
+			if ticket.Status != "CANCELLED" {
+				ticketCodes = append(ticketCodes, ticket.TicketCode)
+			}
+		}
+	}
+	return utils.SignQRPayload(utils.QRTicketPayload{
+		OrderCode:    order.PublicCode,
+		Tickets:      ticketCodes,
+		ShowtimeCode: showtime.PublicCode,
+		StartTime:    showtime.StartTime.Unix(),
+		ExpiresAt:    ShowtimeCheckinEnd(showtime).Unix(),
+	})
+}
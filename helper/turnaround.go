@@ -0,0 +1,137 @@
+package helper
+
+import (
+	"cinema_manager/model"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultOpenTime  = "08:00"
+	DefaultCloseTime = "00:00" // nửa đêm
+)
+
+// Thời gian dọn phòng / quảng cáo mặc định (phút) khi chưa cấu hình cho loại phòng
+var defaultTurnaround = map[model.RoomType][2]int{
+	model.Small:  {10, 10},
+	model.Medium: {15, 10},
+	model.Large:  {20, 10},
+	model.IMAX:   {20, 15},
+	model.FourDX: {25, 10},
+}
+
+type Turnaround struct {
+	Cleaning time.Duration
+	Ads      time.Duration
+}
+
+type OperatingWindow struct {
+	Open  time.Time
+	Close time.Time
+}
+
+var weekdayNames = [...]string{"Chủ nhật", "Thứ 2", "Thứ 3", "Thứ 4", "Thứ 5", "Thứ 6", "Thứ 7"}
+
+func WeekdayName(d time.Weekday) string {
+	return weekdayNames[d]
+}
+
+// DefaultRoomTurnaround trả về cấu hình mặc định của loại phòng
+func DefaultRoomTurnaround(roomType model.RoomType) model.RoomTurnaround {
+	values, ok := defaultTurnaround[roomType]
+	if !ok {
+		values = defaultTurnaround[model.Medium]
+	}
+	return model.RoomTurnaround{RoomType: roomType, CleaningMinutes: values[0], AdMinutes: values[1]}
+}
+
+// GetRoomTurnaround lấy thời gian dọn phòng và quảng cáo của loại phòng
+func GetRoomTurnaround(db *gorm.DB, roomType model.RoomType) Turnaround {
+	config := DefaultRoomTurnaround(roomType)
+	var saved model.RoomTurnaround
+	if err := db.Where("room_type = ?", roomType).First(&saved).Error; err == nil {
+		config = saved
+	}
+	return Turnaround{
+		Cleaning: time.Duration(config.CleaningMinutes) * time.Minute,
+		Ads:      time.Duration(config.AdMinutes) * time.Minute,
+	}
+}
+
+// ShowtimeEndTime: giờ kết thúc = giờ bắt đầu + quảng cáo + thời lượng phim
+func ShowtimeEndTime(start time.Time, movieDuration int, t Turnaround) time.Time {
+	return start.Add(t.Ads + time.Duration(movieDuration)*time.Minute)
+}
+
+// GetOperatingWindow trả về giờ mở/đóng cửa của rạp cho ngày (theo giờ của date)
+func GetOperatingWindow(db *gorm.DB, cinemaID uint, date time.Time) OperatingWindow {
+	openStr, closeStr := DefaultOpenTime, DefaultCloseTime
+	var hour model.CinemaOperatingHour
+	if err := db.Where("cinema_id = ? AND weekday = ?", cinemaID, int(date.Weekday())).First(&hour).Error; err == nil {
+		openStr, closeStr = hour.OpenTime, hour.CloseTime
+	}
+	openAt, _ := ParseClockOffset(openStr)
+	closeAt, _ := ParseClockOffset(closeStr)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return OperatingWindow{Open: day.Add(openAt), Close: CloseAfterOpen(day.Add(openAt), day.Add(closeAt))}
+}
+
+// windowFor tìm khung giờ hoạt động chứa giờ bắt đầu (suất sau nửa đêm thuộc ngày hôm trước)
+func windowFor(db *gorm.DB, cinemaID uint, start time.Time) OperatingWindow {
+	window := GetOperatingWindow(db, cinemaID, start)
+	if start.Before(window.Open) {
+		prev := GetOperatingWindow(db, cinemaID, start.AddDate(0, 0, -1))
+		if start.Before(prev.Close) {
+			return prev
+		}
+	}
+	return window
+}
+
+// CheckShowtimeSlot kiểm tra suất chiếu nằm trong giờ hoạt động của rạp và không trùng
+// với suất khác trong phòng (tính cả thời gian dọn phòng). Lỗi trả về nêu rõ lý do.
+func CheckShowtimeSlot(db *gorm.DB, room model.Room, start, end time.Time, excludeID uint) error {
+	loc := time.FixedZone("ICT", 7*3600)
+	start, end = start.In(loc), end.In(loc)
+
+	window := windowFor(db, room.CinemaId, start)
+	if start.Before(window.Open) {
+		return fmt.Errorf("Suất %s bắt đầu trước giờ mở cửa %s (%s) của rạp",
+			start.Format("02/01 15:04"), window.Open.Format("15:04"), WeekdayName(window.Open.Weekday()))
+	}
+	if end.After(window.Close) {
+		return fmt.Errorf("Suất %s kết thúc lúc %s, sau giờ đóng cửa %s (%s) của rạp",
+			start.Format("02/01 15:04"), end.Format("15:04"), window.Close.Format("15:04"), WeekdayName(window.Open.Weekday()))
+	}
+
+	turnaround := GetRoomTurnaround(db, room.Type)
+	var existing model.Showtime
+	query := db.Preload("Movie").
+		Where("room_id = ? AND start_time < ? AND end_time > ?", room.ID, end.Add(turnaround.Cleaning), start.Add(-turnaround.Cleaning)).
+		Where("status <> ?", "CANCELLED")
+	if excludeID != 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	if err := query.Order("start_time").First(&existing).Error; err != nil {
+		return nil
+	}
+
+	existingStart, existingEnd := existing.StartTime.In(loc), existing.EndTime.In(loc)
+	cleaning := int(turnaround.Cleaning.Minutes())
+	if start.Before(existingEnd) && end.After(existingStart) {
+		return fmt.Errorf("Phòng %s: suất %s–%s trùng với suất \"%s\" %s–%s",
+			room.Name, start.Format("02/01 15:04"), end.Format("15:04"),
+			existing.Movie.Title, existingStart.Format("15:04"), existingEnd.Format("15:04"))
+	}
+	if !start.Before(existingEnd) {
+		return fmt.Errorf("Phòng %s: suất %s bắt đầu chỉ %d phút sau suất \"%s\" kết thúc lúc %s, cần %d phút dọn phòng (sớm nhất %s)",
+			room.Name, start.Format("02/01 15:04"), int(start.Sub(existingEnd).Minutes()),
+			existing.Movie.Title, existingEnd.Format("15:04"), cleaning,
+			existingEnd.Add(turnaround.Cleaning).Format("15:04"))
+	}
+	return fmt.Errorf("Phòng %s: suất %s kết thúc lúc %s, chỉ %d phút trước suất \"%s\" bắt đầu lúc %s, cần %d phút dọn phòng",
+		room.Name, start.Format("02/01 15:04"), end.Format("15:04"), int(existingStart.Sub(end).Minutes()),
+		existing.Movie.Title, existingStart.Format("15:04"), cleaning)
+}
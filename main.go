@@ -21,6 +21,8 @@ func main() {
 	defer helper.StopShowtimeScheduler()
 	handler.StartExpireSeatWorker()
 	helper.StartMovieStatusScheduler()
+	helper.StartLoyaltyScheduler()
+	helper.StartMembershipScheduler()
 	go func() {
 		ticker := time.NewTicker(1 * time.Minute) // Chạy mỗi 1 phút
 		defer ticker.Stop()
@@ -39,6 +41,10 @@ func main() {
 		MaxAge:           600,
 	}))
 
+	// Khóa ký QR vé phải cố định giữa các lần khởi động (QR đã gửi khách, máy soát vé offline)
+	if err := utils.InitQRSigningKey(); err != nil {
+		log.Fatalf("Không thể khởi động: %v", err)
+	}
 	database.ConnectDB()
 
 	router.SetupRoutes(app)
@@ -0,0 +1,35 @@
+package model
+
+const (
+	// AgeCheckOverride: nhân viên cho khách vào dù ngày sinh trong hồ sơ chưa đủ tuổi (đã xem giấy tờ)
+	AgeCheckOverride = "OVERRIDE"
+	// AgeCheckDenied: nhân viên từ chối cho vào vì khách chưa đủ tuổi
+	AgeCheckDenied = "DENIED"
+)
+
+// AgeCheckLog: nhật ký xử lý độ tuổi khi soát vé phim T13/T16/T18, ghi kèm tài khoản nhân viên
+type AgeCheckLog struct {
+	DTO
+	OrderId     uint   `gorm:"not null;index" json:"orderId"`
+	ShowtimeId  uint   `gorm:"index" json:"showtimeId"`
+	AccountId   uint   `gorm:"not null;index" json:"accountId"`
+	Rating      string `gorm:"size:5" json:"rating"`
+	MinimumAge  int    `json:"minimumAge"`
+	CustomerAge *int   `json:"customerAge"` // tuổi theo ngày sinh trong hồ sơ, nil nếu không có
+	Action      string `gorm:"size:20;not null" json:"action"`
+	Note        string `gorm:"size:500" json:"note"`
+}
+
+// UpdateDateOfBirthInput: khách tự khai ngày sinh (chỉ một lần, thay đổi sau đó qua quầy / admin)
+type UpdateDateOfBirthInput struct {
+	DateOfBirth string `json:"dateOfBirth" validate:"required,datetime=2006-01-02"`
+}
+
+type FilterAgeCheckLogInput struct {
+	Pagination
+	CinemaId  uint   `query:"cinemaId"`
+	AccountId uint   `query:"accountId"`
+	Action    string `query:"action" validate:"omitempty,oneof=OVERRIDE DENIED"`
+	From      string `query:"from"`
+	To        string `query:"to"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+const (
+	CashSessionOpen   = "OPEN"
+	CashSessionClosed = "CLOSED"
+
+	CashMovementSale   = "SALE"   // thu tiền mặt khi bán vé tại quầy
+	CashMovementRefund = "REFUND" // trả tiền mặt cho khách khi hủy đơn
+	CashMovementPayout = "PAYOUT" // chi tiền từ két (tạm ứng, chi lặt vặt...)
+)
+
+// CashDrawerSession: ca thu ngân của nhân viên bán vé. Mở ca với tiền đầu ca (OpeningFloat),
+// mọi khoản thu/chi tiền mặt trong ca được ghi vào CashMovement, đóng ca với số tiền đếm thực tế.
+// ExpectedAmount = OpeningFloat + CashSales - CashRefunds - Payouts; Variance = CountedAmount - ExpectedAmount.
+type CashDrawerSession struct {
+	DTO
+	CinemaId       uint       `gorm:"not null;index" json:"cinemaId"`
+	AccountId      uint       `gorm:"not null;index" json:"accountId"`
+	Status         string     `gorm:"size:10;not null;index" json:"status"` // OPEN / CLOSED
+	OpenedAt       time.Time  `json:"openedAt"`
+	OpeningFloat   float64    `json:"openingFloat"`
+	CashSales      float64    `json:"cashSales"`
+	CashRefunds    float64    `json:"cashRefunds"`
+	Payouts        float64    `json:"payouts"`
+	ExpectedAmount float64    `json:"expectedAmount"`
+	CountedAmount  *float64   `json:"countedAmount"`
+	Variance       *float64   `json:"variance"` // âm: thiếu tiền, dương: thừa tiền
+	ClosedAt       *time.Time `json:"closedAt"`
+	OpenNote       string     `gorm:"type:text" json:"openNote"`
+	CloseNote      string     `gorm:"type:text" json:"closeNote"`
+
+	Movements []CashMovement `gorm:"foreignKey:SessionId" json:"movements,omitempty"`
+}
+
+// CashMovement: một khoản tiền mặt vào/ra két trong ca; Amount mang dấu (+ thu, - chi)
+type CashMovement struct {
+	DTO
+	SessionId uint    `gorm:"not null;index" json:"sessionId"`
+	Type      string  `gorm:"size:10;not null;index" json:"type"` // SALE / REFUND / PAYOUT
+	Amount    float64 `gorm:"not null" json:"amount"`
+	OrderId   *uint   `gorm:"index" json:"orderId,omitempty"`
+	Note      string  `gorm:"type:text" json:"note"`
+	CreatedBy uint    `json:"createdBy"`
+}
+
+type OpenCashDrawerInput struct {
+	OpeningFloat float64 `json:"openingFloat" validate:"gte=0"`
+	Note         string  `json:"note" validate:"max=500"`
+}
+
+type CashPayoutInput struct {
+	Amount float64 `json:"amount" validate:"required,gt=0"`
+	Note   string  `json:"note" validate:"required,max=500"` // lý do chi
+}
+
+type CloseCashDrawerInput struct {
+	CountedAmount *float64 `json:"countedAmount" validate:"required,gte=0"`
+	Note          string   `json:"note" validate:"max=500"`
+}
+
+type FilterCashDrawerInput struct {
+	Pagination
+	CinemaId  uint   `query:"cinemaId"`
+	AccountId uint   `query:"accountId"`
+	Status    string `query:"status" validate:"omitempty,oneof=OPEN CLOSED"`
+	From      string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To        string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	// Chỉ lấy ca lệch tiền
+	VarianceOnly bool `query:"varianceOnly"`
+}
@@ -0,0 +1,12 @@
+package model
+
+// ManualCheckinLog: nhật ký check-in bằng mã đơn nhập tay (không có QR có chữ ký),
+// chỉ cho phép khi nhân viên bật ManualEntry và ghi lý do (QR hỏng, khách mất điện thoại...)
+type ManualCheckinLog struct {
+	DTO
+	OrderId    uint   `gorm:"not null;index" json:"orderId"`
+	ShowtimeId uint   `gorm:"index" json:"showtimeId"`
+	AccountId  uint   `gorm:"not null;index" json:"accountId"`
+	Code       string `gorm:"size:100" json:"code"`
+	Reason     string `gorm:"size:500;not null" json:"reason"`
+}
@@ -0,0 +1,104 @@
+package model
+
+import "time"
+
+const (
+	CompReasonPartner         = "PARTNER"          // đối tác
+	CompReasonStaff           = "STAFF"            // phúc lợi nhân viên
+	CompReasonPromotion       = "PROMOTION"        // khuyến mãi, minigame
+	CompReasonServiceRecovery = "SERVICE_RECOVERY" // đền bù sự cố phục vụ
+	CompReasonPress           = "PRESS"            // báo chí, truyền thông
+
+	CompRequestPending  = "PENDING"
+	CompRequestApproved = "APPROVED"
+	CompRequestRejected = "REJECTED"
+	CompRequestIssued   = "ISSUED"
+
+	// PaymentMethodComp: Order.PaymentMethod của đơn vé mời
+	PaymentMethodComp = "COMP"
+
+	DefaultCompMonthlyQuota      = 100 // số vé mời tối đa mỗi tháng của một rạp
+	DefaultCompApprovalThreshold = 2   // xuất quá số vé này trong một lần cần quản lý duyệt
+)
+
+// CompPolicy: hạn mức vé mời của rạp; rạp chưa cấu hình dùng giá trị mặc định
+type CompPolicy struct {
+	DTO
+	CinemaId          uint `gorm:"not null;uniqueIndex" json:"cinemaId"`
+	MonthlyQuota      int  `gorm:"not null" json:"monthlyQuota"`
+	ApprovalThreshold int  `gorm:"not null" json:"approvalThreshold"`
+}
+
+// CompTicketRequest: yêu cầu xuất vé mời vượt ngưỡng, chờ quản lý rạp duyệt.
+// Sau khi được duyệt, nhân viên giữ ghế và xuất vé theo yêu cầu (RequestId) trước giờ chiếu.
+type CompTicketRequest struct {
+	DTO
+	CinemaId       uint       `gorm:"not null;index" json:"cinemaId"`
+	ShowtimeId     uint       `gorm:"not null;index" json:"showtimeId"`
+	Quantity       int        `gorm:"not null" json:"quantity"`
+	ReasonCode     string     `gorm:"size:30;not null" json:"reasonCode"`
+	Note           string     `gorm:"type:text" json:"note"`
+	RecipientName  string     `gorm:"size:100" json:"recipientName"`
+	RecipientPhone string     `gorm:"size:20" json:"recipientPhone"`
+	Status         string     `gorm:"size:20;not null;index" json:"status"` // PENDING / APPROVED / REJECTED / ISSUED
+	RequestedBy    uint       `json:"requestedBy"`
+	ReviewedBy     *uint      `json:"reviewedBy"`
+	ReviewedAt     *time.Time `json:"reviewedAt"`
+	ReviewNote     string     `gorm:"type:text" json:"reviewNote"`
+	OrderId        *uint      `json:"orderId"`
+
+	Showtime *Showtime `gorm:"foreignKey:ShowtimeId" json:"showtime,omitempty"`
+}
+
+type UpdateCompPolicyInput struct {
+	MonthlyQuota      *int `json:"monthlyQuota" validate:"omitempty,gte=0"`
+	ApprovalThreshold *int `json:"approvalThreshold" validate:"omitempty,gte=0"`
+}
+
+type CreateCompRequestInput struct {
+	ShowtimeCode   string `json:"showtimeCode" validate:"required"`
+	Quantity       int    `json:"quantity" validate:"required,min=1,max=100"`
+	ReasonCode     string `json:"reasonCode" validate:"required,oneof=PARTNER STAFF PROMOTION SERVICE_RECOVERY PRESS"`
+	Note           string `json:"note" validate:"required,max=500"`
+	RecipientName  string `json:"recipientName" validate:"required,max=100"`
+	RecipientPhone string `json:"recipientPhone" validate:"omitempty,max=20"`
+}
+
+type ReviewCompRequestInput struct {
+	Approve *bool  `json:"approve" validate:"required"`
+	Note    string `json:"note" validate:"max=500"`
+}
+
+// IssueCompTicketInput: xuất vé mời cho các ghế nhân viên đang giữ; vượt ngưỡng phải kèm yêu cầu đã duyệt
+type IssueCompTicketInput struct {
+	SeatIds        []uint `json:"seatIds" validate:"required,min=1"`
+	ReasonCode     string `json:"reasonCode" validate:"omitempty,oneof=PARTNER STAFF PROMOTION SERVICE_RECOVERY PRESS"`
+	Note           string `json:"note" validate:"max=500"`
+	RecipientName  string `json:"recipientName" validate:"max=100"`
+	RecipientPhone string `json:"recipientPhone" validate:"omitempty,max=20"`
+	RequestId      *uint  `json:"requestId"`
+}
+
+type FilterCompRequestInput struct {
+	Pagination
+	CinemaId uint   `query:"cinemaId"`
+	Status   string `query:"status" validate:"omitempty,oneof=PENDING APPROVED REJECTED ISSUED"`
+}
+
+// CompUsageByReason: số vé mời và giá trị theo giá bán của một lý do trong tháng
+type CompUsageByReason struct {
+	ReasonCode string  `json:"reasonCode"`
+	Tickets    int     `json:"tickets"`
+	FaceValue  float64 `json:"faceValue"`
+}
+
+// CompUsage: tình hình sử dụng hạn mức vé mời của rạp trong tháng
+type CompUsage struct {
+	CinemaId          uint                `json:"cinemaId"`
+	Month             string              `json:"month"` // 2006-01
+	MonthlyQuota      int                 `json:"monthlyQuota"`
+	ApprovalThreshold int                 `json:"approvalThreshold"`
+	Used              int                 `json:"used"`
+	Remaining         int                 `json:"remaining"`
+	ByReason          []CompUsageByReason `json:"byReason"`
+}
@@ -0,0 +1,93 @@
+package model
+
+const (
+	ConcessionPopcorn = "POPCORN"
+	ConcessionDrink   = "DRINK"
+	ConcessionSnack   = "SNACK"
+	ConcessionCombo   = "COMBO"
+)
+
+// ConcessionProduct: sản phẩm bắp nước trong danh mục của một rạp. Sản phẩm loại COMBO gồm
+// nhiều sản phẩm lẻ (ComboItems) nhưng bán theo giá riêng của combo.
+type ConcessionProduct struct {
+	DTO
+	CinemaId    uint    `gorm:"not null;index;uniqueIndex:idx_concession_cinema_code" json:"cinemaId"`
+	Code        string  `gorm:"size:30;not null;uniqueIndex:idx_concession_cinema_code" json:"code"`
+	Name        string  `gorm:"size:150;not null" json:"name"`
+	Category    string  `gorm:"size:20;not null;index" json:"category"` // POPCORN / DRINK / SNACK / COMBO
+	Description string  `gorm:"type:text" json:"description"`
+	ImageUrl    string  `gorm:"size:255" json:"imageUrl"`
+	Price       float64 `gorm:"not null" json:"price"`
+	IsAvailable bool    `gorm:"not null" json:"isAvailable"` // tạm hết hàng / ngừng bán; mặc định true gán ở validate (default:true làm GORM đổi false thành true khi Create)
+	SortOrder   int     `gorm:"default:0" json:"sortOrder"`
+
+	ComboItems []ConcessionComboItem `gorm:"foreignKey:ComboId;constraint:OnDelete:CASCADE" json:"comboItems,omitempty"`
+	Cinema     *Cinema               `gorm:"foreignKey:CinemaId" json:"cinema,omitempty"`
+}
+
+// ConcessionComboItem: một thành phần của combo
+type ConcessionComboItem struct {
+	ID        uint `gorm:"primaryKey" json:"id"`
+	ComboId   uint `gorm:"not null;index" json:"comboId"`
+	ProductId uint `gorm:"not null" json:"productId"`
+	Quantity  int  `gorm:"not null;default:1" json:"quantity"`
+
+	Product *ConcessionProduct `gorm:"foreignKey:ProductId" json:"product,omitempty"`
+}
+
+// OrderConcession: dòng bắp nước trong đơn hàng, lưu lại tên/loại/giá tại thời điểm bán
+type OrderConcession struct {
+	DTO
+	OrderId   uint    `gorm:"not null;index" json:"orderId"`
+	ProductId uint    `gorm:"not null;index" json:"productId"`
+	Name      string  `gorm:"size:150" json:"name"`
+	Category  string  `gorm:"size:20;index" json:"category"`
+	Quantity  int     `gorm:"not null" json:"quantity"`
+	UnitPrice float64 `gorm:"not null" json:"unitPrice"`
+	Amount    float64 `gorm:"not null" json:"amount"`
+	Status    string  `gorm:"size:20;not null;default:'ACTIVE'" json:"status"` // ACTIVE / CANCELLED
+}
+
+type ConcessionComboItemInput struct {
+	ProductId uint `json:"productId" validate:"required"`
+	Quantity  int  `json:"quantity" validate:"required,min=1,max=20"`
+}
+
+type CreateConcessionProductInput struct {
+	CinemaId    uint                       `json:"cinemaId" validate:"required"`
+	Code        string                     `json:"code" validate:"required,min=2,max=30"`
+	Name        string                     `json:"name" validate:"required,min=2,max=150"`
+	Category    string                     `json:"category" validate:"required,oneof=POPCORN DRINK SNACK COMBO"`
+	Description string                     `json:"description"`
+	ImageUrl    string                     `json:"imageUrl" validate:"omitempty,url"`
+	Price       float64                    `json:"price" validate:"gte=0"`
+	IsAvailable *bool                      `json:"isAvailable"`
+	SortOrder   int                        `json:"sortOrder"`
+	ComboItems  []ConcessionComboItemInput `json:"comboItems" validate:"omitempty,dive"`
+}
+
+type UpdateConcessionProductInput struct {
+	Code        *string                    `json:"code" validate:"omitempty,min=2,max=30"`
+	Name        *string                    `json:"name" validate:"omitempty,min=2,max=150"`
+	Category    *string                    `json:"category" validate:"omitempty,oneof=POPCORN DRINK SNACK COMBO"`
+	Description *string                    `json:"description"`
+	ImageUrl    *string                    `json:"imageUrl" validate:"omitempty,url"`
+	Price       *float64                   `json:"price" validate:"omitempty,gte=0"`
+	IsAvailable *bool                      `json:"isAvailable"`
+	SortOrder   *int                       `json:"sortOrder"`
+	ComboItems  []ConcessionComboItemInput `json:"comboItems" validate:"omitempty,dive"`
+}
+
+type FilterConcessionProductInput struct {
+	Pagination
+	CinemaId    uint   `query:"cinemaId"`
+	Category    string `query:"category" validate:"omitempty,oneof=POPCORN DRINK SNACK COMBO"`
+	SearchKey   string `query:"searchKey"`
+	IsAvailable *bool  `query:"isAvailable"`
+}
+
+// ConcessionLineInput: dòng bắp nước khách chọn khi thanh toán
+type ConcessionLineInput struct {
+	ProductId uint `json:"productId" validate:"required"`
+	Quantity  int  `json:"quantity" validate:"required,min=1,max=50"`
+}
@@ -16,6 +16,24 @@ type Customer struct {
 	Gender    *bool   `json:"gender"`
 
 	IsActive bool `gorm:"default:true" json:"isActive"`
+
+	// Token bí mật của đường dẫn lịch .ics vé đã đặt (đổi token = thu hồi đường dẫn cũ)
+	CalendarToken *string `gorm:"size:64;uniqueIndex" json:"-"`
+
+	// Số dư điểm thành viên, luôn bằng tổng sổ điểm LoyaltyTransaction
+	LoyaltyPoints int `gorm:"default:0" json:"loyaltyPoints"`
+
+	// Số dư ví trả trước, luôn bằng tổng sổ cái StoredValueTransaction của khách
+	WalletBalance float64 `gorm:"default:0" json:"walletBalance"`
+
+	// Ngày sinh, dùng phát voucher sinh nhật và kiểm tra phim giới hạn độ tuổi
+	DateOfBirth *time.Time `gorm:"type:date" json:"dateOfBirth"`
+
+	// Hạng thành viên; TierLocked = true khi admin gán tay, job hằng đêm bỏ qua
+	TierId          *uint           `gorm:"index" json:"tierId"`
+	TierLocked      bool            `gorm:"default:false" json:"tierLocked"`
+	TierEvaluatedAt *time.Time      `json:"tierEvaluatedAt"`
+	Tier            *MembershipTier `gorm:"foreignKey:TierId" json:"tier,omitempty"`
 }
 
 type Customers []Customer
@@ -25,6 +43,8 @@ type RegisterCustomerInput struct {
 	Email    string `validate:"required,email" json:"email"`
 	Phone    string `validate:"required" json:"phone"`
 	Password string `validate:"required" json:"password"`
+	// Ngày sinh (không bắt buộc), dùng kiểm tra phim giới hạn độ tuổi
+	Birthday *string `json:"dateOfBirth" validate:"omitempty,datetime=2006-01-02"`
 }
 
 type EditCustomerInput struct {
@@ -33,6 +53,7 @@ type EditCustomerInput struct {
 	PhoneNumber *string `json:"phoneNumber"`
 	Gender      *bool   `json:"gender"`
 	AvatarUrl   *string `json:"avatarUrl"`
+	Birthday    *string `json:"dateOfBirth" validate:"omitempty,datetime=2006-01-02"`
 }
 type CustomerChangePassword struct {
 	CurrentPassword string `json:"currentPassword"`
@@ -0,0 +1,141 @@
+package model
+
+import "time"
+
+// Distributor: nhà phát hành phim, nhận phần chia doanh thu theo hợp đồng
+type Distributor struct {
+	DTO
+	Name        string `gorm:"size:150;not null;uniqueIndex" json:"name"`
+	TaxCode     string `gorm:"size:20" json:"taxCode"`
+	ContactName string `gorm:"size:100" json:"contactName"`
+	Email       string `gorm:"size:100" json:"email"`
+	Phone       string `gorm:"size:20" json:"phone"`
+	Address     string `gorm:"size:255" json:"address"`
+	IsActive    bool   `gorm:"default:true" json:"isActive"`
+}
+
+// RevenueShareTier: từ tuần công chiếu FromWeek (tuần 1 = 7 ngày đầu kể từ ngày khởi chiếu) nhà phát hành
+// nhận Percent % doanh thu, áp dụng đến bậc kế tiếp
+type RevenueShareTier struct {
+	FromWeek int     `json:"fromWeek" validate:"required,min=1"`
+	Percent  float64 `json:"percent" validate:"required,gt=0,lte=100"`
+}
+
+// FilmContract: hợp đồng phát hành một phim, ChainId để trống nghĩa là áp dụng cho mọi chuỗi rạp.
+// Phim đã có hợp đồng chỉ được xếp suất trong thời hạn của một hợp đồng đang hiệu lực.
+type FilmContract struct {
+	DTO
+	Code               string             `gorm:"size:50;not null;uniqueIndex" json:"code"`
+	DistributorId      uint               `gorm:"not null;index" json:"distributorId"`
+	MovieId            uint               `gorm:"not null;index" json:"movieId"`
+	ChainId            *uint              `gorm:"index" json:"chainId"`
+	StartDate          time.Time          `gorm:"type:date;not null" json:"startDate"`
+	EndDate            time.Time          `gorm:"type:date;not null" json:"endDate"`
+	MinShowtimesPerDay int                `gorm:"default:0" json:"minShowtimesPerDay"` // mỗi rạp, 0 = không ràng buộc
+	RevenueShareTiers  []RevenueShareTier `gorm:"type:json;serializer:json" json:"revenueShareTiers"`
+	Status             string             `gorm:"size:20;not null;default:'active'" json:"status"` // active / terminated
+	Note               string             `gorm:"type:text" json:"note"`
+	CreatedBy          uint               `json:"createdBy"`
+
+	Distributor *Distributor `gorm:"foreignKey:DistributorId" json:"distributor,omitempty"`
+	Movie       *Movie       `gorm:"foreignKey:MovieId" json:"movie,omitempty"`
+	Chain       *CinemaChain `gorm:"foreignKey:ChainId" json:"chain,omitempty"`
+}
+
+type CreateDistributorInput struct {
+	Name        string `json:"name" validate:"required,min=2,max=150"`
+	TaxCode     string `json:"taxCode" validate:"omitempty,max=20"`
+	ContactName string `json:"contactName" validate:"omitempty,max=100"`
+	Email       string `json:"email" validate:"omitempty,email"`
+	Phone       string `json:"phone" validate:"omitempty,max=20"`
+	Address     string `json:"address" validate:"omitempty,max=255"`
+}
+
+type UpdateDistributorInput struct {
+	Name        *string `json:"name" validate:"omitempty,min=2,max=150"`
+	TaxCode     *string `json:"taxCode" validate:"omitempty,max=20"`
+	ContactName *string `json:"contactName" validate:"omitempty,max=100"`
+	Email       *string `json:"email" validate:"omitempty,email"`
+	Phone       *string `json:"phone" validate:"omitempty,max=20"`
+	Address     *string `json:"address" validate:"omitempty,max=255"`
+	IsActive    *bool   `json:"isActive"`
+}
+
+type FilterDistributorInput struct {
+	Pagination
+	SearchKey string `query:"searchKey"`
+	IsActive  *bool  `query:"isActive"`
+}
+
+type CreateFilmContractInput struct {
+	Code               string             `json:"code" validate:"required,min=3,max=50"`
+	DistributorId      uint               `json:"distributorId" validate:"required"`
+	MovieId            uint               `json:"movieId" validate:"required"`
+	ChainId            *uint              `json:"chainId" validate:"omitempty,min=1"`
+	StartDate          string             `json:"startDate" validate:"required,datetime=2006-01-02"`
+	EndDate            string             `json:"endDate" validate:"required,datetime=2006-01-02"`
+	MinShowtimesPerDay int                `json:"minShowtimesPerDay" validate:"min=0,max=100"`
+	RevenueShareTiers  []RevenueShareTier `json:"revenueShareTiers" validate:"required,min=1,dive"`
+	Note               string             `json:"note"`
+}
+
+type UpdateFilmContractInput struct {
+	Code               *string            `json:"code" validate:"omitempty,min=3,max=50"`
+	DistributorId      *uint              `json:"distributorId" validate:"omitempty,min=1"`
+	ChainId            *uint              `json:"chainId" validate:"omitempty,min=1"`
+	StartDate          *string            `json:"startDate" validate:"omitempty,datetime=2006-01-02"`
+	EndDate            *string            `json:"endDate" validate:"omitempty,datetime=2006-01-02"`
+	MinShowtimesPerDay *int               `json:"minShowtimesPerDay" validate:"omitempty,min=0,max=100"`
+	RevenueShareTiers  []RevenueShareTier `json:"revenueShareTiers" validate:"omitempty,min=1,dive"`
+	Status             *string            `json:"status" validate:"omitempty,oneof=active terminated"`
+	Note               *string            `json:"note"`
+}
+
+type FilterFilmContractInput struct {
+	Pagination
+	DistributorId uint   `query:"distributorId"`
+	MovieId       uint   `query:"movieId"`
+	Status        string `query:"status" validate:"omitempty,oneof=active terminated"`
+}
+
+type FilmSettlementFilter struct {
+	From          string `query:"from" validate:"required,datetime=2006-01-02"`
+	To            string `query:"to" validate:"required,datetime=2006-01-02"`
+	DistributorId uint   `query:"distributorId"`
+	ContractId    uint   `query:"contractId"`
+}
+
+// FilmSettlementWeek: doanh thu và số tiền trả nhà phát hành trong một tuần công chiếu
+type FilmSettlementWeek struct {
+	Week       int     `json:"week"`
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	Orders     int64   `json:"orders"`
+	Revenue    float64 `json:"revenue"`
+	Percent    float64 `json:"percent"`
+	AmountOwed float64 `json:"amountOwed"`
+}
+
+// FilmShortfallDay: ngày rạp chiếu ít hơn số suất tối thiểu theo hợp đồng
+type FilmShortfallDay struct {
+	Date       string `json:"date"`
+	CinemaId   uint   `json:"cinemaId"`
+	CinemaName string `json:"cinemaName"`
+	Showtimes  int64  `json:"showtimes"`
+	Required   int    `json:"required"`
+}
+
+type FilmSettlement struct {
+	ContractId      uint                 `json:"contractId"`
+	ContractCode    string               `json:"contractCode"`
+	DistributorId   uint                 `json:"distributorId"`
+	DistributorName string               `json:"distributorName"`
+	MovieId         uint                 `json:"movieId"`
+	MovieTitle      string               `json:"movieTitle"`
+	From            string               `json:"from"`
+	To              string               `json:"to"`
+	Revenue         float64              `json:"revenue"`
+	AmountOwed      float64              `json:"amountOwed"`
+	Weeks           []FilmSettlementWeek `json:"weeks"`
+	ShortfallDays   []FilmShortfallDay   `json:"shortfallDays"`
+}
@@ -0,0 +1,86 @@
+package model
+
+import "time"
+
+const (
+	GiftCardActive  = "ACTIVE"
+	GiftCardBlocked = "BLOCKED" // admin khóa hoặc nhập sai PIN quá số lần cho phép
+
+	StoredValueIssue  = "ISSUE"  // phát hành thẻ quà tặng
+	StoredValueRedeem = "REDEEM" // thanh toán đơn hàng
+	StoredValueRefund = "REFUND" // hoàn tiền khi hủy đơn
+	StoredValueLoad   = "LOAD"   // chuyển số dư thẻ quà tặng vào ví
+
+	GiftCardBoxOffice = "BOX_OFFICE"
+	GiftCardOnline    = "ONLINE"
+)
+
+// GiftCard: thẻ quà tặng trả trước, dùng mã + PIN để thanh toán hoặc nạp vào ví
+type GiftCard struct {
+	DTO
+	Code           string     `gorm:"size:20;uniqueIndex;not null" json:"code"`
+	PinHash        string     `gorm:"not null" json:"-"`
+	InitialValue   float64    `gorm:"not null" json:"initialValue"`
+	Balance        float64    `gorm:"not null" json:"balance"`
+	Status         string     `gorm:"size:20;default:'ACTIVE';index" json:"status"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+	FailedAttempts int        `gorm:"default:0" json:"-"`
+
+	Channel       string `gorm:"size:20" json:"channel"` // BOX_OFFICE / ONLINE
+	CinemaId      *uint  `json:"cinemaId"`
+	SoldBy        *uint  `json:"soldBy"`
+	BuyerId       *uint  `gorm:"index" json:"buyerId"` // khách mua online
+	BuyerEmail    string `json:"buyerEmail"`
+	PaymentMethod string `json:"paymentMethod"`
+}
+
+// StoredValueTransaction: sổ cái số dư trả trước. Mỗi dòng thuộc đúng một tài khoản:
+// thẻ quà tặng (GiftCardId) hoặc ví khách hàng (CustomerId). Amount mang dấu.
+type StoredValueTransaction struct {
+	DTO
+	GiftCardId   *uint   `gorm:"index" json:"giftCardId,omitempty"`
+	CustomerId   *uint   `gorm:"index" json:"customerId,omitempty"`
+	OrderId      *uint   `gorm:"index" json:"orderId,omitempty"`
+	Type         string  `gorm:"size:20;not null" json:"type"`
+	Amount       float64 `gorm:"not null" json:"amount"`
+	BalanceAfter float64 `json:"balanceAfter"`
+	Note         string  `gorm:"size:255" json:"note"`
+	CreatedBy    *uint   `json:"createdBy,omitempty"`
+}
+
+type SellGiftCardInput struct {
+	Amount        float64 `json:"amount" validate:"required,min=50000,max=10000000"`
+	PaymentMethod string  `json:"paymentMethod" validate:"required"`
+	ExpiryMonths  int     `json:"expiryMonths" validate:"omitempty,min=1,max=60"`
+	BuyerEmail    string  `json:"buyerEmail" validate:"omitempty,email"`
+}
+
+type GiftCardCredentialInput struct {
+	Code string `json:"code" validate:"required"`
+	Pin  string `json:"pin" validate:"required,len=6,numeric"`
+}
+
+// GiftCardPaymentInput: dùng thẻ quà tặng khi thanh toán; Amount = 0 dùng tối đa số dư
+type GiftCardPaymentInput struct {
+	GiftCardCredentialInput
+	Amount float64 `json:"amount" validate:"min=0"`
+}
+
+type UpdateGiftCardStatusInput struct {
+	Status string `json:"status" validate:"required,oneof=ACTIVE BLOCKED"`
+}
+
+type FilterGiftCardInput struct {
+	Pagination
+	SearchKey string `query:"searchKey"`
+	Status    string `query:"status"`
+	CinemaId  uint   `query:"cinemaId"`
+}
+
+// OrderRefundResult: phân bổ tiền hoàn khi hủy đơn
+type OrderRefundResult struct {
+	GiftCard float64      `json:"giftCard"` // hoàn về thẻ quà tặng đã dùng
+	Wallet   float64      `json:"wallet"`   // hoàn về ví
+	Original float64      `json:"original"` // hoàn qua phương thức thanh toán ban đầu
+	Lines    []RefundLine `json:"lines"`
+}
@@ -1,19 +1,39 @@
 package model
 
-import "time"
+import (
+	"cinema_manager/utils"
+	"time"
+)
 
 type Holiday struct {
 	DTO
 	Name        string    `gorm:"size:100;not null" json:"name"`
 	Date        time.Time `gorm:"type:date;not null" json:"date"`
 	Type        string    `gorm:"size:20;not null" json:"type"` // solar / lunar
-	IsRecurring bool      `gorm:"default:true" json:"isRecurring"`
+	IsRecurring bool      `gorm:"not null" json:"isRecurring"`  // mặc định true gán ở validate; không dùng default:true vì GORM đổi false thành true khi Create
+	// Ngày lễ âm lịch lặp lại theo ngày/tháng âm (Date chỉ là ngày dương tham chiếu)
+	LunarMonth int `gorm:"default:0" json:"lunarMonth"`
+	LunarDay   int `gorm:"default:0" json:"lunarDay"`
 }
+
+// FillLunarDate: ngày lễ âm lịch chưa khai báo ngày/tháng âm thì suy ra từ ngày dương tham chiếu
+func (h *Holiday) FillLunarDate() {
+	if h.Type != "lunar" {
+		h.LunarMonth, h.LunarDay = 0, 0
+		return
+	}
+	if h.LunarMonth == 0 || h.LunarDay == 0 {
+		_, h.LunarMonth, h.LunarDay, _ = utils.SolarToLunar(h.Date)
+	}
+}
+
 type CreateHolidayInput struct {
 	Name        string `json:"name" validate:"required,min=2,max=100"`
 	Date        string `json:"date" validate:"required,datetime=2006-01-02"`
 	Type        string `json:"type" validate:"required,oneof=solar lunar"`
 	IsRecurring *bool  `json:"isRecurring" validate:"omitempty"`
+	LunarMonth  *int   `json:"lunarMonth" validate:"omitempty,min=1,max=12"`
+	LunarDay    *int   `json:"lunarDay" validate:"omitempty,min=1,max=30"`
 }
 
 type UpdateHolidayInput struct {
@@ -21,9 +41,44 @@ type UpdateHolidayInput struct {
 	Date        *string `json:"date" validate:"omitempty,datetime=2006-01-02"`
 	Type        *string `json:"type" validate:"omitempty,oneof=solar lunar"`
 	IsRecurring *bool   `json:"isRecurring" validate:"omitempty"`
+	LunarMonth  *int    `json:"lunarMonth" validate:"omitempty,min=1,max=12"`
+	LunarDay    *int    `json:"lunarDay" validate:"omitempty,min=1,max=30"`
 }
 type HolidayFilter struct {
 	Pagination
 	Type *string `json:"type"`
 	Year *int    `json:"year"`
 }
+
+// CalendarFilter: xem cách hệ thống phân loại các ngày trong khoảng [From, To] tại một rạp
+type CalendarFilter struct {
+	CinemaId     uint   `query:"cinemaId"`
+	MovieId      uint   `query:"movieId"`
+	IsVietnamese *bool  `query:"isVietnamese"`
+	From         string `query:"from" validate:"required,datetime=2006-01-02"`
+	To           string `query:"to" validate:"required,datetime=2006-01-02"`
+}
+
+// HolidayImportPlan: kết quả đối chiếu file .ics với bảng Holiday (xem trước hoặc áp dụng)
+type HolidayImportPlan struct {
+	Creates    []Holiday             `json:"creates"`
+	Updates    []HolidayImportUpdate `json:"updates"`
+	Duplicates []HolidayImportSkip   `json:"duplicates"`
+	Errors     []HolidayImportSkip   `json:"errors"`
+}
+
+type HolidayImportUpdate struct {
+	Before Holiday `json:"before"`
+	After  Holiday `json:"after"`
+}
+
+type HolidayImportSkip struct {
+	UID     string `json:"uid"`
+	Name    string `json:"name"`
+	Date    string `json:"date"`
+	Message string `json:"message"`
+}
+
+type HolidayExportFilter struct {
+	Year int `query:"year"`
+}
@@ -0,0 +1,120 @@
+package model
+
+const (
+	StockReceive    = "RECEIVE"    // nhập hàng
+	StockSale       = "SALE"       // trừ kho khi đơn đã thanh toán
+	StockReturn     = "RETURN"     // hoàn kho khi đơn bị hủy
+	StockWaste      = "WASTE"      // hư hỏng / hết hạn
+	StockAdjustment = "ADJUSTMENT" // kiểm kê điều chỉnh
+)
+
+// InventoryItem: nguyên liệu / vật tư trong kho của một rạp (bắp, ly, syrup...)
+type InventoryItem struct {
+	DTO
+	CinemaId          uint    `gorm:"not null;index;uniqueIndex:idx_inventory_cinema_code" json:"cinemaId"`
+	Code              string  `gorm:"size:30;not null;uniqueIndex:idx_inventory_cinema_code" json:"code"`
+	Name              string  `gorm:"size:150;not null" json:"name"`
+	Unit              string  `gorm:"size:20;not null" json:"unit"` // g, ml, cái, hộp...
+	Quantity          float64 `gorm:"not null;default:0" json:"quantity"`
+	LowStockThreshold float64 `gorm:"default:0" json:"lowStockThreshold"` // tồn <= ngưỡng thì cảnh báo
+	IsActive          bool    `gorm:"default:true" json:"isActive"`
+}
+
+// ConcessionRecipe: lượng nguyên liệu một sản phẩm bắp nước tiêu hao khi bán 1 đơn vị.
+// Combo tiêu hao theo công thức của từng thành phần cộng công thức riêng của combo (nếu có).
+type ConcessionRecipe struct {
+	ID              uint    `gorm:"primaryKey" json:"id"`
+	ProductId       uint    `gorm:"not null;uniqueIndex:idx_recipe_product_item" json:"productId"`
+	InventoryItemId uint    `gorm:"not null;uniqueIndex:idx_recipe_product_item" json:"inventoryItemId"`
+	Quantity        float64 `gorm:"not null" json:"quantity"`
+
+	InventoryItem *InventoryItem `gorm:"foreignKey:InventoryItemId" json:"inventoryItem,omitempty"`
+}
+
+// StockMovement: một lần thay đổi tồn kho; Quantity mang dấu (+ nhập, - xuất)
+type StockMovement struct {
+	DTO
+	CinemaId        uint    `gorm:"not null;index" json:"cinemaId"`
+	InventoryItemId uint    `gorm:"not null;index" json:"inventoryItemId"`
+	Type            string  `gorm:"size:20;not null;index" json:"type"` // RECEIVE / SALE / RETURN / WASTE / ADJUSTMENT
+	Quantity        float64 `gorm:"not null" json:"quantity"`
+	BalanceAfter    float64 `json:"balanceAfter"`
+	OrderId         *uint   `gorm:"index" json:"orderId,omitempty"`
+	Note            string  `gorm:"type:text" json:"note"`
+	CreatedBy       uint    `json:"createdBy"`
+
+	InventoryItem *InventoryItem `gorm:"foreignKey:InventoryItemId" json:"inventoryItem,omitempty"`
+}
+
+type CreateInventoryItemInput struct {
+	CinemaId          uint    `json:"cinemaId" validate:"required"`
+	Code              string  `json:"code" validate:"required,min=2,max=30"`
+	Name              string  `json:"name" validate:"required,min=2,max=150"`
+	Unit              string  `json:"unit" validate:"required,max=20"`
+	Quantity          float64 `json:"quantity" validate:"gte=0"` // tồn đầu kỳ
+	LowStockThreshold float64 `json:"lowStockThreshold" validate:"gte=0"`
+}
+
+type UpdateInventoryItemInput struct {
+	Code              *string  `json:"code" validate:"omitempty,min=2,max=30"`
+	Name              *string  `json:"name" validate:"omitempty,min=2,max=150"`
+	Unit              *string  `json:"unit" validate:"omitempty,max=20"`
+	LowStockThreshold *float64 `json:"lowStockThreshold" validate:"omitempty,gte=0"`
+	IsActive          *bool    `json:"isActive"`
+}
+
+type FilterInventoryItemInput struct {
+	Pagination
+	CinemaId  uint   `query:"cinemaId"`
+	SearchKey string `query:"searchKey"`
+	LowStock  bool   `query:"lowStock"`
+}
+
+// CreateStockMovementInput: nhập hàng / hủy hàng theo số lượng, kiểm kê theo số đếm thực tế
+type CreateStockMovementInput struct {
+	Type            string   `json:"type" validate:"required,oneof=RECEIVE WASTE ADJUSTMENT"`
+	Quantity        float64  `json:"quantity" validate:"omitempty,gt=0"`
+	CountedQuantity *float64 `json:"countedQuantity" validate:"omitempty,gte=0"`
+	Note            string   `json:"note" validate:"max=500"`
+}
+
+type FilterStockMovementInput struct {
+	Pagination
+	CinemaId        uint   `query:"cinemaId"`
+	InventoryItemId uint   `query:"inventoryItemId"`
+	Type            string `query:"type" validate:"omitempty,oneof=RECEIVE SALE RETURN WASTE ADJUSTMENT"`
+	From            string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To              string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type RecipeLineInput struct {
+	InventoryItemId uint    `json:"inventoryItemId" validate:"required"`
+	Quantity        float64 `json:"quantity" validate:"required,gt=0"`
+}
+
+type UpdateRecipeInput struct {
+	Lines []RecipeLineInput `json:"lines" validate:"omitempty,dive"`
+}
+
+type StockReportFilter struct {
+	CinemaId uint   `query:"cinemaId"`
+	From     string `query:"from" validate:"required,datetime=2006-01-02"`
+	To       string `query:"to" validate:"required,datetime=2006-01-02"`
+}
+
+// StockReportItem: xuất nhập tồn của một nguyên liệu trong kỳ
+type StockReportItem struct {
+	InventoryItemId uint    `json:"inventoryItemId"`
+	Code            string  `json:"code"`
+	Name            string  `json:"name"`
+	Unit            string  `json:"unit"`
+	Opening         float64 `json:"opening"`
+	Received        float64 `json:"received"`
+	Sold            float64 `json:"sold"`
+	Returned        float64 `json:"returned"`
+	Wasted          float64 `json:"wasted"`
+	Adjusted        float64 `json:"adjusted"`
+	Closing         float64 `json:"closing"`
+	Current         float64 `json:"current"`
+	IsLowStock      bool    `json:"isLowStock"`
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+const (
+	LoyaltyEarn    = "EARN"    // tích điểm khi đơn đã thanh toán
+	LoyaltyRedeem  = "REDEEM"  // dùng điểm giảm giá khi thanh toán
+	LoyaltyReverse = "REVERSE" // thu hồi điểm đã tích khi đơn bị hủy
+	LoyaltyRefund  = "REFUND"  // trả lại điểm đã dùng khi đơn bị hủy
+	LoyaltyExpire  = "EXPIRE"  // điểm hết hạn
+)
+
+// LoyaltySetting: cấu hình tích/đổi điểm toàn hệ thống (một dòng duy nhất, chưa cấu hình thì dùng mặc định)
+type LoyaltySetting struct {
+	DTO
+	AmountPerPoint   float64 `json:"amountPerPoint"`   // chi tiêu bao nhiêu VND được 1 điểm
+	PointValue       float64 `json:"pointValue"`       // 1 điểm đổi được bao nhiêu VND
+	ExpiryMonths     int     `json:"expiryMonths"`     // điểm hết hạn sau N tháng kể từ ngày tích
+	MaxRedeemPercent float64 `json:"maxRedeemPercent"` // giảm tối đa bao nhiêu % giá trị đơn
+	MinRedeemPoints  int     `json:"minRedeemPoints"`  // số điểm tối thiểu mỗi lần dùng
+	IsActive         bool    `gorm:"default:true" json:"isActive"`
+}
+
+// LoyaltyTransaction: một dòng sổ điểm của khách. Points mang dấu (+ cộng, - trừ).
+// Dòng cộng điểm (EARN/REFUND) là một "lô" điểm: Remaining là phần chưa dùng, hết hạn theo ExpiresAt.
+type LoyaltyTransaction struct {
+	DTO
+	CustomerId   uint       `gorm:"not null;index" json:"customerId"`
+	OrderId      *uint      `gorm:"index" json:"orderId,omitempty"`
+	Type         string     `gorm:"size:20;not null;index" json:"type"` // EARN / REDEEM / REVERSE / REFUND / EXPIRE
+	Points       int        `gorm:"not null" json:"points"`
+	Remaining    int        `gorm:"default:0" json:"remaining"`
+	ExpiresAt    *time.Time `gorm:"index" json:"expiresAt,omitempty"`
+	BalanceAfter int        `json:"balanceAfter"`
+	Note         string     `gorm:"size:255" json:"note"`
+
+	Order *Order `gorm:"foreignKey:OrderId" json:"order,omitempty"`
+}
+
+type UpdateLoyaltySettingInput struct {
+	AmountPerPoint   float64 `json:"amountPerPoint" validate:"required,gt=0"`
+	PointValue       float64 `json:"pointValue" validate:"required,gt=0"`
+	ExpiryMonths     int     `json:"expiryMonths" validate:"required,min=1,max=120"`
+	MaxRedeemPercent float64 `json:"maxRedeemPercent" validate:"required,gt=0,lte=100"`
+	MinRedeemPoints  int     `json:"minRedeemPoints" validate:"min=0"`
+	IsActive         *bool   `json:"isActive"`
+}
+
+type FilterLoyaltyTransactionInput struct {
+	Pagination
+	Type string `query:"type" validate:"omitempty,oneof=EARN REDEEM REVERSE REFUND EXPIRE"`
+}
+
+// LoyaltySummary: số dư và điểm sắp hết hạn hiển thị cho khách
+type LoyaltySummary struct {
+	Balance          int                  `json:"balance"`
+	BalanceValue     float64              `json:"balanceValue"` // quy đổi VND
+	ExpiringPoints   int                  `json:"expiringPoints"`
+	ExpiringAt       *time.Time           `json:"expiringAt,omitempty"`
+	PointValue       float64              `json:"pointValue"`
+	AmountPerPoint   float64              `json:"amountPerPoint"`
+	RecentHistory    []LoyaltyTransaction `json:"recentHistory"`
+	MaxRedeemPercent float64              `json:"maxRedeemPercent"`
+}
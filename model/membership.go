@@ -0,0 +1,99 @@
+package model
+
+import "time"
+
+const (
+	TierChangeAuto     = "AUTO"     // đánh giá lại theo chi tiêu 12 tháng
+	TierChangeOverride = "OVERRIDE" // admin gán tay, khóa không cho tự động thay đổi
+	TierChangeRelease  = "RELEASE"  // admin bỏ khóa, trả về đánh giá tự động
+
+	VoucherBirthday = "BIRTHDAY"
+)
+
+// MembershipTier: hạng thành viên xét theo tổng chi tiêu đơn PAID 12 tháng gần nhất.
+// Rank càng cao hạng càng cao; khách đạt MinSpend của hạng nào thì thuộc hạng cao nhất đạt được.
+type MembershipTier struct {
+	DTO
+	Code     string  `gorm:"size:30;uniqueIndex;not null" json:"code"`
+	Name     string  `gorm:"size:100;not null" json:"name"`
+	Rank     int     `gorm:"uniqueIndex;not null" json:"rank"`
+	MinSpend float64 `gorm:"not null" json:"minSpend"`
+
+	// Quyền lợi
+	DiscountPercent       float64 `json:"discountPercent"`       // giảm % tiền vé mỗi đơn
+	FreeVipUpgrades       int     `json:"freeVipUpgrades"`       // số ghế VIP tính giá ghế thường mỗi đơn
+	BirthdayVoucherAmount float64 `json:"birthdayVoucherAmount"` // voucher sinh nhật (VND), 0 = không có
+	PresaleHours          int     `json:"presaleHours"`          // được mua trước giờ mở bán bao nhiêu giờ
+	IsActive              bool    `gorm:"default:true" json:"isActive"`
+}
+
+// TierChangeLog: lịch sử thay đổi hạng của khách (cả tự động lẫn admin gán tay)
+type TierChangeLog struct {
+	DTO
+	CustomerId   uint    `gorm:"not null;index" json:"customerId"`
+	FromTierId   *uint   `json:"fromTierId"`
+	ToTierId     *uint   `json:"toTierId"`
+	Source       string  `gorm:"size:20;not null" json:"source"` // AUTO / OVERRIDE / RELEASE
+	RollingSpend float64 `json:"rollingSpend"`
+	Note         string  `gorm:"size:500" json:"note"`
+	ChangedBy    *uint   `json:"changedBy"` // account admin, nil nếu tự động
+
+	FromTier *MembershipTier `gorm:"foreignKey:FromTierId" json:"fromTier,omitempty"`
+	ToTier   *MembershipTier `gorm:"foreignKey:ToTierId" json:"toTier,omitempty"`
+}
+
+// MemberVoucher: voucher phát cho khách (hiện có voucher sinh nhật), dùng một lần khi thanh toán
+type MemberVoucher struct {
+	DTO
+	CustomerId uint       `gorm:"not null;index" json:"customerId"`
+	Code       string     `gorm:"size:30;uniqueIndex;not null" json:"code"`
+	Type       string     `gorm:"size:20;not null" json:"type"`
+	Year       int        `gorm:"index" json:"year"` // voucher sinh nhật: mỗi năm một lần
+	Amount     float64    `json:"amount"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	UsedAt     *time.Time `json:"usedAt"`
+	OrderId    *uint      `gorm:"index" json:"orderId"`
+}
+
+type CreateMembershipTierInput struct {
+	Code                  string  `json:"code" validate:"required,max=30"`
+	Name                  string  `json:"name" validate:"required,max=100"`
+	Rank                  int     `json:"rank" validate:"min=0"`
+	MinSpend              float64 `json:"minSpend" validate:"min=0"`
+	DiscountPercent       float64 `json:"discountPercent" validate:"min=0,max=100"`
+	FreeVipUpgrades       int     `json:"freeVipUpgrades" validate:"min=0,max=10"`
+	BirthdayVoucherAmount float64 `json:"birthdayVoucherAmount" validate:"min=0"`
+	PresaleHours          int     `json:"presaleHours" validate:"min=0,max=720"`
+}
+
+type UpdateMembershipTierInput struct {
+	Code                  *string  `json:"code" validate:"omitempty,max=30"`
+	Name                  *string  `json:"name" validate:"omitempty,max=100"`
+	Rank                  *int     `json:"rank" validate:"omitempty,min=0"`
+	MinSpend              *float64 `json:"minSpend" validate:"omitempty,min=0"`
+	DiscountPercent       *float64 `json:"discountPercent" validate:"omitempty,min=0,max=100"`
+	FreeVipUpgrades       *int     `json:"freeVipUpgrades" validate:"omitempty,min=0,max=10"`
+	BirthdayVoucherAmount *float64 `json:"birthdayVoucherAmount" validate:"omitempty,min=0"`
+	PresaleHours          *int     `json:"presaleHours" validate:"omitempty,min=0,max=720"`
+	IsActive              *bool    `json:"isActive"`
+}
+
+// OverrideCustomerTierInput: TierId = nil kèm Release = true để bỏ khóa, trả về xét hạng tự động
+type OverrideCustomerTierInput struct {
+	TierId  *uint  `json:"tierId"`
+	Release bool   `json:"release"`
+	Note    string `json:"note" validate:"required,max=500"`
+}
+
+// TierProgress: hạng hiện tại và tiến độ lên hạng kế tiếp hiển thị cho khách
+type TierProgress struct {
+	Tier          *MembershipTier `json:"tier"`
+	NextTier      *MembershipTier `json:"nextTier"`
+	RollingSpend  float64         `json:"rollingSpend"` // chi tiêu 12 tháng gần nhất
+	AmountToNext  float64         `json:"amountToNext"`
+	Percent       float64         `json:"percent"`
+	Locked        bool            `json:"locked"` // hạng do admin gán
+	EvaluatedAt   *time.Time      `json:"evaluatedAt"`
+	Vouchers      []MemberVoucher `json:"vouchers"`
+	WindowStartAt time.Time       `json:"windowStartAt"`
+}
@@ -0,0 +1,36 @@
+package model
+
+// RoomTurnaround: thời gian dọn phòng và quảng cáo trước phim theo loại phòng
+type RoomTurnaround struct {
+	DTO
+	RoomType        RoomType `gorm:"size:20;uniqueIndex" json:"roomType"`
+	CleaningMinutes int      `json:"cleaningMinutes"` // dọn phòng sau khi phim kết thúc
+	AdMinutes       int      `json:"adMinutes"`       // quảng cáo + trailer trước khi vào phim
+}
+
+// CinemaOperatingHour: giờ mở/đóng cửa của rạp theo thứ trong tuần
+type CinemaOperatingHour struct {
+	DTO
+	CinemaId  uint   `gorm:"index" json:"cinemaId"`
+	Weekday   int    `json:"weekday"`                 // 0 = Chủ nhật ... 6 = Thứ 7
+	OpenTime  string `gorm:"size:5" json:"openTime"`  // "08:00"
+	CloseTime string `gorm:"size:5" json:"closeTime"` // "23:30", nhỏ hơn giờ mở = sau nửa đêm
+}
+
+type RoomTurnaroundInput struct {
+	RoomType        RoomType `json:"roomType" validate:"required,oneof=Small Medium Large IMAX 4DX"`
+	CleaningMinutes int      `json:"cleaningMinutes" validate:"min=0,max=120"`
+	AdMinutes       int      `json:"adMinutes" validate:"min=0,max=60"`
+}
+type UpdateRoomTurnaroundInput struct {
+	Items []RoomTurnaroundInput `json:"items" validate:"required,min=1,dive"`
+}
+
+type OperatingHourInput struct {
+	Weekday   *int   `json:"weekday" validate:"required,min=0,max=6"`
+	OpenTime  string `json:"openTime" validate:"required,datetime=15:04"`
+	CloseTime string `json:"closeTime" validate:"required,datetime=15:04"`
+}
+type UpdateOperatingHoursInput struct {
+	Hours []OperatingHourInput `json:"hours" validate:"required,min=1,max=7,dive"`
+}
@@ -14,3 +14,47 @@ type CreatePaymentInput struct {
 	OrderId uint   `json:"orderId" validate:"required,gt=0"`
 	Method  string `json:"method" validate:"required,oneof=VNPAY MOMO"`
 }
+
+const (
+	TenderCash         = "CASH"
+	TenderCard         = "CARD"
+	TenderBankTransfer = "BANK_TRANSFER"
+	TenderMomo         = "MOMO"
+	TenderVNPay        = "VNPAY"
+	TenderGiftCard     = "GIFT_CARD"
+	TenderWallet       = "WALLET"
+
+	// PaymentMethodSplit: Order.PaymentMethod khi đơn trả bằng nhiều hình thức
+	PaymentMethodSplit = "SPLIT"
+)
+
+// OrderPayment: một dòng thanh toán của đơn (tách hình thức: tiền mặt + thẻ, voucher + tiền mặt...).
+// Tổng Amount các dòng bằng TotalAmount của đơn; khi hủy, tiền hoàn được phân bổ ngược lại theo từng dòng.
+type OrderPayment struct {
+	DTO
+	OrderId          uint    `gorm:"not null;index" json:"orderId"`
+	Method           string  `gorm:"size:20;not null;index" json:"method"`
+	Amount           float64 `gorm:"not null" json:"amount"`
+	Reference        string  `gorm:"size:100" json:"reference"` // mã chuẩn chi thẻ, mã giao dịch, mã thẻ quà tặng...
+	GiftCardId       *uint   `json:"giftCardId,omitempty"`
+	RefundedAmount   float64 `json:"refundedAmount"`
+	RefundedToWallet float64 `json:"refundedToWallet"` // phần hoàn vào ví thay vì hình thức gốc
+	CreatedBy        uint    `json:"createdBy"`
+}
+
+// PaymentLineInput: dòng thanh toán nhân viên nhập tại quầy
+type PaymentLineInput struct {
+	Method       string  `json:"method" validate:"required,oneof=CASH CARD BANK_TRANSFER MOMO VNPAY GIFT_CARD"`
+	Amount       float64 `json:"amount" validate:"required,gt=0"`
+	Reference    string  `json:"reference" validate:"max=100"`
+	GiftCardCode string  `json:"giftCardCode"`
+	GiftCardPin  string  `json:"giftCardPin"`
+}
+
+// RefundLine: phần tiền hoàn phân bổ cho một dòng thanh toán
+type RefundLine struct {
+	PaymentId uint    `json:"paymentId"`
+	Method    string  `json:"method"`
+	Amount    float64 `json:"amount"`
+	ToWallet  bool    `json:"toWallet"`
+}
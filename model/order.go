@@ -22,4 +22,66 @@ type Order struct {
 	CancelledAt   *time.Time `json:"cancelledAt"`
 	RefundAmount  float64    `json:"refundAmount"`
 	ActualRevenue float64    `json:"actualRevenue"`
+	// Suất chiếu bị dời: khách được hủy hoàn 100% đến thời điểm này
+	FullRefundUntil *time.Time `json:"fullRefundUntil,omitempty"`
+	// Tiền bắp nước, đã gồm trong TotalAmount
+	ConcessionAmount float64           `json:"concessionAmount"`
+	Concessions      []OrderConcession `gorm:"foreignKey:OrderId" json:"concessions,omitempty"`
+	// Điểm thành viên: điểm đã dùng (giảm LoyaltyDiscount, đã trừ vào TotalAmount) và điểm tích được
+	PointsRedeemed  int     `json:"pointsRedeemed"`
+	LoyaltyDiscount float64 `json:"loyaltyDiscount"`
+	PointsEarned    int     `json:"pointsEarned"`
+	// Quyền lợi hạng thành viên: giảm % tiền vé + nâng hạng ghế VIP miễn phí; voucher sinh nhật
+	MemberDiscount  float64 `json:"memberDiscount"`
+	VoucherId       *uint   `json:"voucherId"`
+	VoucherDiscount float64 `json:"voucherDiscount"`
+	// Phần tổng tiền thanh toán bằng thẻ quà tặng / ví (không phải giảm giá); hoàn về ví khi hủy
+	GiftCardId     *uint   `json:"giftCardId"`
+	GiftCardAmount float64 `json:"giftCardAmount"`
+	WalletAmount   float64 `json:"walletAmount"`
+	WalletRefund   float64 `json:"walletRefund"`
+	// Các dòng thanh toán (tách hình thức); PaymentMethod = SPLIT khi có nhiều hình thức
+	Payments []OrderPayment `gorm:"foreignKey:OrderId" json:"payments,omitempty"`
+	// Đặt chỗ qua điện thoại: đơn RESERVED giữ ghế đến ReservedUntil, thanh toán tại quầy theo mã đơn
+	ReservedUntil   *time.Time `json:"reservedUntil,omitempty"`
+	ReservationNote string     `gorm:"type:text" json:"reservationNote,omitempty"`
+	// Vé mời: đơn 0đ không tính vào doanh thu (ActualRevenue = 0), CompValue là giá trị vé theo giá bán
+	IsComp        bool    `gorm:"default:false;index" json:"isComp"`
+	CompReason    string  `gorm:"size:30" json:"compReason,omitempty"`
+	CompValue     float64 `json:"compValue"`
+	CompRequestId *uint   `json:"compRequestId,omitempty"`
+	// Phim T13/T16/T18: người mua online (chưa khai ngày sinh) xác nhận người xem đủ tuổi
+	AgeConfirmed bool `gorm:"default:false" json:"ageConfirmed"`
+}
+
+const (
+	// OrderReserved: đơn đặt chỗ chưa thanh toán, vé ở trạng thái RESERVED
+	OrderReserved = "RESERVED"
+	// DefaultReservationCutoff: đơn đặt chỗ tự hủy trước giờ chiếu bao nhiêu phút
+	DefaultReservationCutoff = 30
+)
+
+// CreateReservationInput: nhân viên đặt chỗ cho khách gọi điện (ghế đã được nhân viên giữ)
+type CreateReservationInput struct {
+	SeatIds       []uint `json:"seatIds" validate:"required,min=1"`
+	CustomerName  string `json:"customerName" validate:"required,max=100"`
+	Phone         string `json:"phone" validate:"required,min=9,max=15"`
+	Email         string `json:"email" validate:"omitempty,email"`
+	Note          string `json:"note" validate:"max=500"`
+	CutoffMinutes int    `json:"cutoffMinutes" validate:"omitempty,min=15,max=180"` // mặc định 30 phút trước giờ chiếu
+	// Loại vé từng ghế; ghế không khai báo tính giá thường
+	SeatCategories []SeatCategoryInput `json:"seatCategories" validate:"omitempty,dive"`
+}
+
+// PayReservationInput: thanh toán đơn đặt chỗ tại quầy (một hình thức hoặc tách nhiều dòng)
+type PayReservationInput struct {
+	PaymentMethod string             `json:"paymentMethod"`
+	Payments      []PaymentLineInput `json:"payments"`
+}
+
+type FilterReservationInput struct {
+	Pagination
+	Status       string `query:"status" validate:"omitempty,oneof=RESERVED PAID CANCELLED"`
+	Phone        string `query:"phone"`
+	ShowtimeCode string `query:"showtimeCode"`
 }
@@ -6,7 +6,7 @@ type ScheduleTemplate struct {
 	Name        string `gorm:"size:100;not null" json:"name" validate:"required,min=3,max=100"`
 	Description string `gorm:"type:text" json:"description"`
 
-	DayTypes   []string `gorm:"type:json;serializer:json" json:"dayTypes" validate:"required,dive,oneof=weekday weekend friday saturday sunday holiday early"`
+	DayTypes   []string `gorm:"type:json;serializer:json" json:"dayTypes" validate:"required,dive,oneof=weekday weekend friday saturday sunday holiday early special premiere festival fan_screening marathon"`
 	MovieTypes []string `gorm:"type:json;serializer:json" json:"movieTypes" validate:"required,dive,oneof=blockbuster vietnamese kids art horror romance"`
 	TimeSlots  []string `gorm:"type:json;serializer:json" json:"timeSlots" validate:"required,dive,timeslot"`
 	Formats    []string `gorm:"type:json;serializer:json" json:"formats" validate:"required,dive,oneof=2D 3D IMAX 4DX"`
@@ -16,7 +16,37 @@ type ScheduleTemplate struct {
 
 	Priority int `gorm:"not null;default:50;check:priority >= 0 AND priority <= 100" json:"priority" validate:"required,min=0,max=100"`
 
+	// Quy tắc riêng cho từng định dạng; định dạng không khai báo dùng mặc định hệ thống
+	FormatRules []FormatRule `gorm:"type:json;serializer:json" json:"formatRules"`
+
 	CreatedBy uint `gorm:"not null" json:"createdBy"`
+
+	Items []ScheduleTemplateItem `gorm:"foreignKey:ScheduleTemplateId" json:"items,omitempty"`
+}
+
+// ScheduleTemplateItem: một suất trong mẫu lịch (phòng + giờ + định dạng + ngôn ngữ),
+// áp dụng mẫu cho một ngày sẽ tạo một suất chiếu cho mỗi item
+type ScheduleTemplateItem struct {
+	DTO
+	ScheduleTemplateId uint         `gorm:"not null;index" json:"scheduleTemplateId"`
+	RoomId             uint         `gorm:"not null;index" json:"roomId"`
+	Time               string       `gorm:"size:5;not null" json:"time"` // "15:04"
+	Format             string       `gorm:"size:10;not null" json:"format"`
+	LanguageType       LanguageType `gorm:"size:20;default:'VI_SUB'" json:"languageType"`
+
+	Room Room `gorm:"foreignKey:RoomId" json:"-"`
+}
+
+// FormatRule: quy tắc xếp lịch của một định dạng (2D/3D/IMAX/4DX)
+type FormatRule struct {
+	Format        string `json:"format" validate:"required,oneof=2D 3D IMAX 4DX"`
+	MaxPerDay     int    `json:"maxPerDay" validate:"min=1,max=50"`      // số suất tối đa/ngày mỗi phòng
+	StartHour     int    `json:"startHour" validate:"min=0,max=23"`      // suất bắt đầu từ giờ này
+	EndHour       int    `json:"endHour" validate:"min=1,max=24"`        // suất bắt đầu trước giờ này
+	OffsetMinutes int    `json:"offsetMinutes" validate:"min=0,max=120"` // lệch giờ giữa các phòng
+	MaxRooms      int    `json:"maxRooms" validate:"min=1,max=20"`       // số phòng tối đa chiếu cùng phim
+	LateHour      int    `json:"lateHour" validate:"min=0,max=23"`       // từ giờ này tính là suất muộn (0 = không giới hạn)
+	MaxLatePerDay int    `json:"maxLatePerDay" validate:"min=0,max=10"`  // số suất muộn tối đa/ngày
 }
 type FilterScheduleTemplateInput struct {
 	Pagination
@@ -25,27 +55,29 @@ type FilterScheduleTemplateInput struct {
 	TimeSlots  string `query:"timeSlots" validate:"omitempty,regex=^[0-9:]+(,[0-9:]+)*$"`
 }
 type CreateScheduleTemplateInput struct {
-	Name        string   `json:"name" validate:"required,min=3,max=100"`
-	Description string   `json:"description"`
-	DayTypes    []string `json:"dayTypes" validate:"required,dive,oneof=weekday weekend friday saturday sunday holiday early"`
-	MovieTypes  []string `json:"movieTypes" validate:"required,dive,oneof=blockbuster vietnamese kids art horror romance"`
-	TimeSlots   []string `json:"timeSlots" validate:"required,dive,timeslot"`
-	Formats     []string `json:"formats" validate:"required,dive,oneof=2D 3D IMAX 4DX"`
-	MaxRooms    int      `json:"maxRooms" validate:"required,min=1,max=10"`
-	MaxPerDay   int      `json:"maxPerDay" validate:"required,min=1,max=10"`
-	Priority    int      `json:"priority" validate:"required,min=0,max=100"`
+	Name        string       `json:"name" validate:"required,min=3,max=100"`
+	Description string       `json:"description"`
+	DayTypes    []string     `json:"dayTypes" validate:"required,dive,oneof=weekday weekend friday saturday sunday holiday early special premiere festival fan_screening marathon"`
+	MovieTypes  []string     `json:"movieTypes" validate:"required,dive,oneof=blockbuster vietnamese kids art horror romance"`
+	TimeSlots   []string     `json:"timeSlots" validate:"required,dive,timeslot"`
+	Formats     []string     `json:"formats" validate:"required,dive,oneof=2D 3D IMAX 4DX"`
+	MaxRooms    int          `json:"maxRooms" validate:"required,min=1,max=10"`
+	MaxPerDay   int          `json:"maxPerDay" validate:"required,min=1,max=10"`
+	Priority    int          `json:"priority" validate:"required,min=0,max=100"`
+	FormatRules []FormatRule `json:"formatRules" validate:"omitempty,dive"`
 }
 
 type UpdateScheduleTemplateInput struct {
-	Name        *string  `json:"name" validate:"omitempty,min=3,max=100"`
-	Description *string  `json:"description"`
-	DayTypes    []string `json:"dayTypes" validate:"omitempty,dive,oneof=weekday weekend friday saturday sunday holiday early"`
-	MovieTypes  []string `json:"movieTypes" validate:"omitempty,dive,oneof=blockbuster vietnamese kids art horror romance"`
-	TimeSlots   []string `json:"timeSlots" validate:"omitempty,dive,timeslot"`
-	Formats     []string `json:"formats" validate:"omitempty,dive,oneof=2D 3D IMAX 4DX"`
-	MaxRooms    *int     `json:"maxRooms" validate:"omitempty,min=1,max=10"`
-	MaxPerDay   *int     `json:"maxPerDay" validate:"omitempty,min=1,max=10"`
-	Priority    *int     `json:"priority" validate:"omitempty,min=0,max=100"`
+	Name        *string      `json:"name" validate:"omitempty,min=3,max=100"`
+	Description *string      `json:"description"`
+	DayTypes    []string     `json:"dayTypes" validate:"omitempty,dive,oneof=weekday weekend friday saturday sunday holiday early special premiere festival fan_screening marathon"`
+	MovieTypes  []string     `json:"movieTypes" validate:"omitempty,dive,oneof=blockbuster vietnamese kids art horror romance"`
+	TimeSlots   []string     `json:"timeSlots" validate:"omitempty,dive,timeslot"`
+	Formats     []string     `json:"formats" validate:"omitempty,dive,oneof=2D 3D IMAX 4DX"`
+	MaxRooms    *int         `json:"maxRooms" validate:"omitempty,min=1,max=10"`
+	MaxPerDay   *int         `json:"maxPerDay" validate:"omitempty,min=1,max=10"`
+	Priority    *int         `json:"priority" validate:"omitempty,min=0,max=100"`
+	FormatRules []FormatRule `json:"formatRules" validate:"omitempty,dive"`
 }
 type AutoGenerateScheduleTemplateInput struct {
 	MovieId      uint     `json:"movieId" validate:"required"`
@@ -57,3 +89,23 @@ type AutoGenerateScheduleTemplateInput struct {
 	TemplateName *string  `json:"templateName,omitempty"`
 	IsVietnamese *bool    `json:"isVietnamese,omitempty"`
 }
+
+type CreateScheduleTemplateItemInput struct {
+	RoomId       uint   `json:"roomId" validate:"required"`
+	Time         string `json:"time" validate:"required,datetime=15:04"`
+	Format       string `json:"format" validate:"required,oneof=2D 3D IMAX 4DX"`
+	LanguageType string `json:"languageType" validate:"omitempty,oneof=VI_SUB VI_DUB EN_SUB EN_DUB VI"`
+}
+
+type UpdateScheduleTemplateItemInput struct {
+	RoomId       *uint   `json:"roomId" validate:"omitempty,min=1"`
+	Time         *string `json:"time" validate:"omitempty,datetime=15:04"`
+	Format       *string `json:"format" validate:"omitempty,oneof=2D 3D IMAX 4DX"`
+	LanguageType *string `json:"languageType" validate:"omitempty,oneof=VI_SUB VI_DUB EN_SUB EN_DUB VI"`
+}
+
+// ApplyScheduleTemplateInput: áp dụng các item của mẫu lịch cho phim vào danh sách ngày
+type ApplyScheduleTemplateInput struct {
+	MovieId    uint     `json:"movieId" validate:"required"`
+	ApplyDates []string `json:"applyDates" validate:"required,min=1,max=31,dive,required,datetime=2006-01-02"`
+}
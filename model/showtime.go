@@ -14,11 +14,15 @@ const (
 
 type Showtime struct {
 	DTO
-	PublicCode   string       `gorm:"size:16;uniqueIndex" json:"publicCode"` // 👈 THÊM
-	StartTime    time.Time    `validate:"required" json:"start"`
-	EndTime      time.Time    `validate:"required" json:"end"`
-	Price        float64      `json:"price"`
-	Status       string       `json:"status"`
+	PublicCode   string     `gorm:"size:16;uniqueIndex" json:"publicCode"` // 👈 THÊM
+	StartTime    time.Time  `validate:"required" json:"start"`
+	EndTime      time.Time  `validate:"required" json:"end"`
+	Price        float64    `json:"price"`
+	Status       string     `json:"status"`
+	CancelledAt  *time.Time `json:"cancelledAt"`
+	CancelReason string     `json:"cancelReason"`
+	// Giờ mở bán công khai; nil = mở bán ngay. Hạng thành viên có quyền mua trước (PresaleHours)
+	SaleOpensAt  *time.Time   `json:"saleOpensAt"`
 	Format       string       `gorm:"size:10" json:"format"` // 2D, 3D, IMAX, 4DX
 	LanguageType LanguageType `gorm:"size:20"  default:"VI_SUB" json:"languageType"`
 	MovieId      uint         `json:"movieId"`
@@ -27,6 +31,10 @@ type Showtime struct {
 	Room         Room         `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:RoomId" json:"Room"`
 
 	Tickets []Ticket `gorm:"foreignKey:ShowtimeId" json:"tickets"`
+
+	// Thông tin sự kiện đặc biệt để hiển thị, không lưu DB
+	EventTags     []string `gorm:"-" json:"eventTags,omitempty"`
+	NonRefundable bool     `gorm:"-" json:"nonRefundable,omitempty"`
 }
 type ShowtimeSeat struct {
 	DTO
@@ -89,7 +97,8 @@ type AutoGenerateScheduleInput struct {
 	LanguageType string   `gorm:"size:20" json:"languageType"`        // VI_SUB, VI_DUB, EN_SUB, EN_DUB
 	TimeSlots    []string `json:"timeSlots" validate:"required,dive"` // ["18:30", "20:45"]
 	IsVietnamese bool     `json:"isVietnamese"`
-	Price        float64  `json:"price"` // ưu tiên khung giờ vàng
+	Price        float64  `json:"price"`                // ưu tiên khung giờ vàng
+	TemplateId   *uint    `json:"templateId,omitempty"` // lấy quy tắc định dạng từ mẫu lịch
 }
 type UpdateShowtimeInput struct {
 	MovieId   *uint      `json:"movieId" `
@@ -97,6 +106,8 @@ type UpdateShowtimeInput struct {
 	StartTime *time.Time `json:"start_time" `
 	EndTime   *time.Time `json:"endTime" `
 	Price     *float64   `json:"price"`
+	// Giờ mở bán công khai, phải trước giờ chiếu
+	SaleOpensAt *time.Time `json:"saleOpensAt"`
 }
 
 // BulkShowtimeInput defines the input structure for a single showtime in bulk creation
@@ -112,3 +123,46 @@ type BulkShowtimeInput struct {
 type CreateBulkShowtimesInput struct {
 	Showtimes []BulkShowtimeInput `json:"showtimes" validate:"required,dive"`
 }
+
+// PlanCinemaScheduleInput: lập lịch chiếu cho toàn bộ rạp (tất cả phim, tất cả phòng)
+type PlanCinemaScheduleInput struct {
+	CinemaId        uint               `json:"cinemaId" validate:"required"`
+	StartDate       string             `json:"startDate" validate:"required"` // YYYY-MM-DD
+	EndDate         string             `json:"endDate" validate:"required"`
+	MovieIds        []uint             `json:"movieIds"` // rỗng = tất cả phim NOW_SHOWING/COMING_SOON
+	RoomIds         []uint             `json:"roomIds"`  // rỗng = tất cả phòng đang hoạt động
+	MovieWeights    []MovieWeightInput `json:"movieWeights" validate:"omitempty,dive"`
+	Objective       string             `json:"objective" validate:"omitempty,oneof=revenue seat_hours"`
+	OpenTime        string             `json:"openTime"`                                           // rỗng = theo giờ hoạt động của rạp
+	CloseTime       string             `json:"closeTime"`                                          // "23:30", có thể qua nửa đêm: "01:00"
+	CleaningMinutes *int               `json:"cleaningMinutes" validate:"omitempty,min=0,max=120"` // rỗng = theo loại phòng
+	StaggerMinutes  *int               `json:"staggerMinutes" validate:"omitempty,min=0,max=60"`
+	LanguageType    string             `json:"languageType"`
+	DryRun          bool               `json:"dryRun"` // true = chỉ xem trước, không lưu
+}
+type MovieWeightInput struct {
+	MovieId uint    `json:"movieId" validate:"required"`
+	Weight  float64 `json:"weight" validate:"gt=0"`
+}
+
+// CopyScheduleInput: sao chép lịch chiếu của rạp từ khoảng ngày nguồn sang khoảng ngày đích
+type CopyScheduleInput struct {
+	CinemaId    uint   `json:"cinemaId" validate:"required"`
+	SourceStart string `json:"sourceStart" validate:"required,datetime=2006-01-02"`
+	SourceEnd   string `json:"sourceEnd" validate:"required,datetime=2006-01-02"`
+	TargetStart string `json:"targetStart" validate:"required,datetime=2006-01-02"`
+	TargetEnd   string `json:"targetEnd" validate:"omitempty,datetime=2006-01-02"` // rỗng = cùng số ngày với nguồn
+	RoomIds     []uint `json:"roomIds"`                                            // rỗng = tất cả phòng
+	MovieIds    []uint `json:"movieIds"`                                           // rỗng = tất cả phim
+	DryRun      bool   `json:"dryRun"`
+}
+
+type CancelShowtimeInput struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type MoveShowtimeInput struct {
+	RoomId    *uint      `json:"roomId"`
+	StartTime *time.Time `json:"startTime"`
+	Notify    *bool      `json:"notify"` // mặc định gửi email cho khách
+}
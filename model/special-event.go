@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+const (
+	EventPremiere     = "premiere"      // công chiếu / ra mắt phim
+	EventFestival     = "festival"      // liên hoan phim
+	EventFanScreening = "fan_screening" // suất chiếu fan
+	EventMarathon     = "marathon"      // chiếu liên tục nhiều phim
+)
+
+// SpecialEvent: sự kiện đặc biệt áp dụng trong khoảng ngày. Phạm vi thu hẹp dần theo chuỗi → rạp → phim,
+// trường để trống nghĩa là áp dụng cho tất cả.
+type SpecialEvent struct {
+	DTO
+	Name        string    `gorm:"size:150;not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	Type        string    `gorm:"size:20;not null;index" json:"type"` // premiere / festival / fan_screening / marathon
+	StartDate   time.Time `gorm:"type:date;not null;index" json:"startDate"`
+	EndDate     time.Time `gorm:"type:date;not null;index" json:"endDate"`
+
+	ChainId  *uint `gorm:"index" json:"chainId"`
+	CinemaId *uint `gorm:"index" json:"cinemaId"`
+	MovieId  *uint `gorm:"index" json:"movieId"`
+
+	// Điều chỉnh giá: giá mới = giá * (1 + PricePercent/100) + PriceAmount
+	PricePercent float64 `gorm:"default:0" json:"pricePercent"`
+	PriceAmount  float64 `gorm:"default:0" json:"priceAmount"`
+
+	NonRefundable bool     `gorm:"default:false" json:"nonRefundable"` // vé thuộc sự kiện không được hủy/hoàn
+	Tags          []string `gorm:"type:json;serializer:json" json:"tags"`
+	IsActive      bool     `gorm:"default:false" json:"isActive"`
+
+	CreatedBy uint `gorm:"not null" json:"createdBy"`
+
+	Cinema *Cinema `gorm:"foreignKey:CinemaId" json:"cinema,omitempty"`
+	Movie  *Movie  `gorm:"foreignKey:MovieId" json:"movie,omitempty"`
+}
+
+type CreateSpecialEventInput struct {
+	Name          string   `json:"name" validate:"required,min=3,max=150"`
+	Description   string   `json:"description"`
+	Type          string   `json:"type" validate:"required,oneof=premiere festival fan_screening marathon"`
+	StartDate     string   `json:"startDate" validate:"required,datetime=2006-01-02"`
+	EndDate       string   `json:"endDate" validate:"required,datetime=2006-01-02"`
+	ChainId       *uint    `json:"chainId" validate:"omitempty,min=1"`
+	CinemaId      *uint    `json:"cinemaId" validate:"omitempty,min=1"`
+	MovieId       *uint    `json:"movieId" validate:"omitempty,min=1"`
+	PricePercent  float64  `json:"pricePercent" validate:"min=-100,max=500"`
+	PriceAmount   float64  `json:"priceAmount"`
+	NonRefundable bool     `json:"nonRefundable"`
+	Tags          []string `json:"tags" validate:"omitempty,dive,min=1,max=30"`
+	IsActive      *bool    `json:"isActive"`
+}
+
+type UpdateSpecialEventInput struct {
+	Name          *string  `json:"name" validate:"omitempty,min=3,max=150"`
+	Description   *string  `json:"description"`
+	Type          *string  `json:"type" validate:"omitempty,oneof=premiere festival fan_screening marathon"`
+	StartDate     *string  `json:"startDate" validate:"omitempty,datetime=2006-01-02"`
+	EndDate       *string  `json:"endDate" validate:"omitempty,datetime=2006-01-02"`
+	ChainId       *uint    `json:"chainId" validate:"omitempty,min=1"`
+	CinemaId      *uint    `json:"cinemaId" validate:"omitempty,min=1"`
+	MovieId       *uint    `json:"movieId" validate:"omitempty,min=1"`
+	PricePercent  *float64 `json:"pricePercent" validate:"omitempty,min=-100,max=500"`
+	PriceAmount   *float64 `json:"priceAmount"`
+	NonRefundable *bool    `json:"nonRefundable"`
+	Tags          []string `json:"tags" validate:"omitempty,dive,min=1,max=30"`
+	IsActive      *bool    `json:"isActive"`
+}
+
+type FilterSpecialEventInput struct {
+	Pagination
+	Type     string `query:"type" validate:"omitempty,oneof=premiere festival fan_screening marathon"`
+	CinemaId uint   `query:"cinemaId"`
+	MovieId  uint   `query:"movieId"`
+	From     string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To       string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+}
@@ -0,0 +1,53 @@
+package model
+
+const (
+	TicketCategoryAdult   = "ADULT" // giá thường, mặc định khi không chọn loại vé
+	TicketCategoryChild   = "CHILD"
+	TicketCategoryStudent = "STUDENT"
+	TicketCategorySenior  = "SENIOR"
+	TicketCategoryU22     = "U22"
+)
+
+// TicketCategory: loại vé (trẻ em, học sinh - sinh viên, người cao tuổi, U22...) với quy tắc giá riêng.
+// Giá ghế = (FixedPrice nếu có, ngược lại giá suất chiếu giảm DiscountPercent) × hệ số loại ghế.
+type TicketCategory struct {
+	DTO
+	Code            string   `gorm:"size:20;uniqueIndex;not null" json:"code"`
+	Name            string   `gorm:"size:100;not null" json:"name"`
+	DiscountPercent float64  `json:"discountPercent"`
+	FixedPrice      *float64 `json:"fixedPrice"`                // giá cố định trước hệ số loại ghế, ưu tiên hơn DiscountPercent
+	AgeRatings      string   `gorm:"size:50" json:"ageRatings"` // "P,K": chỉ bán cho phim có phân loại này; rỗng = mọi phim
+	RequiresIdCheck bool     `json:"requiresIdCheck"`           // soát vé phải kiểm tra giấy tờ
+	Description     string   `gorm:"size:500" json:"description"`
+	SortOrder       int      `json:"sortOrder"`
+	IsActive        bool     `gorm:"default:true" json:"isActive"`
+}
+
+type CreateTicketCategoryInput struct {
+	Code            string   `json:"code" validate:"required,max=20"`
+	Name            string   `json:"name" validate:"required,max=100"`
+	DiscountPercent float64  `json:"discountPercent" validate:"min=0,max=100"`
+	FixedPrice      *float64 `json:"fixedPrice" validate:"omitempty,min=0"`
+	AgeRatings      []string `json:"ageRatings" validate:"omitempty,dive,oneof=P K T13 T16 T18"`
+	RequiresIdCheck bool     `json:"requiresIdCheck"`
+	Description     string   `json:"description" validate:"max=500"`
+	SortOrder       int      `json:"sortOrder"`
+}
+
+type UpdateTicketCategoryInput struct {
+	Name            *string   `json:"name" validate:"omitempty,max=100"`
+	DiscountPercent *float64  `json:"discountPercent" validate:"omitempty,min=0,max=100"`
+	FixedPrice      *float64  `json:"fixedPrice" validate:"omitempty,min=0"`
+	ClearFixedPrice bool      `json:"clearFixedPrice"` // bỏ giá cố định, quay về giảm theo %
+	AgeRatings      *[]string `json:"ageRatings" validate:"omitempty,dive,oneof=P K T13 T16 T18"`
+	RequiresIdCheck *bool     `json:"requiresIdCheck"`
+	Description     *string   `json:"description" validate:"omitempty,max=500"`
+	SortOrder       *int      `json:"sortOrder"`
+	IsActive        *bool     `json:"isActive"`
+}
+
+// SeatCategoryInput: loại vé chọn cho từng ghế khi thanh toán; ghế không khai báo tính giá thường
+type SeatCategoryInput struct {
+	SeatId       uint   `json:"seatId" validate:"required"`
+	CategoryCode string `json:"categoryCode" validate:"required,max=20"`
+}
@@ -8,11 +8,15 @@ type Ticket struct {
 	Status      string    `gorm:"not null;default:'BOOKED'" json:"status"`
 	TicketCode  string    `gorm:"size:20;uniqueIndex" json:"ticketCode"`
 	Price       float64   `gorm:"not null" json:"price"`
+	// Loại vé chọn khi thanh toán (ADULT = giá thường), in trên vé và dùng khi soát vé
+	CategoryCode    string `gorm:"size:20;default:'ADULT'" json:"categoryCode"`
+	CategoryName    string `gorm:"size:100" json:"categoryName,omitempty"`
+	RequiresIdCheck bool   `gorm:"default:false" json:"requiresIdCheck"`
 
 	IssuedAt       time.Time  `json:"issuedAt"`
 	UsedAt         *time.Time `json:"usedAt,omitempty"`
 	CancelledAt    *time.Time `json:"cancelledAt"`
-	ShowtimeSeatId uint       `json:"showtimeSeatId"`
+	ShowtimeSeatId *uint      `json:"showtimeSeatId"` // null: vé đã hủy của suất bị dời phòng (sơ đồ ghế cũ đã xóa)
 	ShowtimeId     uint       `json:"showtimeId"`
 	SeatId         uint       `json:"seatId"`
 	OrderId        uint       `json:"orderId"`
@@ -34,6 +34,22 @@ func SetupRoutes(app *fiber.App) {
 	staff.Post("/seats/release/:code", middleware.Protected(), handler.ReleaseSeatForStaff)
 	staff.Post("/ticket/create/:code", middleware.Protected(), handler.CreateTicketForStaff)
 	staff.Post("/ticket/checkin", middleware.Protected(), handler.CheckinByOrderCode)
+	staff.Post("/orders/cancel", middleware.Protected(), handler.CancelOrderByCode)
+	staff.Get("/ticket/qr-public-key", handler.GetQRPublicKey)
+	staff.Get("/age-checks", middleware.Protected(), handler.GetAgeCheckLogs)
+	staff.Post("/reservations/:code", middleware.Protected(), validate.CreateReservation(), handler.CreateReservation)
+	staff.Get("/reservations", middleware.Protected(), handler.GetReservations)
+	staff.Get("/reservations/order/:orderCode", middleware.Protected(), validate.Reservation("orderCode"), handler.GetReservationByCode)
+	staff.Post("/reservations/order/:orderCode/pay", middleware.Protected(), validate.Reservation("orderCode"), validate.PayReservation(), handler.PayReservation)
+	staff.Post("/reservations/order/:orderCode/cancel", middleware.Protected(), validate.Reservation("orderCode"), handler.CancelReservation)
+	staff.Post("/cash-drawer/open", middleware.Protected(), validate.OpenCashDrawer(), handler.OpenCashDrawer)
+	staff.Get("/cash-drawer/current", middleware.Protected(), handler.GetCurrentCashDrawer)
+	staff.Post("/cash-drawer/payout", middleware.Protected(), validate.CashPayout(), handler.CashDrawerPayout)
+	staff.Post("/cash-drawer/refund/:orderCode", middleware.Protected(), handler.CashDrawerRefund)
+	staff.Post("/cash-drawer/close", middleware.Protected(), validate.CloseCashDrawer(), handler.CloseCashDrawer)
+	staff.Get("/cash-drawer/sessions", middleware.Protected(), handler.GetCashDrawerSessions)
+	staff.Get("/cash-drawer/sessions/:sessionId", middleware.Protected(), handler.GetCashDrawerSessionById)
+	staff.Post("/comp-tickets/issue/:code", middleware.Protected(), validate.IssueCompTickets(), handler.IssueCompTickets)
 	staff.Get("/:staffId", middleware.Protected(), validate.GetById("staffId"), handler.GetStaffById)
 	staff.Post("/", middleware.Protected(), validate.CreateStaff(), handler.CreateStaff)
 	staff.Put("/:staffId", middleware.Protected(), validate.EditStaff("staffId"), handler.EditStaff)
@@ -51,6 +67,37 @@ func SetupRoutes(app *fiber.App) {
 
 	customer.Get("/", middleware.Protected(), handler.GetCustomer)
 	customer.Get("/:customerId", middleware.Protected(), validate.GetById("customerId"), handler.GetCustomerById)
+	customer.Get("/:customerId/loyalty", middleware.Protected(), validate.GetById("customerId"), validate.LoyaltyHistory(), handler.GetCustomerLoyalty)
+	customer.Get("/:customerId/tier", middleware.Protected(), validate.GetById("customerId"), handler.GetCustomerTierHistory)
+	customer.Put("/:customerId/tier", middleware.Protected(), validate.OverrideCustomerTier("customerId"), handler.OverrideCustomerTier)
+
+	giftCard := v1.Group("/gift-cards", logger.New())
+	giftCard.Get("/", middleware.Protected(), handler.GetGiftCards)
+	giftCard.Post("/", middleware.Protected(), validate.SellGiftCard(), handler.SellGiftCard)
+	giftCard.Get("/:giftCardId", middleware.Protected(), validate.GiftCard("giftCardId"), handler.GetGiftCardById)
+	giftCard.Put("/:giftCardId/status", middleware.Protected(), validate.GiftCard("giftCardId"), handler.UpdateGiftCardStatus)
+
+	theQuaTang := v1.Group("/the-qua-tang")
+	theQuaTang.Post("/mua", middleware.OptionalJWT(), middleware.OptionalAuth(), validate.BuyGiftCard(), handler.SellGiftCard)
+	theQuaTang.Post("/kiem-tra", validate.GiftCardCredential(), handler.CheckGiftCardBalance)
+
+	tier := v1.Group("/membership-tiers", logger.New())
+	tier.Get("/", middleware.Protected(), handler.GetMembershipTiers)
+	tier.Post("/", middleware.Protected(), validate.CreateMembershipTier(), handler.CreateMembershipTier)
+	tier.Get("/:tierId", middleware.Protected(), validate.MembershipTier("tierId"), handler.GetMembershipTierById)
+	tier.Put("/:tierId", middleware.Protected(), validate.MembershipTier("tierId"), handler.UpdateMembershipTier)
+	tier.Delete("/:tierId", middleware.Protected(), validate.MembershipTier("tierId"), handler.DeleteMembershipTier)
+
+	ticketCategory := v1.Group("/ticket-categories", logger.New())
+	ticketCategory.Get("/", middleware.Protected(), handler.GetTicketCategories)
+	ticketCategory.Post("/", middleware.Protected(), validate.CreateTicketCategory(), handler.CreateTicketCategory)
+	ticketCategory.Get("/:categoryId", middleware.Protected(), validate.TicketCategory("categoryId"), handler.GetTicketCategoryById)
+	ticketCategory.Put("/:categoryId", middleware.Protected(), validate.TicketCategory("categoryId"), handler.UpdateTicketCategory)
+	ticketCategory.Delete("/:categoryId", middleware.Protected(), validate.TicketCategory("categoryId"), handler.DeleteTicketCategory)
+
+	loyalty := v1.Group("/loyalty", logger.New())
+	loyalty.Get("/settings", middleware.Protected(), handler.GetLoyaltySetting)
+	loyalty.Put("/settings", middleware.Protected(), validate.UpdateLoyaltySetting(), handler.UpdateLoyaltySetting)
 
 	chain := v1.Group("/chain", logger.New())
 	chain.Get("/", middleware.Protected(), handler.GetCinemaChain)
@@ -66,9 +113,13 @@ func SetupRoutes(app *fiber.App) {
 	cinema.Put("/:cinemaId", middleware.Protected(), validate.EditCinema("cinemaId"), handler.EditCinema)
 	cinema.Delete("/", middleware.Protected(), validate.Delete(), handler.DeleteCinema)
 	cinema.Get("/:cinemaId/rooms", middleware.Protected(), handler.GetRoomsByCinemaId)
+	cinema.Get("/:cinemaId/operating-hours", middleware.Protected(), validate.CinemaOperatingHours("cinemaId"), handler.GetCinemaOperatingHours)
+	cinema.Put("/:cinemaId/operating-hours", middleware.Protected(), validate.CinemaOperatingHours("cinemaId"), handler.UpdateCinemaOperatingHours)
 
 	room := v1.Group("/room", logger.New())
 	room.Get("/", middleware.Protected(), handler.GetRoom)
+	room.Get("/turnaround", middleware.Protected(), handler.GetRoomTurnaround)
+	room.Put("/turnaround", middleware.Protected(), validate.UpdateRoomTurnaround(), handler.UpdateRoomTurnaround)
 	room.Get("/:roomId", middleware.Protected(), handler.GetRoomById)
 	room.Post("/", middleware.Protected(), validate.CreateRoom(), handler.CreateRoom)
 	room.Put("/:roomId", middleware.Protected(), validate.EditRoom("roomId"), handler.EditRoom)
@@ -111,17 +162,36 @@ func SetupRoutes(app *fiber.App) {
 
 	schedule := v1.Group("/schedule", logger.New())
 	schedule.Get("/", middleware.Protected(), handler.GetScheduleTemplate)
+	schedule.Get("/format-rules/default", middleware.Protected(), handler.GetDefaultFormatRules)
 	schedule.Get("/:scheduleTemplateId", middleware.Protected(), validate.ValidScheduleTemplateId, handler.GetScheduleTemplateById)
 	schedule.Post("/", middleware.Protected(), validate.CreateScheduleTemplate(), handler.CreateScheduleTemplate)
 	schedule.Put("/:scheduleTemplateId", middleware.Protected(), validate.UpdateSchedulerTemplate("scheduleTemplateId"), handler.UpdateSchedulerTemplate)
 	schedule.Delete("/:scheduleTemplateId", middleware.Protected(), validate.ValidScheduleTemplateId, handler.DeleteScheduleTemplate)
+	schedule.Get("/:scheduleTemplateId/items", middleware.Protected(), validate.ScheduleTemplateItems("scheduleTemplateId", "itemId"), handler.GetScheduleTemplateItems)
+	schedule.Post("/:scheduleTemplateId/items", middleware.Protected(), validate.ScheduleTemplateItems("scheduleTemplateId", "itemId"), handler.CreateScheduleTemplateItem)
+	schedule.Put("/:scheduleTemplateId/items/:itemId", middleware.Protected(), validate.ScheduleTemplateItems("scheduleTemplateId", "itemId"), handler.UpdateScheduleTemplateItem)
+	schedule.Delete("/:scheduleTemplateId/items/:itemId", middleware.Protected(), validate.ScheduleTemplateItems("scheduleTemplateId", "itemId"), handler.DeleteScheduleTemplateItem)
+	schedule.Post("/:scheduleTemplateId/apply", middleware.Protected(), validate.AutoSchedule("scheduleTemplateId"), handler.AutoSchedule)
 
 	holidays := v1.Group("/holidays", logger.New())
 	holidays.Get("/", middleware.Protected(), handler.GetHoliday)
 	holidays.Post("/", middleware.Protected(), validate.CreateHoliday(), handler.CreateHoliday)
+	holidays.Get("/export", middleware.Protected(), handler.ExportHoliday)
+	holidays.Post("/import/preview", middleware.Protected(), validate.ImportHoliday(), handler.PreviewImportHoliday)
+	holidays.Post("/import", middleware.Protected(), validate.ImportHoliday(), handler.ImportHoliday)
 	holidays.Put("/:holidayId", middleware.Protected(), validate.UpdateHoliday("holidayId"), handler.UpdateHoliday)
 	holidays.Delete("/:id", middleware.Protected(), handler.DeleteHoliday)
 
+	calendar := v1.Group("/calendar", logger.New())
+	calendar.Get("/", middleware.Protected(), validate.Calendar(), handler.GetCalendar)
+
+	specialEvents := v1.Group("/special-events", logger.New())
+	specialEvents.Get("/", middleware.Protected(), handler.GetSpecialEvents)
+	specialEvents.Get("/:eventId", middleware.Protected(), validate.SpecialEvent("eventId"), handler.GetSpecialEventById)
+	specialEvents.Post("/", middleware.Protected(), validate.CreateSpecialEvent(), handler.CreateSpecialEvent)
+	specialEvents.Put("/:eventId", middleware.Protected(), validate.SpecialEvent("eventId"), handler.UpdateSpecialEvent)
+	specialEvents.Delete("/:eventId", middleware.Protected(), validate.SpecialEvent("eventId"), handler.DeleteSpecialEvent)
+
 	showtime := v1.Group("/showtime", logger.New())
 	showtime.Get("/", middleware.Protected(), handler.GetShowtime)
 	showtime.Post("/create-ticket", middleware.Protected(), validate.CreateTicket(), handler.CreateTicket)
@@ -132,18 +202,68 @@ func SetupRoutes(app *fiber.App) {
 	showtime.Get("/:id/seats", middleware.Protected(), handler.GetShowtimeSeatMap)
 	showtime.Post("/", middleware.Protected(), validate.CreateShowtimeBatch(), handler.CreateShowtimeBatch)
 	showtime.Post("/auto-generate", middleware.Protected(), validate.AutoGenerateShowtimeSchedule(), handler.AutoGenerateShowtimeSchedule)
+	showtime.Post("/auto-plan", middleware.Protected(), validate.PlanCinemaSchedule(), handler.PlanCinemaSchedule)
+	showtime.Post("/copy", middleware.Protected(), validate.CopySchedule(), handler.CopySchedule)
+	showtime.Post("/:showtimeId/cancel", middleware.Protected(), validate.CancelShowtime("showtimeId"), handler.CancelShowtime)
+	showtime.Post("/:showtimeId/move", middleware.Protected(), validate.MoveShowtime("showtimeId"), handler.MoveShowtime)
 	showtime.Put("/:showtimeId", middleware.Protected(), validate.EditShowtime("showtimeId"), handler.EditShowtime)
 	showtime.Delete("/:showtimeId", middleware.Protected(), validate.DeleteShowtime("showtimeId"), handler.DeleteShowtime)
 
 	statistic := v1.Group("/statistic", logger.New())
 	statistic.Get("/", middleware.Protected(), handler.GetAdminStats)
+
+	comp := v1.Group("/comp-tickets", logger.New())
+	comp.Get("/usage", middleware.Protected(), handler.GetCompUsage)
+	comp.Put("/policy/:cinemaId", middleware.Protected(), validate.UpdateCompPolicy("cinemaId"), handler.UpdateCompPolicy)
+	comp.Get("/requests", middleware.Protected(), handler.GetCompRequests)
+	comp.Post("/requests", middleware.Protected(), validate.CreateCompRequest(), handler.CreateCompRequest)
+	comp.Get("/requests/:requestId", middleware.Protected(), validate.CompRequest("requestId"), handler.GetCompRequestById)
+	comp.Put("/requests/:requestId", middleware.Protected(), validate.CompRequest("requestId"), handler.ReviewCompRequest)
+
 	report := v1.Group("/report", logger.New())
 	report.Get("/dashboard", middleware.Protected(), handler.DashboardReport)
 	report.Get("/no-show", middleware.Protected(), handler.NoShowDetailReport)
 
 	report.Get("/check-in", middleware.Protected(), handler.StaffCheckInReport)
+	report.Get("/box-office", middleware.Protected(), handler.BoxOfficeTenderReport)
+	report.Get("/cash-drawer", middleware.Protected(), handler.CashDrawerVarianceReport)
 	report.Get("/check-in-detail/:staffid", middleware.Protected(), handler.StaffCheckInDetailReport)
 	report.Get("/no-show-ticket", middleware.Protected(), handler.NoShowTicketReport)
+	report.Get("/film-settlement", middleware.Protected(), validate.FilmSettlement(), handler.FilmSettlementReport)
+
+	distributors := v1.Group("/distributors", logger.New())
+	distributors.Get("/", middleware.Protected(), handler.GetDistributors)
+	distributors.Get("/:distributorId", middleware.Protected(), validate.Distributor("distributorId"), handler.GetDistributorById)
+	distributors.Post("/", middleware.Protected(), validate.CreateDistributor(), handler.CreateDistributor)
+	distributors.Put("/:distributorId", middleware.Protected(), validate.Distributor("distributorId"), handler.UpdateDistributor)
+	distributors.Delete("/:distributorId", middleware.Protected(), validate.Distributor("distributorId"), handler.DeleteDistributor)
+
+	filmContracts := v1.Group("/film-contracts", logger.New())
+	filmContracts.Get("/", middleware.Protected(), handler.GetFilmContracts)
+	filmContracts.Get("/:contractId", middleware.Protected(), validate.FilmContract("contractId"), handler.GetFilmContractById)
+	filmContracts.Post("/", middleware.Protected(), validate.CreateFilmContract(), handler.CreateFilmContract)
+	filmContracts.Put("/:contractId", middleware.Protected(), validate.FilmContract("contractId"), handler.UpdateFilmContract)
+	filmContracts.Delete("/:contractId", middleware.Protected(), validate.FilmContract("contractId"), handler.DeleteFilmContract)
+
+	concessions := v1.Group("/concessions", logger.New())
+	concessions.Get("/", middleware.Protected(), handler.GetConcessionProducts)
+	concessions.Get("/:productId", middleware.Protected(), validate.ConcessionProduct("productId"), handler.GetConcessionProductById)
+	concessions.Post("/", middleware.Protected(), validate.CreateConcessionProduct(), handler.CreateConcessionProduct)
+	concessions.Put("/:productId", middleware.Protected(), validate.ConcessionProduct("productId"), handler.UpdateConcessionProduct)
+	concessions.Delete("/:productId", middleware.Protected(), validate.ConcessionProduct("productId"), handler.DeleteConcessionProduct)
+	concessions.Get("/:productId/recipe", middleware.Protected(), validate.ConcessionRecipe("productId"), handler.GetConcessionRecipe)
+	concessions.Put("/:productId/recipe", middleware.Protected(), validate.ConcessionRecipe("productId"), handler.UpdateConcessionRecipe)
+
+	inventory := v1.Group("/inventory", logger.New())
+	inventory.Get("/", middleware.Protected(), handler.GetInventoryItems)
+	inventory.Get("/low-stock", middleware.Protected(), handler.GetLowStockAlerts)
+	inventory.Get("/movements", middleware.Protected(), handler.GetStockMovements)
+	inventory.Get("/report", middleware.Protected(), validate.StockReport(), handler.StockReport)
+	inventory.Get("/:itemId", middleware.Protected(), validate.InventoryItem("itemId"), handler.GetInventoryItemById)
+	inventory.Post("/", middleware.Protected(), validate.CreateInventoryItem(), handler.CreateInventoryItem)
+	inventory.Put("/:itemId", middleware.Protected(), validate.InventoryItem("itemId"), handler.UpdateInventoryItem)
+	inventory.Delete("/:itemId", middleware.Protected(), validate.InventoryItem("itemId"), handler.DeleteInventoryItem)
+	inventory.Post("/:itemId/movements", middleware.Protected(), validate.InventoryItem("itemId"), handler.CreateStockMovement)
 	// Public
 
 	// ROUTES
@@ -162,6 +282,8 @@ func SetupRoutes(app *fiber.App) {
 	rap.Get("/dia-chi", middleware.OptionalJWT(), middleware.OptionalAuth(), handler.GetCinemaProvinces)
 	rap.Get("/:slug", middleware.OptionalJWT(), middleware.OptionalAuth(), handler.GetCinemaDetail)
 	rap.Get("/:slug/lich-chieu", middleware.OptionalJWT(), middleware.OptionalAuth(), handler.GetShowtimeByCinemaId)
+	rap.Get("/:slug/lich-chieu/ical", handler.GetCinemaShowtimeFeed)
+	rap.Get("/:slug/bap-nuoc", middleware.OptionalJWT(), middleware.OptionalAuth(), handler.GetCinemaConcessions)
 	phim := v1.Group("/phim")
 	phim.Get("/search", middleware.OptionalJWT(), middleware.OptionalAuth(), handler.SearchMovies)
 	phim.Get("/dang-chieu", middleware.OptionalJWT(), middleware.OptionalAuth(), handler.GetMovieNowShowing)
@@ -179,6 +301,7 @@ func SetupRoutes(app *fiber.App) {
 	lichchieu.Post("/:code/giu-ghe", middleware.OptionalJWT(), middleware.OptionalAuth(), handler.HoldSeat)
 	lichchieu.Post("/:code/tra-ghe", middleware.OptionalJWT(), middleware.OptionalAuth(), handler.ReleaseSeat)
 	lichchieu.Get("/:code/ghe-giu", middleware.OptionalJWT(), middleware.OptionalAuth(), handler.GetHeldSeatsBySession)
+	lichchieu.Get("/:code/loai-ve", middleware.OptionalJWT(), middleware.OptionalAuth(), handler.GetShowtimeTicketCategories)
 	lichchieu.Post("/:code/thanh-toan", middleware.OptionalJWT(), middleware.OptionalAuth(), handler.PurchaseSeats)
 
 	lichchieu.Get("/ghe/:showtimeId", middleware.OptionalJWT(), middleware.OptionalAuth(), websocket.New(handler.SeatWebsocket))
@@ -193,8 +316,16 @@ func SetupRoutes(app *fiber.App) {
 	khachhang.Post("/refresh-token", handler.RefreshCustomerToken)
 	khachhang.Post("/login", handler.CustomerLogin)
 	khachhang.Get("/me", middleware.OptionalJWT(), middleware.OptionalAuth(), handler.GetCurrentCustomer)
+	khachhang.Get("/me/hang-thanh-vien", middleware.OptionalJWT(), middleware.RequireAuth(), middleware.OptionalAuth(), handler.GetMyTierProgress)
+	khachhang.Get("/me/vi", middleware.OptionalJWT(), middleware.RequireAuth(), middleware.OptionalAuth(), handler.GetMyWallet)
+	khachhang.Put("/me/ngay-sinh", middleware.OptionalJWT(), middleware.RequireAuth(), middleware.OptionalAuth(), validate.UpdateMyDateOfBirth(), handler.UpdateMyDateOfBirth)
+	khachhang.Post("/me/vi/nap-the", middleware.OptionalJWT(), middleware.RequireAuth(), middleware.OptionalAuth(), validate.GiftCardCredential(), handler.LoadGiftCardToWallet)
+	khachhang.Get("/me/diem", middleware.OptionalJWT(), middleware.RequireAuth(), middleware.OptionalAuth(), validate.LoyaltyHistory(), handler.GetMyLoyaltyHistory)
 	khachhang.Post("/register", validate.RegisterCustomer(), handler.RegisterCustomer)
 	khachhang.Post("/change-password", middleware.OptionalJWT(), middleware.RequireAuth(), middleware.OptionalAuth(), validate.ChangePasswordCustomer(), handler.ChangePasswordCustomer)
 	khachhang.Post("/forgot-password", middleware.OptionalJWT(), middleware.OptionalAuth(), validate.ForgetPassword(), handler.ForgotPassword)
 	khachhang.Post("/reset-password", middleware.OptionalJWT(), middleware.OptionalAuth(), validate.RestPassword(), handler.ResetPassword)
+	khachhang.Get("/lich-ve", middleware.OptionalJWT(), middleware.RequireAuth(), middleware.OptionalAuth(), handler.GetCustomerFeedUrl)
+	khachhang.Post("/lich-ve/doi-link", middleware.OptionalJWT(), middleware.RequireAuth(), middleware.OptionalAuth(), handler.ResetCustomerFeedUrl)
+	khachhang.Get("/lich-ve/:token", handler.GetCustomerTicketFeed)
 }
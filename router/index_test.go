@@ -0,0 +1,52 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func signTestToken(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("không ký được token: %v", err)
+	}
+	return token
+}
+
+func TestStaffCancelOrderRoute(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	app := fiber.New()
+	SetupRoutes(app)
+
+	customerToken := signTestToken(t, "test-secret", jwt.MapClaims{"username": "khach", "customerId": 7, "accountId": 0})
+	forgedToken := signTestToken(t, "other-secret", jwt.MapClaims{"username": "staff", "accountId": 3})
+
+	cases := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"Thiếu token", "", fiber.StatusUnauthorized},
+		{"Token sai chữ ký", forgedToken, fiber.StatusUnauthorized},
+		{"Token khách hàng không được hủy tại quầy", customerToken, fiber.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, "/api/v1/staff/orders/cancel?orderCode=ORD-TEST", nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Errorf("POST /api/v1/staff/orders/cancel = %d, muốn %d", resp.StatusCode, tc.status)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ type OrderConfirmationData struct {
 	MovieName     string
 	Showtime      string
 	Seats         string
+	Concessions   string // "2 x Combo Couple, 1 x Pepsi L"
 	TotalAmount   float64
 	PaymentMethod string
 	DetailLink    string
@@ -24,6 +25,26 @@ type OrderConfirmationData struct {
 	CancelledAt   string
 }
 
+// ShowtimeChangeData dữ liệu cho email thông báo suất chiếu bị hủy / thay đổi
+type ShowtimeChangeData struct {
+	CustomerName string
+	OrderCode    string
+	MovieName    string
+	CinemaName   string
+	RoomName     string
+	Showtime     string
+	Seats        string
+	TotalAmount  float64
+	RefundAmount float64
+	Reason       string
+	// Chỉ dùng khi dời suất chiếu
+	OldShowtime string
+	OldRoomName string
+	OldSeats    string
+	DetailLink  string
+	CancelLink  string
+}
+
 // SendOrderConfirmationEmail gửi email xác nhận đơn hàng (async)
 func SendOrderConfirmationEmail(to string, data OrderConfirmationData) {
 	go func() { // Async để không delay response
@@ -60,3 +81,50 @@ func SendOrderConfirmationEmail(to string, data OrderConfirmationData) {
 		}
 	}()
 }
+
+// CashVarianceData dữ liệu cho email báo lệch két gửi quản lý rạp
+type CashVarianceData struct {
+	CinemaName     string
+	StaffName      string
+	SessionId      uint
+	OpenedAt       string
+	ClosedAt       string
+	OpeningFloat   float64
+	CashSales      float64
+	CashRefunds    float64
+	Payouts        float64
+	ExpectedAmount float64
+	CountedAmount  float64
+	Variance       float64
+	CloseNote      string
+}
+
+// SendCashVarianceEmail gửi báo cáo lệch két khi đóng ca cho quản lý rạp (async)
+func SendCashVarianceEmail(to []string, data CashVarianceData) {
+	go func() {
+		tmpl, err := template.ParseFiles("templates/cash_variance.html")
+		if err != nil {
+			log.Printf("Lỗi load template email lệch két: %v", err)
+			return
+		}
+
+		var body bytes.Buffer
+		if err := tmpl.Execute(&body, data); err != nil {
+			log.Printf("Lỗi render template email lệch két: %v", err)
+			return
+		}
+
+		port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+
+		m := gomail.NewMessage()
+		m.SetHeader("From", os.Getenv("SMTP_FROM"))
+		m.SetHeader("To", to...)
+		m.SetHeader("Subject", "Lệch két ca thu ngân #"+strconv.FormatUint(uint64(data.SessionId), 10)+" - "+data.CinemaName)
+		m.SetBody("text/html", body.String())
+
+		d := gomail.NewDialer(os.Getenv("SMTP_HOST"), port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+		if err := d.DialAndSend(m); err != nil {
+			log.Printf("Lỗi gửi email lệch két: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// iCalendar (RFC 5545): chỉ hỗ trợ phần VEVENT cần cho ngày lễ và lịch chiếu
+const ICalProdID = "-//Cinema Manager//VI"
+
+type ICalEvent struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	URL          string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool   // DTSTART dạng VALUE=DATE
+	RRule        string // VD "FREQ=YEARLY"
+	Status       string // CONFIRMED / TENTATIVE / CANCELLED
+	Sequence     int
+	LastModified time.Time
+	Extra        map[string]string // thuộc tính mở rộng X-...
+}
+
+// BuildICal tạo nội dung file .ics (CRLF, gập dòng 75 octet) từ danh sách sự kiện
+func BuildICal(calendarName string, events []ICalEvent) string {
+	var b strings.Builder
+	write := func(line string) {
+		b.WriteString(foldICalLine(line))
+		b.WriteString("\r\n")
+	}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	write("BEGIN:VCALENDAR")
+	write("VERSION:2.0")
+	write("PRODID:" + ICalProdID)
+	write("CALSCALE:GREGORIAN")
+	write("METHOD:PUBLISH")
+	if calendarName != "" {
+		write("X-WR-CALNAME:" + EscapeICalText(calendarName))
+	}
+	for _, e := range events {
+		write("BEGIN:VEVENT")
+		write("UID:" + e.UID)
+		write("DTSTAMP:" + stamp)
+		if e.AllDay {
+			write("DTSTART;VALUE=DATE:" + e.Start.Format("20060102"))
+			end := e.End
+			if end.IsZero() {
+				end = e.Start.AddDate(0, 0, 1)
+			}
+			write("DTEND;VALUE=DATE:" + end.Format("20060102"))
+		} else {
+			write("DTSTART:" + e.Start.UTC().Format("20060102T150405Z"))
+			if !e.End.IsZero() {
+				write("DTEND:" + e.End.UTC().Format("20060102T150405Z"))
+			}
+		}
+		if e.RRule != "" {
+			write("RRULE:" + e.RRule)
+		}
+		write("SUMMARY:" + EscapeICalText(e.Summary))
+		if e.Description != "" {
+			write("DESCRIPTION:" + EscapeICalText(e.Description))
+		}
+		if e.Location != "" {
+			write("LOCATION:" + EscapeICalText(e.Location))
+		}
+		if e.URL != "" {
+			write("URL:" + e.URL)
+		}
+		if e.Status != "" {
+			write("STATUS:" + e.Status)
+		}
+		write(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+		if !e.LastModified.IsZero() {
+			write("LAST-MODIFIED:" + e.LastModified.UTC().Format("20060102T150405Z"))
+		}
+		keys := make([]string, 0, len(e.Extra))
+		for k := range e.Extra {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			write(k + ":" + EscapeICalText(e.Extra[k]))
+		}
+		write("END:VEVENT")
+	}
+	write("END:VCALENDAR")
+	return b.String()
+}
+
+// ParseICal đọc các VEVENT trong file .ics; ngày giờ không có múi giờ (hoặc TZID không nhận ra) hiểu theo loc
+func ParseICal(data string, loc *time.Location) ([]ICalEvent, error) {
+	lines := unfoldICalLines(data)
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, errors.New("file không phải định dạng iCalendar (thiếu BEGIN:VCALENDAR)")
+	}
+
+	events := []ICalEvent{}
+	var current *ICalEvent
+	for _, line := range lines {
+		name, params, value, ok := splitICalLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current = &ICalEvent{Extra: map[string]string{}}
+			continue
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current != nil {
+				events = append(events, *current)
+			}
+			current = nil
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		switch name {
+		case "UID":
+			current.UID = value
+		case "SUMMARY":
+			current.Summary = UnescapeICalText(value)
+		case "DESCRIPTION":
+			current.Description = UnescapeICalText(value)
+		case "LOCATION":
+			current.Location = UnescapeICalText(value)
+		case "URL":
+			current.URL = value
+		case "STATUS":
+			current.Status = strings.ToUpper(value)
+		case "RRULE":
+			current.RRule = strings.ToUpper(value)
+		case "SEQUENCE":
+			fmt.Sscanf(value, "%d", &current.Sequence)
+		case "DTSTART":
+			current.Start, current.AllDay = parseICalTime(value, params, loc)
+		case "DTEND":
+			current.End, _ = parseICalTime(value, params, loc)
+		case "LAST-MODIFIED":
+			current.LastModified, _ = parseICalTime(value, params, loc)
+		default:
+			if strings.HasPrefix(name, "X-") {
+				current.Extra[name] = UnescapeICalText(value)
+			}
+		}
+	}
+	return events, nil
+}
+
+// ICalRRuleParts tách RRULE thành map, VD "FREQ=YEARLY;RSCALE=CHINESE" → {FREQ: YEARLY, RSCALE: CHINESE}
+func ICalRRuleParts(rrule string) map[string]string {
+	parts := map[string]string{}
+	for _, part := range strings.Split(rrule, ";") {
+		if kv := strings.SplitN(part, "=", 2); len(kv) == 2 {
+			parts[strings.ToUpper(strings.TrimSpace(kv[0]))] = strings.ToUpper(strings.TrimSpace(kv[1]))
+		}
+	}
+	return parts
+}
+
+func EscapeICalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+func UnescapeICalText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+// foldICalLine gập dòng dài hơn 75 octet, không cắt giữa ký tự UTF-8
+func foldICalLine(line string) string {
+	if len(line) <= 75 {
+		return line
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+func unfoldICalLines(data string) []string {
+	lines := []string{}
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitICalLine tách "NAME;PARAM=X:VALUE" thành tên (viết hoa), tham số và giá trị
+func splitICalLine(line string) (string, map[string]string, string, bool) {
+	idx := strings.Index(line, ":")
+	if idx < 0 {
+		return "", nil, "", false
+	}
+	head, value := line[:idx], line[idx+1:]
+	fields := strings.Split(head, ";")
+	params := map[string]string{}
+	for _, p := range fields[1:] {
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return strings.ToUpper(fields[0]), params, value, true
+}
+
+func parseICalTime(value string, params map[string]string, loc *time.Location) (time.Time, bool) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return time.Time{}, true
+		}
+		return t, true
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, _ := time.Parse("20060102T150405Z", value)
+		return t, false
+	}
+	if tzid, ok := params["TZID"]; ok {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, _ := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false
+}
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"math"
+	"time"
+)
+
+// Âm lịch Việt Nam theo thuật toán thiên văn của Hồ Ngọc Đức: tháng âm bắt đầu vào ngày Sóc
+// (trăng mới), tháng 11 âm luôn chứa Đông chí, năm có 13 tháng thì tháng nhuận là tháng đầu tiên
+// không chứa trung khí. Tính theo múi giờ Việt Nam (UTC+7), dùng cho khoảng năm 1900–2100.
+const lunarTimeZone = 7.0
+
+// jdFromDate: số ngày Julius của ngày dương lịch
+func jdFromDate(dd, mm, yy int) int {
+	a := (14 - mm) / 12
+	y := yy + 4800 - a
+	m := mm + 12*a - 3
+	jd := dd + (153*m+2)/5 + 365*y + y/4 - y/100 + y/400 - 32045
+	if jd < 2299161 {
+		jd = dd + (153*m+2)/5 + 365*y + y/4 - 32083
+	}
+	return jd
+}
+
+// jdToDate: ngày dương lịch của số ngày Julius
+func jdToDate(jd int) (int, int, int) {
+	var a, b, c int
+	if jd > 2299160 {
+		a = jd + 32044
+		b = (4*a + 3) / 146097
+		c = a - b*146097/4
+	} else {
+		b = 0
+		c = jd + 32082
+	}
+	d := (4*c + 3) / 1461
+	e := c - 1461*d/4
+	m := (5*e + 2) / 153
+	day := e - (153*m+2)/5 + 1
+	month := m + 3 - 12*(m/10)
+	year := b*100 + d - 4800 + m/10
+	return day, month, year
+}
+
+// newMoonDay: ngày (số Julius) của lần Sóc thứ k tính từ 1/1/1900
+func newMoonDay(k int) int {
+	kf := float64(k)
+	T := kf / 1236.85
+	T2 := T * T
+	T3 := T2 * T
+	dr := math.Pi / 180
+	Jd1 := 2415020.75933 + 29.53058868*kf + 0.0001178*T2 - 0.000000155*T3
+	Jd1 += 0.00033 * math.Sin((166.56+132.87*T-0.009173*T2)*dr)
+	M := 359.2242 + 29.10535608*kf - 0.0000333*T2 - 0.00000347*T3
+	Mpr := 306.0253 + 385.81691806*kf + 0.0107306*T2 + 0.00001236*T3
+	F := 21.2964 + 390.67050646*kf - 0.0016528*T2 - 0.00000239*T3
+	C1 := (0.1734-0.000393*T)*math.Sin(M*dr) + 0.0021*math.Sin(2*dr*M)
+	C1 = C1 - 0.4068*math.Sin(Mpr*dr) + 0.0161*math.Sin(dr*2*Mpr)
+	C1 = C1 - 0.0004*math.Sin(dr*3*Mpr)
+	C1 = C1 + 0.0104*math.Sin(dr*2*F) - 0.0051*math.Sin(dr*(M+Mpr))
+	C1 = C1 - 0.0074*math.Sin(dr*(M-Mpr)) + 0.0004*math.Sin(dr*(2*F+M))
+	C1 = C1 - 0.0004*math.Sin(dr*(2*F-M)) - 0.0006*math.Sin(dr*(2*F+Mpr))
+	C1 = C1 + 0.0010*math.Sin(dr*(2*F-Mpr)) + 0.0005*math.Sin(dr*(2*Mpr+M))
+	var deltat float64
+	if T < -11 {
+		deltat = 0.001 + 0.000839*T + 0.0002261*T2 - 0.00000845*T3 - 0.000000081*T*T3
+	} else {
+		deltat = -0.000278 + 0.000265*T + 0.000262*T2
+	}
+	JdNew := Jd1 + C1 - deltat
+	return int(math.Floor(JdNew + 0.5 + lunarTimeZone/24))
+}
+
+// sunLongitude: cung hoàng đạo (0–11, mỗi cung 30°) của Mặt Trời lúc 0h ngày jdn
+func sunLongitude(jdn int) int {
+	T := (float64(jdn) - 2451545.5 - lunarTimeZone/24) / 36525
+	T2 := T * T
+	dr := math.Pi / 180
+	M := 357.52910 + 35999.05030*T - 0.0001559*T2 - 0.00000048*T*T2
+	L0 := 280.46645 + 36000.76983*T + 0.0003032*T2
+	DL := (1.914600-0.004817*T-0.000014*T2)*math.Sin(dr*M) +
+		(0.019993-0.000101*T)*math.Sin(dr*2*M) + 0.000290*math.Sin(dr*3*M)
+	L := (L0 + DL) * dr
+	L = L - math.Pi*2*math.Floor(L/(math.Pi*2))
+	return int(math.Floor(L / math.Pi * 6))
+}
+
+// lunarMonth11: ngày bắt đầu tháng 11 âm lịch (tháng chứa Đông chí) của năm dương yy
+func lunarMonth11(yy int) int {
+	off := jdFromDate(31, 12, yy) - 2415021
+	k := int(math.Floor(float64(off) / 29.530588853))
+	nm := newMoonDay(k)
+	if sunLongitude(nm) >= 9 {
+		nm = newMoonDay(k - 1)
+	}
+	return nm
+}
+
+// leapMonthOffset: vị trí tháng nhuận tính từ tháng 11 âm (a11)
+func leapMonthOffset(a11 int) int {
+	k := int(math.Floor((float64(a11)-2415021.076998695)/29.530588853 + 0.5))
+	last := 0
+	i := 1
+	arc := sunLongitude(newMoonDay(k + i))
+	for {
+		last = arc
+		i++
+		arc = sunLongitude(newMoonDay(k + i))
+		if arc == last || i >= 14 {
+			break
+		}
+	}
+	return i - 1
+}
+
+// SolarToLunar đổi ngày dương lịch sang âm lịch Việt Nam (chỉ dùng phần ngày/tháng/năm của t)
+func SolarToLunar(t time.Time) (lunarYear, lunarMonth, lunarDay int, isLeap bool) {
+	dd, mm, yy := t.Day(), int(t.Month()), t.Year()
+	dayNumber := jdFromDate(dd, mm, yy)
+	k := int(math.Floor((float64(dayNumber) - 2415021.076998695) / 29.530588853))
+	monthStart := newMoonDay(k + 1)
+	for monthStart > dayNumber {
+		k--
+		monthStart = newMoonDay(k + 1)
+	}
+	a11 := lunarMonth11(yy)
+	b11 := a11
+	if a11 >= monthStart {
+		lunarYear = yy
+		a11 = lunarMonth11(yy - 1)
+	} else {
+		lunarYear = yy + 1
+		b11 = lunarMonth11(yy + 1)
+	}
+	lunarDay = dayNumber - monthStart + 1
+	diff := (monthStart - a11) / 29
+	lunarMonth = diff + 11
+	if b11-a11 > 365 {
+		leapMonthDiff := leapMonthOffset(a11)
+		if diff >= leapMonthDiff {
+			lunarMonth = diff + 10
+			if diff == leapMonthDiff {
+				isLeap = true
+			}
+		}
+	}
+	if lunarMonth > 12 {
+		lunarMonth -= 12
+	}
+	if lunarMonth >= 11 && diff < 4 {
+		lunarYear--
+	}
+	return
+}
+
+// LunarToSolar đổi ngày âm lịch sang dương lịch (00:00 theo loc). ok = false nếu ngày âm không tồn tại
+// (VD tháng nhuận không có trong năm đó, hoặc ngày 30 của tháng thiếu).
+func LunarToSolar(lunarYear, lunarMonth, lunarDay int, isLeap bool, loc *time.Location) (time.Time, bool) {
+	var a11, b11 int
+	if lunarMonth < 11 {
+		a11 = lunarMonth11(lunarYear - 1)
+		b11 = lunarMonth11(lunarYear)
+	} else {
+		a11 = lunarMonth11(lunarYear)
+		b11 = lunarMonth11(lunarYear + 1)
+	}
+	k := int(math.Floor(0.5 + (float64(a11)-2415021.076998695)/29.530588853))
+	off := lunarMonth - 11
+	if off < 0 {
+		off += 12
+	}
+	if b11-a11 > 365 {
+		leapOff := leapMonthOffset(a11)
+		leapMonth := leapOff - 2
+		if leapMonth < 0 {
+			leapMonth += 12
+		}
+		if isLeap && lunarMonth != leapMonth {
+			return time.Time{}, false
+		} else if isLeap || off >= leapOff {
+			off++
+		}
+	} else if isLeap {
+		return time.Time{}, false
+	}
+	monthStart := newMoonDay(k + off)
+	if lunarDay < 1 || lunarDay > lunarMonthLength(monthStart, k+off) {
+		return time.Time{}, false
+	}
+	dd, mm, yy := jdToDate(monthStart + lunarDay - 1)
+	return time.Date(yy, time.Month(mm), dd, 0, 0, 0, 0, loc), true
+}
+
+// lunarMonthLength: số ngày (29/30) của tháng âm bắt đầu tại monthStart (lần Sóc thứ k)
+func lunarMonthLength(monthStart, k int) int {
+	return newMoonDay(k+1) - monthStart
+}
+
+// IsLastLunarDay cho biết t có phải ngày cuối của tháng âm (29 hoặc 30)
+func IsLastLunarDay(t time.Time) bool {
+	_, _, nextDay, _ := SolarToLunar(t.AddDate(0, 0, 1))
+	return nextDay == 1
+}
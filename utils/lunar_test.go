@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+var lunarCases = []struct {
+	name  string
+	solar string
+	year  int
+	month int
+	day   int
+	leap  bool
+}{
+	{"Tết Ất Tỵ 2025", "2025-01-29", 2025, 1, 1, false},
+	{"Tết Giáp Thìn 2024", "2024-02-10", 2024, 1, 1, false},
+	{"Tết Quý Mão 2023", "2023-01-22", 2023, 1, 1, false},
+	{"Giao thừa 2025 (29 tháng Chạp)", "2025-01-28", 2024, 12, 29, false},
+	{"Mùng 1 tháng 2 nhuận 2023", "2023-03-22", 2023, 2, 1, true},
+	{"Mùng 1 tháng 2 thường 2023", "2023-02-20", 2023, 2, 1, false},
+	{"Giỗ Tổ Hùng Vương 2025", "2025-04-07", 2025, 3, 10, false},
+	{"Trung thu 2024", "2024-09-17", 2024, 8, 15, false},
+	{"Tháng 11 âm vắt sang năm dương", "2024-12-31", 2024, 12, 1, false},
+}
+
+func TestSolarToLunar(t *testing.T) {
+	for _, tc := range lunarCases {
+		t.Run(tc.name, func(t *testing.T) {
+			solar, _ := time.Parse("2006-01-02", tc.solar)
+			year, month, day, leap := SolarToLunar(solar)
+			if year != tc.year || month != tc.month || day != tc.day || leap != tc.leap {
+				t.Errorf("SolarToLunar(%s) = %d/%d/%d leap=%v, muốn %d/%d/%d leap=%v",
+					tc.solar, day, month, year, leap, tc.day, tc.month, tc.year, tc.leap)
+			}
+		})
+	}
+}
+
+func TestLunarToSolar(t *testing.T) {
+	for _, tc := range lunarCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := LunarToSolar(tc.year, tc.month, tc.day, tc.leap, time.UTC)
+			if !ok || got.Format("2006-01-02") != tc.solar {
+				t.Errorf("LunarToSolar(%d/%d/%d leap=%v) = %s ok=%v, muốn %s",
+					tc.day, tc.month, tc.year, tc.leap, got.Format("2006-01-02"), ok, tc.solar)
+			}
+		})
+	}
+}
+
+func TestLunarToSolarInvalid(t *testing.T) {
+	cases := []struct {
+		name             string
+		year, month, day int
+		leap             bool
+	}{
+		{"Năm 2024 không có tháng nhuận", 2024, 2, 1, true},
+		{"Tháng 2 nhuận 2023 là tháng thiếu", 2023, 2, 30, true},
+		{"Tháng Chạp 2024 là tháng thiếu", 2024, 12, 30, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, ok := LunarToSolar(tc.year, tc.month, tc.day, tc.leap, time.UTC); ok {
+				t.Errorf("LunarToSolar(%d/%d/%d leap=%v) = %s, muốn không tồn tại",
+					tc.day, tc.month, tc.year, tc.leap, got.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestIsLastLunarDay(t *testing.T) {
+	cases := map[string]bool{
+		"2025-01-28": true,  // 29 tháng Chạp Giáp Thìn (tháng thiếu)
+		"2025-01-29": false, // mùng 1 Tết
+		"2025-01-27": false,
+	}
+	for date, want := range cases {
+		solar, _ := time.Parse("2006-01-02", date)
+		if got := IsLastLunarDay(solar); got != want {
+			t.Errorf("IsLastLunarDay(%s) = %v, muốn %v", date, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"cinema_manager/config"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// QRTokenPrefix: tiền tố nhận diện QR vé có chữ ký (phiên bản 1)
+const QRTokenPrefix = "CQR1."
+
+var ErrQRInvalid = errors.New("mã QR không hợp lệ hoặc đã bị chỉnh sửa")
+
+// QRTicketPayload: nội dung QR vé được ký Ed25519; máy soát vé kiểm tra chữ ký offline bằng khóa công khai
+type QRTicketPayload struct {
+	OrderCode    string   `json:"o"`
+	Tickets      []string `json:"t"`
+	ShowtimeCode string   `json:"s"`
+	StartTime    int64    `json:"st"`  // unix giây
+	ExpiresAt    int64    `json:"exp"` // unix giây, hết suất chiếu lúc ký (cho máy soát vé offline)
+	KeyId        string   `json:"kid"`
+}
+
+// ErrQRKeyNotLoaded: chưa gọi InitQRSigningKey (hoặc khóa lỗi) nên không ký / kiểm tra được QR
+var ErrQRKeyNotLoaded = errors.New("chưa nạp khóa ký QR (QR_SIGNING_KEY)")
+
+var (
+	qrPrivateKey ed25519.PrivateKey
+	qrKeyId      string
+)
+
+// InitQRSigningKey nạp seed Ed25519 (32 byte, base64) từ QR_SIGNING_KEY, gọi một lần khi khởi động.
+// Không có khóa tạm: khóa đổi sau mỗi lần khởi động sẽ làm mọi QR đã gửi khách và khóa công khai
+// trên máy soát vé mất hiệu lực, nên thiếu hoặc sai khóa thì server không được chạy.
+// Tạo khóa mới: head -c 32 /dev/urandom | base64
+func InitQRSigningKey() error {
+	raw := strings.TrimSpace(config.Config("QR_SIGNING_KEY"))
+	if raw == "" {
+		return errors.New("QR_SIGNING_KEY chưa được cấu hình")
+	}
+	seed, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return fmt.Errorf("QR_SIGNING_KEY phải là %d byte mã hóa base64", ed25519.SeedSize)
+	}
+	qrPrivateKey = ed25519.NewKeyFromSeed(seed)
+	sum := sha256.Sum256(qrPrivateKey.Public().(ed25519.PublicKey))
+	qrKeyId = hex.EncodeToString(sum[:4])
+	return nil
+}
+
+// QRPublicKey: khóa công khai (base64) và mã khóa để công bố cho máy soát vé
+func QRPublicKey() (string, string, error) {
+	if qrPrivateKey == nil {
+		return "", "", ErrQRKeyNotLoaded
+	}
+	return base64.StdEncoding.EncodeToString(qrPrivateKey.Public().(ed25519.PublicKey)), qrKeyId, nil
+}
+
+// SignQRPayload tạo chuỗi QR dạng CQR1.<payload>.<chữ ký>, cả hai phần base64url không padding
+func SignQRPayload(payload QRTicketPayload) (string, error) {
+	if qrPrivateKey == nil {
+		return "", ErrQRKeyNotLoaded
+	}
+	key, keyId := qrPrivateKey, qrKeyId
+	payload.KeyId = keyId
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(body)
+	signature := ed25519.Sign(key, []byte(encoded))
+	return QRTokenPrefix + encoded + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// IsSignedQR: chuỗi quét được có phải QR vé có chữ ký không
+func IsSignedQR(token string) bool {
+	return strings.HasPrefix(token, QRTokenPrefix)
+}
+
+// VerifyQRToken kiểm tra chữ ký của QR vé, trả về nội dung đã xác thực. Không kiểm tra exp:
+// suất chiếu có thể bị dời sau khi ký, nơi gọi kiểm tra hạn theo giờ chiếu hiện tại.
+func VerifyQRToken(token string) (QRTicketPayload, error) {
+	var payload QRTicketPayload
+	parts := strings.Split(strings.TrimPrefix(token, QRTokenPrefix), ".")
+	if !IsSignedQR(token) || len(parts) != 2 {
+		return payload, ErrQRInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return payload, ErrQRInvalid
+	}
+	if qrPrivateKey == nil {
+		return payload, ErrQRKeyNotLoaded
+	}
+	if !ed25519.Verify(qrPrivateKey.Public().(ed25519.PublicKey), []byte(parts[0]), signature) {
+		return payload, ErrQRInvalid
+	}
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(body, &payload) != nil || payload.OrderCode == "" {
+		return payload, ErrQRInvalid
+	}
+	return payload, nil
+}
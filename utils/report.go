@@ -397,8 +397,7 @@ JOIN showtimes st ON t.showtime_id = st.id
 JOIN movies m ON st.movie_id = m.id
 JOIN rooms r ON st.room_id = r.id
 JOIN cinemas cin ON r.cinema_id = cin.id
-JOIN showtime_seats sts ON t.showtime_seat_id = sts.id
-JOIN seats s ON sts.seat_id = s.id
+JOIN seats s ON t.seat_id = s.id
 WHERE o.status = 'PAID'
   AND o.created_by = 0 
   AND st.start_time >= $1
@@ -500,6 +499,22 @@ type RevenueByCinemaItem struct {
 	AvgTicketPrice float64 `json:"avgTicketPrice"`
 }
 
+// RevenueByCategoryItem: doanh thu theo hạng mục (TICKET hoặc loại bắp nước)
+type RevenueByCategoryItem struct {
+	Category string  `json:"category"`
+	Quantity int64   `json:"quantity"`
+	Revenue  float64 `json:"revenue"`
+	Percent  float64 `json:"percent"`
+}
+
+// CompTicketItem: vé mời theo lý do, tách riêng khỏi doanh thu
+type CompTicketItem struct {
+	ReasonCode string  `json:"reasonCode"`
+	Orders     int64   `json:"orders"`
+	Tickets    int64   `json:"tickets"`
+	FaceValue  float64 `json:"faceValue"`
+}
+
 type OccupancyTrendItem struct {
 	Date string  `json:"date"` // Format: 02/01
 	Rate float64 `json:"rate"`
@@ -511,6 +526,12 @@ type DashboardSummary struct {
 	AvgOccupancy   float64 `json:"avgOccupancy"`
 	TotalCustomers int64   `json:"totalCustomers"`
 
+	TicketRevenue     float64 `json:"ticketRevenue"`
+	ConcessionRevenue float64 `json:"concessionRevenue"`
+
+	CompTickets   int64   `json:"compTickets"`
+	CompFaceValue float64 `json:"compFaceValue"`
+
 	PrevTotalRevenue   float64 `json:"prevTotalRevenue"`
 	PrevTotalTickets   int64   `json:"prevTotalTickets"`
 	PrevAvgOccupancy   float64 `json:"prevAvgOccupancy"`
@@ -523,14 +544,16 @@ type DashboardSummary struct {
 }
 
 type DashboardReport struct {
-	Items          []interface{}         `json:"items"` // Linh hoạt cho top movies/revenue
-	Summary        *DashboardSummary     `json:"summary"`
-	Pagination     *PaginationInfo       `json:"pagination,omitempty"`
-	Trends         []OccupancyTrendItem  `json:"trends,omitempty"`
-	TopMovies      []TopMovieItem        `json:"top_movies"`
-	RevenueCinemas []RevenueByCinemaItem `json:"revenue_cinemas"`
-	DailyMetrics   []DailyMetric         `json:"daily_metrics"`
-	TicketByHours  []TicketByHourItem    `json:"ticket_by_hours"`
+	Items          []interface{}           `json:"items"` // Linh hoạt cho top movies/revenue
+	Summary        *DashboardSummary       `json:"summary"`
+	Pagination     *PaginationInfo         `json:"pagination,omitempty"`
+	Trends         []OccupancyTrendItem    `json:"trends,omitempty"`
+	TopMovies      []TopMovieItem          `json:"top_movies"`
+	RevenueCinemas []RevenueByCinemaItem   `json:"revenue_cinemas"`
+	DailyMetrics   []DailyMetric           `json:"daily_metrics"`
+	TicketByHours  []TicketByHourItem      `json:"ticket_by_hours"`
+	Categories     []RevenueByCategoryItem `json:"revenue_categories"`
+	CompTickets    []CompTicketItem        `json:"comp_tickets"`
 }
 type PrevKPI struct {
 	Revenue   float64
@@ -585,7 +608,7 @@ WITH paid_orders AS (
     JOIN rooms r ON st.room_id = r.id
     JOIN cinemas cin ON r.cinema_id = cin.id
     LEFT JOIN addresses a ON cin.id = a.cinema_id
-    WHERE o.status = 'PAID'
+    WHERE o.status = 'PAID' AND NOT o.is_comp
       AND o.created_at >= $1
       AND o.created_at <= $2
       AND ($3::bigint IS NULL OR cin.id = $3)
@@ -682,7 +705,7 @@ order_movie AS (
     JOIN tickets t ON t.order_id = o.id
     JOIN showtimes st ON st.id = t.showtime_id
     JOIN filtered_showtimes fs ON fs.showtime_id = st.id
-    WHERE o.status = 'PAID'
+    WHERE o.status = 'PAID' AND NOT o.is_comp
 ),
 
 movie_revenue AS (
@@ -698,7 +721,7 @@ movie_tickets AS (
         st.movie_id,
         COUNT(t.id) AS tickets
     FROM tickets t
-    JOIN orders o ON o.id = t.order_id AND o.status = 'PAID'
+    JOIN orders o ON o.id = t.order_id AND o.status = 'PAID' AND NOT o.is_comp
     JOIN showtimes st ON st.id = t.showtime_id
     JOIN filtered_showtimes fs ON fs.showtime_id = st.id
     GROUP BY st.movie_id
@@ -770,7 +793,7 @@ WITH paid_orders AS (
     JOIN rooms r        ON r.id = st.room_id
     JOIN cinemas cin    ON cin.id = r.cinema_id
     LEFT JOIN addresses a ON a.cinema_id = cin.id
-    WHERE o.status = 'PAID'
+    WHERE o.status = 'PAID' AND NOT o.is_comp
       AND st.start_time BETWEEN $1 AND $2
       AND ($3::bigint IS NULL OR cin.id = $3)
       AND ($4::bigint IS NULL OR st.movie_id = $4)
@@ -788,7 +811,7 @@ order_ticket_count AS (
     JOIN rooms r        ON r.id = st.room_id
     JOIN cinemas cin    ON cin.id = r.cinema_id
     LEFT JOIN addresses a ON a.cinema_id = cin.id
-    WHERE o.status = 'PAID'
+    WHERE o.status = 'PAID' AND NOT o.is_comp
       AND st.start_time BETWEEN $1 AND $2
       AND ($3::bigint IS NULL OR cin.id = $3)
       AND ($4::bigint IS NULL OR st.movie_id = $4)
@@ -862,7 +885,7 @@ WITH total_tickets AS (
   SELECT COUNT(t.id) AS total
   FROM tickets t
   JOIN showtimes st ON t.showtime_id = st.id
-  JOIN orders o ON t.order_id = o.id AND o.status = 'PAID'
+  JOIN orders o ON t.order_id = o.id AND o.status = 'PAID' AND NOT o.is_comp
   WHERE st.start_time BETWEEN $1 AND $2
 ),
 hourly_tickets AS (
@@ -878,7 +901,7 @@ hourly_tickets AS (
     COUNT(t.id) AS tickets
   FROM tickets t
   JOIN showtimes st ON t.showtime_id = st.id
-  JOIN orders o ON t.order_id = o.id AND o.status = 'PAID'
+  JOIN orders o ON t.order_id = o.id AND o.status = 'PAID' AND NOT o.is_comp
   WHERE st.start_time BETWEEN $1 AND $2
   GROUP BY time_range
 )
@@ -911,7 +934,7 @@ WITH daily_orders AS (
     LEFT JOIN addresses a ON cin.id = a.cinema_id
     WHERE o.created_at >= $1
       AND o.created_at <= $2
-      AND o.status = 'PAID'
+      AND o.status = 'PAID' AND NOT o.is_comp
       -- thêm lọc cinema/movie/province/search nếu cần
        AND ($3::bigint IS NULL OR cin.id = $3)
     AND ($4::bigint IS NULL OR st.movie_id = $4)
@@ -924,7 +947,7 @@ daily_tickets AS (
         COUNT(DISTINCT t.id) AS tickets
     FROM orders o
     JOIN tickets t ON t.order_id = o.id
-    WHERE o.status = 'PAID'
+    WHERE o.status = 'PAID' AND NOT o.is_comp
       AND o.created_at >= $1
       AND o.created_at <= $2
     GROUP BY DATE(o.created_at)
@@ -976,8 +999,108 @@ ORDER BY DATE(st.start_time)
 		trends = append(trends, OccupancyTrendItem{Date: row.Date, Rate: row.Rate})
 	}
 
+	// 8. Doanh thu theo hạng mục: vé + từng loại bắp nước của các đơn đã thanh toán
+	var concessionCategories []RevenueByCategoryItem
+	categoryQuery := `
+WITH paid_orders AS (
+    SELECT DISTINCT o.id
+    FROM orders o
+    JOIN tickets t ON t.order_id = o.id
+    JOIN showtimes st ON t.showtime_id = st.id
+    JOIN rooms r ON st.room_id = r.id
+    JOIN cinemas cin ON r.cinema_id = cin.id
+    LEFT JOIN addresses a ON cin.id = a.cinema_id
+    WHERE o.status = 'PAID' AND NOT o.is_comp
+      AND o.created_at >= $1
+      AND o.created_at <= $2
+      AND ($3::bigint IS NULL OR cin.id = $3)
+      AND ($4::bigint IS NULL OR st.movie_id = $4)
+      AND ($5::text IS NULL OR $5 = '' OR LOWER(a.province) = LOWER($5))
+      AND ($6::text IS NULL OR $6 = '' OR o.public_code ILIKE '%' || $6 || '%' OR o.customer_name ILIKE '%' || $6 || '%')
+)
+SELECT
+    oc.category,
+    COALESCE(SUM(oc.quantity), 0) AS quantity,
+    COALESCE(SUM(oc.amount), 0) AS revenue
+FROM order_concessions oc
+JOIN paid_orders po ON po.id = oc.order_id
+WHERE oc.status = 'ACTIVE'
+GROUP BY oc.category
+ORDER BY revenue DESC
+`
+	err = db.Raw(categoryQuery, from, to, cinemaID, movieID, province, search).Scan(&concessionCategories).Error
+	if err != nil {
+		return nil, err
+	}
+	concessionRevenue := 0.0
+	for _, item := range concessionCategories {
+		concessionRevenue += item.Revenue
+	}
+	ticketRevenue := kpi.TotalRevenue - concessionRevenue
+	categories := append([]RevenueByCategoryItem{{
+		Category: "TICKET",
+		Quantity: kpi.TicketsSold,
+		Revenue:  ticketRevenue,
+	}}, concessionCategories...)
+	for i := range categories {
+		if kpi.TotalRevenue > 0 {
+			categories[i].Percent = roundFloat(categories[i].Revenue/kpi.TotalRevenue*100, 2)
+		}
+	}
+
+	// 9. Vé mời: không tính vào doanh thu, báo cáo riêng theo lý do và giá trị theo giá bán
+	compTickets := []CompTicketItem{}
+	compQuery := `
+WITH comp_orders AS (
+    SELECT o.id, o.comp_reason, o.comp_value
+    FROM orders o
+    JOIN showtimes st ON o.showtime_id = st.id
+    JOIN rooms r ON st.room_id = r.id
+    JOIN cinemas cin ON r.cinema_id = cin.id
+    LEFT JOIN addresses a ON cin.id = a.cinema_id
+    WHERE o.status = 'PAID'
+      AND o.is_comp
+      AND o.created_at >= $1
+      AND o.created_at <= $2
+      AND ($3::bigint IS NULL OR cin.id = $3)
+      AND ($4::bigint IS NULL OR st.movie_id = $4)
+      AND ($5::text IS NULL OR $5 = '' OR LOWER(a.province) = LOWER($5))
+),
+comp_counts AS (
+    SELECT co.id, co.comp_reason, co.comp_value,
+           COUNT(t.id) AS total,
+           COUNT(t.id) FILTER (WHERE t.status <> 'CANCELLED') AS active
+    FROM comp_orders co
+    JOIN tickets t ON t.order_id = co.id
+    GROUP BY co.id, co.comp_reason, co.comp_value
+)
+SELECT
+    comp_reason AS reason_code,
+    COUNT(*) FILTER (WHERE active > 0) AS orders,
+    COALESCE(SUM(active), 0) AS tickets,
+    COALESCE(SUM(comp_value * active / NULLIF(total, 0)), 0) AS face_value
+FROM comp_counts
+GROUP BY comp_reason
+HAVING SUM(active) > 0
+ORDER BY tickets DESC
+`
+	err = db.Raw(compQuery, from, to, cinemaID, movieID, province).Scan(&compTickets).Error
+	if err != nil {
+		return nil, err
+	}
+	var compTicketCount int64
+	compFaceValue := 0.0
+	for _, item := range compTickets {
+		compTicketCount += item.Tickets
+		compFaceValue += item.FaceValue
+	}
+
 	// Summary
 	summary := &DashboardSummary{
+		TicketRevenue:      ticketRevenue,
+		ConcessionRevenue:  concessionRevenue,
+		CompTickets:        compTicketCount,
+		CompFaceValue:      compFaceValue,
 		TotalRevenue:       kpi.TotalRevenue,
 		TotalTickets:       kpi.TicketsSold,
 		AvgOccupancy:       kpi.OccupancyRate,
@@ -991,7 +1114,7 @@ ORDER BY DATE(st.start_time)
 		CustomerChangePct:  kpi.CustomerChange,
 		OccupancyChangePct: kpi.OccupancyChange,
 	}
-	countQuery := "SELECT COUNT(*) FROM movies m JOIN showtimes st ON m.id = st.movie_id JOIN tickets t ON st.id = t.showtime_id JOIN orders o ON t.order_id = o.id WHERE o.status = 'PAID' AND o.created_by = 0 AND st.start_time >= $1 AND st.start_time <= $2" // Ví dụ cho top movies
+	countQuery := "SELECT COUNT(*) FROM movies m JOIN showtimes st ON m.id = st.movie_id JOIN tickets t ON st.id = t.showtime_id JOIN orders o ON t.order_id = o.id WHERE o.status = 'PAID' AND NOT o.is_comp AND o.created_by = 0 AND st.start_time >= $1 AND st.start_time <= $2" // Ví dụ cho top movies
 	var total int
 	db.Raw(countQuery, from, to).Scan(&total)
 	// Report
@@ -1002,6 +1125,8 @@ ORDER BY DATE(st.start_time)
 		RevenueCinemas: revenueCinemas,
 		DailyMetrics:   dailyMetrics,
 		TicketByHours:  ticketByHours,
+		Categories:     categories,
+		CompTickets:    compTickets,
 		Pagination: &PaginationInfo{
 			CurrentPage: (offset / limit) + 1,
 			TotalPages:  (total + limit - 1) / limit,
@@ -1014,3 +1139,196 @@ ORDER BY DATE(st.start_time)
 
 	return report, nil
 }
+
+type BoxOfficeTenderItem struct {
+	Method   string  `json:"method"` // CASH, CARD, GIFT_CARD...
+	Orders   int     `json:"orders"`
+	Amount   float64 `json:"amount"`   // tiền thu
+	Refunded float64 `json:"refunded"` // tiền đã hoàn theo hình thức này (kể cả phần hoàn vào ví)
+	Net      float64 `json:"net"`
+	Percent  float64 `json:"percent"` // % trên tổng tiền thu thuần
+}
+
+type BoxOfficeTenderSummary struct {
+	TotalOrders   int     `json:"totalOrders"`
+	TotalAmount   float64 `json:"totalAmount"`
+	TotalRefunded float64 `json:"totalRefunded"`
+	TotalNet      float64 `json:"totalNet"`
+}
+
+// GetBoxOfficeTenderReport: doanh thu đơn bán tại quầy (orders.created_by <> 0) theo hình thức thanh toán.
+// Đơn cũ chưa có dòng thanh toán được tính theo orders.payment_method.
+func GetBoxOfficeTenderReport(db *gorm.DB, from, to time.Time, cinemaID *uint, staffID *uint) ([]BoxOfficeTenderItem, *BoxOfficeTenderSummary, error) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	to = time.Date(to.Year(), to.Month(), to.Day(), 23, 59, 59, 999999999, to.Location())
+
+	var results []BoxOfficeTenderItem
+
+	query := `
+WITH box_orders AS (
+    SELECT o.id, o.payment_method, o.total_amount, o.refund_amount
+    FROM orders o
+    JOIN showtimes st ON o.showtime_id = st.id
+    JOIN rooms r ON st.room_id = r.id
+    WHERE o.paid_at >= $1
+      AND o.paid_at <= $2
+      AND o.created_by <> 0
+      AND NOT o.is_comp
+      AND ($3::bigint IS NULL OR r.cinema_id = $3::bigint)
+      AND ($4::bigint IS NULL OR o.created_by = $4::bigint)
+),
+lines AS (
+    SELECT op.method, op.order_id, op.amount, op.refunded_amount AS refunded
+    FROM order_payments op
+    JOIN box_orders bo ON bo.id = op.order_id
+    UNION ALL
+    SELECT bo.payment_method, bo.id, bo.total_amount, bo.refund_amount
+    FROM box_orders bo
+    WHERE NOT EXISTS (SELECT 1 FROM order_payments op WHERE op.order_id = bo.id)
+)
+SELECT
+    method,
+    COUNT(DISTINCT order_id) AS orders,
+    COALESCE(SUM(amount), 0) AS amount,
+    COALESCE(SUM(refunded), 0) AS refunded,
+    COALESCE(SUM(amount - refunded), 0) AS net
+FROM lines
+GROUP BY method
+ORDER BY net DESC;
+`
+
+	var cinemaParam interface{} = nil
+	if cinemaID != nil {
+		cinemaParam = *cinemaID
+	}
+	var staffParam interface{} = nil
+	if staffID != nil {
+		staffParam = *staffID
+	}
+
+	if err := db.Raw(query, from, to, cinemaParam, staffParam).Scan(&results).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var orderCount int64
+	countQuery := `
+SELECT COUNT(*)
+FROM orders o
+JOIN showtimes st ON o.showtime_id = st.id
+JOIN rooms r ON st.room_id = r.id
+WHERE o.paid_at >= $1
+  AND o.paid_at <= $2
+  AND o.created_by <> 0
+  AND NOT o.is_comp
+  AND ($3::bigint IS NULL OR r.cinema_id = $3::bigint)
+  AND ($4::bigint IS NULL OR o.created_by = $4::bigint)
+`
+	if err := db.Raw(countQuery, from, to, cinemaParam, staffParam).Scan(&orderCount).Error; err != nil {
+		return nil, nil, err
+	}
+
+	summary := &BoxOfficeTenderSummary{TotalOrders: int(orderCount)}
+	for _, r := range results {
+		summary.TotalAmount += r.Amount
+		summary.TotalRefunded += r.Refunded
+		summary.TotalNet += r.Net
+	}
+	for i := range results {
+		if summary.TotalNet > 0 {
+			results[i].Percent = roundFloat(results[i].Net/summary.TotalNet*100, 2)
+		}
+	}
+
+	return results, summary, nil
+}
+
+type CashDrawerVarianceItem struct {
+	StaffID         uint    `json:"staffId"`
+	FullName        string  `json:"fullName"`
+	Username        string  `json:"username"`
+	CinemaName      string  `json:"cinemaName"`
+	Sessions        int     `json:"sessions"`
+	CashSales       float64 `json:"cashSales"`
+	CashRefunds     float64 `json:"cashRefunds"`
+	Payouts         float64 `json:"payouts"`
+	ExpectedAmount  float64 `json:"expectedAmount"`
+	CountedAmount   float64 `json:"countedAmount"`
+	Variance        float64 `json:"variance"`        // tổng chênh lệch (âm: thiếu)
+	ShortSessions   int     `json:"shortSessions"`   // số ca thiếu tiền
+	OverSessions    int     `json:"overSessions"`    // số ca thừa tiền
+	LargestShortage float64 `json:"largestShortage"` // ca thiếu nhiều nhất
+}
+
+type CashDrawerVarianceSummary struct {
+	TotalSessions int     `json:"totalSessions"`
+	TotalExpected float64 `json:"totalExpected"`
+	TotalCounted  float64 `json:"totalCounted"`
+	TotalVariance float64 `json:"totalVariance"`
+	SessionsOff   int     `json:"sessionsOff"`  // số ca lệch tiền
+	OpenSessions  int     `json:"openSessions"` // ca chưa đóng (không tính vào chênh lệch)
+}
+
+// GetCashDrawerVarianceReport: chênh lệch két theo nhân viên cho các ca đã đóng trong kỳ
+func GetCashDrawerVarianceReport(db *gorm.DB, from, to time.Time, cinemaID *uint) ([]CashDrawerVarianceItem, *CashDrawerVarianceSummary, error) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	to = time.Date(to.Year(), to.Month(), to.Day(), 23, 59, 59, 999999999, to.Location())
+
+	var results []CashDrawerVarianceItem
+
+	query := `
+SELECT
+    a.id AS staff_id,
+    COALESCE(TRIM(s.first_name || ' ' || s.last_name), a.username) AS full_name,
+    a.username AS username,
+    c.name AS cinema_name,
+    COUNT(cs.id) AS sessions,
+    COALESCE(SUM(cs.cash_sales), 0) AS cash_sales,
+    COALESCE(SUM(cs.cash_refunds), 0) AS cash_refunds,
+    COALESCE(SUM(cs.payouts), 0) AS payouts,
+    COALESCE(SUM(cs.expected_amount), 0) AS expected_amount,
+    COALESCE(SUM(cs.counted_amount), 0) AS counted_amount,
+    COALESCE(SUM(cs.variance), 0) AS variance,
+    COUNT(*) FILTER (WHERE cs.variance < 0) AS short_sessions,
+    COUNT(*) FILTER (WHERE cs.variance > 0) AS over_sessions,
+    COALESCE(-MIN(LEAST(cs.variance, 0)), 0) AS largest_shortage
+FROM cash_drawer_sessions cs
+JOIN accounts a ON cs.account_id = a.id
+LEFT JOIN staffs s ON a.id = s.account_id
+LEFT JOIN cinemas c ON cs.cinema_id = c.id
+WHERE cs.status = 'CLOSED'
+  AND cs.closed_at >= $1
+  AND cs.closed_at <= $2
+  AND ($3::bigint IS NULL OR cs.cinema_id = $3::bigint)
+GROUP BY a.id, full_name, a.username, c.name
+ORDER BY variance ASC;
+`
+
+	var cinemaParam interface{} = nil
+	if cinemaID != nil {
+		cinemaParam = *cinemaID
+	}
+
+	if err := db.Raw(query, from, to, cinemaParam).Scan(&results).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var openSessions int64
+	openQuery := db.Table("cash_drawer_sessions").Where("status = 'OPEN'")
+	if cinemaID != nil {
+		openQuery = openQuery.Where("cinema_id = ?", *cinemaID)
+	}
+	openQuery.Count(&openSessions)
+
+	summary := &CashDrawerVarianceSummary{OpenSessions: int(openSessions)}
+	for i, r := range results {
+		results[i].Variance = roundFloat(r.Variance, 2)
+		summary.TotalSessions += r.Sessions
+		summary.TotalExpected += r.ExpectedAmount
+		summary.TotalCounted += r.CountedAmount
+		summary.TotalVariance += r.Variance
+		summary.SessionsOff += r.ShortSessions + r.OverSessions
+	}
+	summary.TotalVariance = roundFloat(summary.TotalVariance, 2)
+
+	return results, summary, nil
+}
@@ -0,0 +1,28 @@
+package validate
+
+import (
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UpdateMyDateOfBirth: khách tự khai ngày sinh dùng để kiểm tra phim giới hạn độ tuổi
+func UpdateMyDateOfBirth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input model.UpdateDateOfBirthInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, err.Error(), err, "dateOfBirth")
+		}
+		dob, _ := time.Parse("2006-01-02", input.DateOfBirth)
+		if dob.After(time.Now()) || dob.Before(time.Now().AddDate(-120, 0, 0)) {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Ngày sinh không hợp lệ", nil, "dateOfBirth")
+		}
+		c.Locals("dateOfBirth", dob)
+		return c.Next()
+	}
+}
@@ -0,0 +1,82 @@
+package validate
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// cashDrawerStaff: chỉ nhân viên bán vé (đã gán rạp) thao tác két của chính mình.
+// Trả về thông báo lỗi nếu không có quyền.
+func cashDrawerStaff(c *fiber.Ctx) (model.TokenClaim, string) {
+	accountInfo, _, _, _, isStaff := helper.GetInfoAccountFromToken(c)
+	if !isStaff {
+		return accountInfo, constants.NOT_ADMIN
+	}
+	if accountInfo.CinemaId == nil {
+		return accountInfo, "Tài khoản chưa được gán rạp"
+	}
+	return accountInfo, ""
+}
+
+func OpenCashDrawer() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, msg := cashDrawerStaff(c)
+		if msg != "" {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, msg, errors.New("chỉ nhân viên bán vé được thao tác ca thu ngân"))
+		}
+		var input model.OpenCashDrawerInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		c.Locals("cashDrawerInput", input)
+		c.Locals("accountId", accountInfo.AccountId)
+		c.Locals("cinemaId", *accountInfo.CinemaId)
+		return c.Next()
+	}
+}
+
+func CashPayout() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, msg := cashDrawerStaff(c)
+		if msg != "" {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, msg, errors.New("chỉ nhân viên bán vé được thao tác ca thu ngân"))
+		}
+		var input model.CashPayoutInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		c.Locals("cashPayoutInput", input)
+		c.Locals("accountId", accountInfo.AccountId)
+		return c.Next()
+	}
+}
+
+func CloseCashDrawer() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, msg := cashDrawerStaff(c)
+		if msg != "" {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, msg, errors.New("chỉ nhân viên bán vé được thao tác ca thu ngân"))
+		}
+		var input model.CloseCashDrawerInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, err.Error(), err, "countedAmount")
+		}
+		c.Locals("closeCashDrawerInput", input)
+		c.Locals("accountId", accountInfo.AccountId)
+		return c.Next()
+	}
+}
@@ -0,0 +1,173 @@
+package validate
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UpdateCompPolicy: chỉ admin đặt hạn mức vé mời cho rạp
+func UpdateCompPolicy(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cinemaId, err := strconv.Atoi(c.Params(key))
+		if err != nil || cinemaId <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var cinema model.Cinema
+		if err := database.DB.Select("id").First(&cinema, cinemaId).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Rạp không tồn tại", err, key)
+		}
+		var input model.UpdateCompPolicyInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		c.Locals("compPolicyInput", input)
+		c.Locals("cinemaId", uint(cinemaId))
+		return c.Next()
+	}
+}
+
+// CreateCompRequest: nhân viên / quản lý rạp gửi yêu cầu xuất vé mời vượt ngưỡng
+func CreateCompRequest() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, _, isManager, _, isStaff := helper.GetInfoAccountFromToken(c)
+		if !isManager && !isStaff {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		if accountInfo.CinemaId == nil {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Tài khoản chưa được gán rạp", nil)
+		}
+		var input model.CreateCompRequestInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		var showtime model.Showtime
+		if err := database.DB.Preload("Room").Where("public_code = ?", input.ShowtimeCode).First(&showtime).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Suất chiếu không tồn tại", err, "showtimeCode")
+		}
+		if showtime.Room.CinemaId != *accountInfo.CinemaId {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusForbidden, "Suất chiếu không thuộc rạp của bạn", nil, "showtimeCode")
+		}
+		if showtime.Status == "CANCELLED" || !showtime.StartTime.After(time.Now()) {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Suất chiếu đã bị hủy hoặc đã bắt đầu", nil, "showtimeCode")
+		}
+		usage, err := helper.GetCompUsage(database.DB, showtime.Room.CinemaId, time.Now())
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể kiểm tra hạn mức vé mời", err)
+		}
+		if input.Quantity <= usage.ApprovalThreshold {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest,
+				fmt.Sprintf("Từ %d vé trở xuống không cần duyệt, hãy xuất vé mời trực tiếp", usage.ApprovalThreshold), nil, "quantity")
+		}
+		if usage.Used+input.Quantity > usage.MonthlyQuota {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest,
+				fmt.Sprintf("Vượt hạn mức vé mời tháng %s (đã dùng %d/%d)", usage.Month, usage.Used, usage.MonthlyQuota), nil, "quantity")
+		}
+		c.Locals("compRequest", model.CompTicketRequest{
+			CinemaId:       showtime.Room.CinemaId,
+			ShowtimeId:     showtime.ID,
+			Quantity:       input.Quantity,
+			ReasonCode:     input.ReasonCode,
+			Note:           input.Note,
+			RecipientName:  input.RecipientName,
+			RecipientPhone: input.RecipientPhone,
+			Status:         model.CompRequestPending,
+			RequestedBy:    accountInfo.AccountId,
+		})
+		return c.Next()
+	}
+}
+
+// CompRequest nạp yêu cầu vé mời; duyệt / từ chối (PUT) chỉ dành cho quản lý rạp đó hoặc admin
+func CompRequest(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil || valueKey <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		accountInfo, isAdmin, isManager, _, isStaff := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager && !isStaff {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var request model.CompTicketRequest
+		if err := database.DB.Preload("Showtime.Movie").First(&request, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Yêu cầu vé mời không tồn tại", err, key)
+		}
+		if !isAdmin && (accountInfo.CinemaId == nil || *accountInfo.CinemaId != request.CinemaId) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Yêu cầu vé mời không thuộc rạp của bạn", nil)
+		}
+
+		if c.Method() == fiber.MethodPut {
+			if !isAdmin && !isManager {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, "Chỉ quản lý rạp được duyệt vé mời", nil)
+			}
+			if request.Status != model.CompRequestPending {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("Yêu cầu đang ở trạng thái %s", request.Status), nil)
+			}
+			var input model.ReviewCompRequestInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			if !*input.Approve && input.Note == "" {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Vui lòng nhập lý do từ chối", nil, "note")
+			}
+			c.Locals("reviewInput", input)
+		}
+		c.Locals("compRequest", request)
+		c.Locals("accountId", accountInfo.AccountId)
+		return c.Next()
+	}
+}
+
+// IssueCompTickets: nhân viên bán vé xuất vé mời cho các ghế đang giữ
+func IssueCompTickets() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, _, _, _, isStaff := helper.GetInfoAccountFromToken(c)
+		if !isStaff {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		if accountInfo.CinemaId == nil {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Nhân viên chưa được gán rạp", nil)
+		}
+		var input model.IssueCompTicketInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		if input.RequestId == nil {
+			if input.ReasonCode == "" {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Vui lòng chọn lý do xuất vé mời", nil, "reasonCode")
+			}
+			if input.RecipientName == "" {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Vui lòng nhập người nhận vé mời", nil, "recipientName")
+			}
+		}
+		c.Locals("compInput", input)
+		c.Locals("accountId", accountInfo.AccountId)
+		c.Locals("cinemaId", *accountInfo.CinemaId)
+		return c.Next()
+	}
+}
@@ -0,0 +1,159 @@
+package validate
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func comboItemsFromInput(items []model.ConcessionComboItemInput) []model.ConcessionComboItem {
+	result := make([]model.ConcessionComboItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, model.ConcessionComboItem{ProductId: item.ProductId, Quantity: item.Quantity})
+	}
+	return result
+}
+
+func CreateConcessionProduct() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var input model.CreateConcessionProductInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		if isManager && (accountInfo.CinemaId == nil || *accountInfo.CinemaId != input.CinemaId) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Bạn chỉ được quản lý bắp nước của rạp mình", nil)
+		}
+		var cinema model.Cinema
+		if err := database.DB.First(&cinema, input.CinemaId).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Rạp không tồn tại", err, "cinemaId")
+		}
+		var count int64
+		database.DB.Model(&model.ConcessionProduct{}).Where("cinema_id = ? AND code = ?", input.CinemaId, input.Code).Count(&count)
+		if count > 0 {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Mã sản phẩm đã tồn tại trong rạp", nil, "code")
+		}
+		if err := helper.ValidateComboItems(database.DB, input.CinemaId, input.Category, input.ComboItems); err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, err.Error(), err, "comboItems")
+		}
+		c.Locals("concessionProduct", model.ConcessionProduct{
+			CinemaId:    input.CinemaId,
+			Code:        input.Code,
+			Name:        input.Name,
+			Category:    input.Category,
+			Description: input.Description,
+			ImageUrl:    input.ImageUrl,
+			Price:       input.Price,
+			IsAvailable: input.IsAvailable == nil || *input.IsAvailable,
+			SortOrder:   input.SortOrder,
+			ComboItems:  comboItemsFromInput(input.ComboItems),
+		})
+		return c.Next()
+	}
+}
+
+// ConcessionProduct nạp sản phẩm bắp nước theo id cho GET/PUT/DELETE; manager chỉ thao tác trên rạp mình
+func ConcessionProduct(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil || valueKey <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var product model.ConcessionProduct
+		if err := database.DB.Preload("ComboItems.Product").First(&product, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Sản phẩm không tồn tại", err, key)
+		}
+		if isManager && (accountInfo.CinemaId == nil || *accountInfo.CinemaId != product.CinemaId) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Bạn chỉ được quản lý bắp nước của rạp mình", nil)
+		}
+
+		switch c.Method() {
+		case fiber.MethodPut:
+			var input model.UpdateConcessionProductInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			if input.Code != nil {
+				var count int64
+				database.DB.Model(&model.ConcessionProduct{}).
+					Where("cinema_id = ? AND code = ? AND id <> ?", product.CinemaId, *input.Code, product.ID).
+					Count(&count)
+				if count > 0 {
+					return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Mã sản phẩm đã tồn tại trong rạp", nil, "code")
+				}
+				product.Code = *input.Code
+			}
+			if input.Name != nil {
+				product.Name = *input.Name
+			}
+			if input.Category != nil {
+				if *input.Category != model.ConcessionCombo && product.Category == model.ConcessionCombo && input.ComboItems == nil {
+					return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Hãy xóa thành phần combo trước khi đổi loại", nil, "category")
+				}
+				product.Category = *input.Category
+			}
+			if input.Description != nil {
+				product.Description = *input.Description
+			}
+			if input.ImageUrl != nil {
+				product.ImageUrl = *input.ImageUrl
+			}
+			if input.Price != nil {
+				product.Price = *input.Price
+			}
+			if input.IsAvailable != nil {
+				product.IsAvailable = *input.IsAvailable
+			}
+			if input.SortOrder != nil {
+				product.SortOrder = *input.SortOrder
+			}
+			if input.ComboItems != nil || input.Category != nil {
+				items := input.ComboItems
+				if items == nil {
+					items = make([]model.ConcessionComboItemInput, 0, len(product.ComboItems))
+					for _, item := range product.ComboItems {
+						items = append(items, model.ConcessionComboItemInput{ProductId: item.ProductId, Quantity: item.Quantity})
+					}
+				}
+				for _, item := range items {
+					if item.ProductId == product.ID {
+						return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Combo không được chứa chính nó", nil, "comboItems")
+					}
+				}
+				if err := helper.ValidateComboItems(database.DB, product.CinemaId, product.Category, items); err != nil {
+					return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, err.Error(), err, "comboItems")
+				}
+				c.Locals("comboItems", comboItemsFromInput(items))
+			}
+		case fiber.MethodDelete:
+			if product.Category != model.ConcessionCombo {
+				var count int64
+				database.DB.Model(&model.ConcessionComboItem{}).Where("product_id = ?", product.ID).Count(&count)
+				if count > 0 {
+					return utils.ErrorResponse(c, fiber.StatusBadRequest, "Sản phẩm đang nằm trong combo, hãy gỡ khỏi combo trước", nil)
+				}
+			}
+		}
+		c.Locals("concessionProduct", product)
+		return c.Next()
+	}
+}
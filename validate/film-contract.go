@@ -0,0 +1,279 @@
+package validate
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func CreateDistributor() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var input model.CreateDistributorInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		var count int64
+		database.DB.Model(&model.Distributor{}).Where("LOWER(name) = LOWER(?)", input.Name).Count(&count)
+		if count > 0 {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Tên nhà phát hành đã tồn tại", nil, "name")
+		}
+		c.Locals("distributor", model.Distributor{
+			Name:        input.Name,
+			TaxCode:     input.TaxCode,
+			ContactName: input.ContactName,
+			Email:       input.Email,
+			Phone:       input.Phone,
+			Address:     input.Address,
+			IsActive:    true,
+		})
+		return c.Next()
+	}
+}
+
+// Distributor nạp nhà phát hành theo id cho GET/PUT/DELETE (chỉ admin)
+func Distributor(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil || valueKey <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var distributor model.Distributor
+		if err := database.DB.First(&distributor, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Nhà phát hành không tồn tại", err, key)
+		}
+
+		switch c.Method() {
+		case fiber.MethodPut:
+			var input model.UpdateDistributorInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			if input.Name != nil {
+				var count int64
+				database.DB.Model(&model.Distributor{}).Where("LOWER(name) = LOWER(?) AND id <> ?", *input.Name, distributor.ID).Count(&count)
+				if count > 0 {
+					return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Tên nhà phát hành đã tồn tại", nil, "name")
+				}
+				distributor.Name = *input.Name
+			}
+			if input.TaxCode != nil {
+				distributor.TaxCode = *input.TaxCode
+			}
+			if input.ContactName != nil {
+				distributor.ContactName = *input.ContactName
+			}
+			if input.Email != nil {
+				distributor.Email = *input.Email
+			}
+			if input.Phone != nil {
+				distributor.Phone = *input.Phone
+			}
+			if input.Address != nil {
+				distributor.Address = *input.Address
+			}
+			if input.IsActive != nil {
+				distributor.IsActive = *input.IsActive
+			}
+		case fiber.MethodDelete:
+			var count int64
+			database.DB.Model(&model.FilmContract{}).Where("distributor_id = ?", distributor.ID).Count(&count)
+			if count > 0 {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Nhà phát hành đã có hợp đồng, hãy chuyển sang ngừng hoạt động", nil)
+			}
+		}
+		c.Locals("distributor", distributor)
+		return c.Next()
+	}
+}
+
+// checkFilmContract: nhà phát hành đang hoạt động, phim/chuỗi tồn tại, thời hạn hợp lệ,
+// không chồng thời hạn với hợp đồng đang hiệu lực khác của cùng phim và phạm vi chuỗi
+func checkFilmContract(contract model.FilmContract) (string, string, error) {
+	if contract.EndDate.Before(contract.StartDate) {
+		return "Ngày kết thúc phải sau ngày bắt đầu", "endDate", nil
+	}
+	if err := helper.ValidateRevenueShareTiers(contract.RevenueShareTiers); err != nil {
+		return err.Error(), "revenueShareTiers", err
+	}
+	var distributor model.Distributor
+	if err := database.DB.First(&distributor, contract.DistributorId).Error; err != nil {
+		return "Nhà phát hành không tồn tại", "distributorId", err
+	}
+	if !distributor.IsActive {
+		return "Nhà phát hành đã ngừng hoạt động", "distributorId", nil
+	}
+	var movie model.Movie
+	if err := database.DB.First(&movie, contract.MovieId).Error; err != nil {
+		return "Phim không tồn tại", "movieId", err
+	}
+	if contract.ChainId != nil {
+		var chain model.CinemaChain
+		if err := database.DB.First(&chain, *contract.ChainId).Error; err != nil {
+			return "Chuỗi rạp không tồn tại", "chainId", err
+		}
+	}
+	var count int64
+	database.DB.Model(&model.FilmContract{}).Where("code = ? AND id <> ?", contract.Code, contract.ID).Count(&count)
+	if count > 0 {
+		return "Mã hợp đồng đã tồn tại", "code", nil
+	}
+	if contract.Status == "active" {
+		query := database.DB.Model(&model.FilmContract{}).
+			Where("movie_id = ? AND id <> ? AND status = ?", contract.MovieId, contract.ID, "active").
+			Where("start_date <= ? AND end_date >= ?", contract.EndDate.Format("2006-01-02"), contract.StartDate.Format("2006-01-02"))
+		if contract.ChainId != nil {
+			query = query.Where("(chain_id IS NULL OR chain_id = ?)", *contract.ChainId)
+		}
+		query.Count(&count)
+		if count > 0 {
+			return "Thời hạn trùng với hợp đồng khác của phim", "startDate", nil
+		}
+	}
+	return "", "", nil
+}
+
+func CreateFilmContract() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var input model.CreateFilmContractInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		startDate, _ := time.Parse("2006-01-02", input.StartDate)
+		endDate, _ := time.Parse("2006-01-02", input.EndDate)
+		contract := model.FilmContract{
+			Code:               input.Code,
+			DistributorId:      input.DistributorId,
+			MovieId:            input.MovieId,
+			ChainId:            input.ChainId,
+			StartDate:          startDate,
+			EndDate:            endDate,
+			MinShowtimesPerDay: input.MinShowtimesPerDay,
+			RevenueShareTiers:  input.RevenueShareTiers,
+			Status:             "active",
+			Note:               input.Note,
+			CreatedBy:          accountInfo.AccountId,
+		}
+		if msg, key, err := checkFilmContract(contract); msg != "" {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, msg, err, key)
+		}
+		c.Locals("filmContract", contract)
+		return c.Next()
+	}
+}
+
+// FilmContract nạp hợp đồng theo id cho GET/PUT/DELETE (chỉ admin)
+func FilmContract(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil || valueKey <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var contract model.FilmContract
+		if err := database.DB.First(&contract, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Hợp đồng không tồn tại", err, key)
+		}
+
+		if c.Method() == fiber.MethodPut {
+			var input model.UpdateFilmContractInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			if input.Code != nil {
+				contract.Code = *input.Code
+			}
+			if input.DistributorId != nil {
+				contract.DistributorId = *input.DistributorId
+			}
+			if input.ChainId != nil {
+				contract.ChainId = input.ChainId
+			}
+			if input.StartDate != nil {
+				contract.StartDate, _ = time.Parse("2006-01-02", *input.StartDate)
+			}
+			if input.EndDate != nil {
+				contract.EndDate, _ = time.Parse("2006-01-02", *input.EndDate)
+			}
+			if input.MinShowtimesPerDay != nil {
+				contract.MinShowtimesPerDay = *input.MinShowtimesPerDay
+			}
+			if input.RevenueShareTiers != nil {
+				contract.RevenueShareTiers = input.RevenueShareTiers
+			}
+			if input.Status != nil {
+				contract.Status = *input.Status
+			}
+			if input.Note != nil {
+				contract.Note = *input.Note
+			}
+			if msg, key, err := checkFilmContract(contract); msg != "" {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, msg, err, key)
+			}
+		}
+		c.Locals("filmContract", contract)
+		return c.Next()
+	}
+}
+
+// FilmSettlement kiểm tra kỳ đối soát (tối đa 1 năm) cho báo cáo tiền thuê phim
+func FilmSettlement() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var filter model.FilmSettlementFilter
+		if err := c.QueryParser(&filter); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+		}
+		if err := validate.Struct(filter); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		from, _ := time.Parse("2006-01-02", filter.From)
+		to, _ := time.Parse("2006-01-02", filter.To)
+		if to.Before(from) {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Ngày kết thúc phải sau ngày bắt đầu", nil, "to")
+		}
+		if to.Sub(from) > 366*24*time.Hour {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Kỳ đối soát tối đa 1 năm", nil, "to")
+		}
+		c.Locals("settlementFilter", filter)
+		c.Locals("from", from)
+		c.Locals("to", to)
+		return c.Next()
+	}
+}
@@ -0,0 +1,111 @@
+package validate
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SellGiftCard: bán thẻ tại quầy (nhân viên/manager/admin)
+func SellGiftCard() fiber.Handler {
+	return giftCardSale(model.GiftCardBoxOffice)
+}
+
+// BuyGiftCard: khách (hoặc khách vãng lai có email) mua thẻ online
+func BuyGiftCard() fiber.Handler {
+	return giftCardSale(model.GiftCardOnline)
+}
+
+func giftCardSale(channel string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input model.SellGiftCardInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		card := model.GiftCard{
+			InitialValue:  input.Amount,
+			Channel:       channel,
+			BuyerEmail:    input.BuyerEmail,
+			PaymentMethod: input.PaymentMethod,
+		}
+		if channel == model.GiftCardBoxOffice {
+			accountInfo, isAdmin, isManager, _, isStaff := helper.GetInfoAccountFromToken(c)
+			if !isAdmin && !isManager && !isStaff {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+			}
+			card.CinemaId = accountInfo.CinemaId
+			card.SoldBy = &accountInfo.AccountId
+		} else {
+			customer, ok := c.Locals("customer").(*model.Customer)
+			if ok && customer != nil {
+				card.BuyerId = &customer.ID
+				if card.BuyerEmail == "" {
+					card.BuyerEmail = customer.Email
+				}
+			}
+			if card.BuyerEmail == "" {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Vui lòng nhập email nhận thẻ", nil, "buyerEmail")
+			}
+		}
+		c.Locals("giftCard", card)
+		c.Locals("expiryMonths", input.ExpiryMonths)
+		return c.Next()
+	}
+}
+
+// GiftCardCredential đọc mã + PIN thẻ (tra cứu số dư, nạp vào ví)
+func GiftCardCredential() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input model.GiftCardCredentialInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		c.Locals("giftCardCredential", input)
+		return c.Next()
+	}
+}
+
+// GiftCard nạp thẻ theo id cho admin/manager (manager chỉ xem thẻ bán tại rạp mình); PUT đổi trạng thái
+func GiftCard(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil || valueKey <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var card model.GiftCard
+		if err := database.DB.First(&card, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Thẻ quà tặng không tồn tại", err, key)
+		}
+		if isManager && (accountInfo.CinemaId == nil || card.CinemaId == nil || *card.CinemaId != *accountInfo.CinemaId) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Bạn chỉ được quản lý thẻ bán tại rạp mình", nil)
+		}
+		if c.Method() == fiber.MethodPut {
+			var input model.UpdateGiftCardStatusInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			card.Status = input.Status
+		}
+		c.Locals("giftCard", card)
+		return c.Next()
+	}
+}
@@ -2,10 +2,16 @@ package validate
 
 import (
 	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
 	"cinema_manager/model"
 	"cinema_manager/utils"
 	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -32,12 +38,20 @@ func CreateHoliday() fiber.Handler {
 		if input.IsRecurring != nil {
 			isRecurring = *input.IsRecurring
 		}
-		c.Locals("createInput", model.Holiday{
+		if (input.LunarMonth == nil) != (input.LunarDay == nil) {
+			return utils.ErrorResponseHaveKey(c, 400, "Cần nhập đủ cả ngày và tháng âm lịch", nil, "lunarDay")
+		}
+		holiday := model.Holiday{
 			Name:        input.Name,
 			Date:        date,
 			Type:        input.Type,
 			IsRecurring: isRecurring,
-		})
+		}
+		if input.LunarMonth != nil {
+			holiday.LunarMonth, holiday.LunarDay = *input.LunarMonth, *input.LunarDay
+		}
+		holiday.FillLunarDate()
+		c.Locals("createInput", holiday)
 
 		return c.Next()
 	}
@@ -67,18 +81,123 @@ func UpdateHoliday(key string) fiber.Handler {
 			date = &d
 		}
 
+		if (input.LunarMonth == nil) != (input.LunarDay == nil) {
+			return utils.ErrorResponseHaveKey(c, 400, "Cần nhập đủ cả ngày và tháng âm lịch", nil, "lunarDay")
+		}
+
 		c.Locals("updateInput", struct {
 			Name        *string
 			Date        *time.Time
 			Type        *string
 			IsRecurring *bool
+			LunarMonth  *int
+			LunarDay    *int
 		}{
 			Name:        input.Name,
 			Date:        date,
 			Type:        input.Type,
 			IsRecurring: input.IsRecurring,
+			LunarMonth:  input.LunarMonth,
+			LunarDay:    input.LunarDay,
 		})
 		c.Locals("holidayId", valueKey)
 		return c.Next()
 	}
 }
+
+// Calendar kiểm tra khoảng ngày (tối đa 62 ngày), rạp và phim của lịch phân loại ngày;
+// manager chỉ xem được lịch của rạp mình
+func Calendar() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var filter model.CalendarFilter
+		if err := c.QueryParser(&filter); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+		}
+		if err := validate.Struct(filter); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		loc := time.FixedZone("ICT", 7*3600)
+		from, _ := time.ParseInLocation("2006-01-02", filter.From, loc)
+		to, _ := time.ParseInLocation("2006-01-02", filter.To, loc)
+		if to.Before(from) {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Ngày kết thúc phải sau ngày bắt đầu", nil, "to")
+		}
+		if to.Sub(from) > 61*24*time.Hour {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Chỉ xem được tối đa 62 ngày", nil, "to")
+		}
+
+		if isManager {
+			if accountInfo.CinemaId == nil {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, "Bạn chưa được gán rạp", nil)
+			}
+			if filter.CinemaId == 0 {
+				filter.CinemaId = *accountInfo.CinemaId
+			}
+			if filter.CinemaId != *accountInfo.CinemaId {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, "Bạn chỉ được xem lịch của rạp mình", nil)
+			}
+		}
+		if filter.CinemaId != 0 {
+			var cinema model.Cinema
+			if err := database.DB.First(&cinema, filter.CinemaId).Error; err != nil {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Rạp không tồn tại", err, "cinemaId")
+			}
+		}
+		var movie *model.Movie
+		if filter.MovieId != 0 {
+			movie = &model.Movie{}
+			if err := database.DB.First(movie, filter.MovieId).Error; err != nil {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Phim không tồn tại", err, "movieId")
+			}
+		}
+
+		c.Locals("calendarFilter", filter)
+		c.Locals("calendarFrom", from)
+		c.Locals("calendarTo", to)
+		c.Locals("movie", movie)
+		return c.Next()
+	}
+}
+
+// ImportHoliday đọc file .ics (field "file", tối đa 1MB) và đối chiếu với bảng Holiday
+func ImportHoliday() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin {
+			return utils.ErrorResponse(c, 403, "Chỉ admin được phép", nil)
+		}
+		file, err := c.FormFile("file")
+		if err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Vui lòng chọn file .ics", err, "file")
+		}
+		if strings.ToLower(filepath.Ext(file.Filename)) != ".ics" {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Định dạng file không hỗ trợ (chỉ hỗ trợ .ics)", fmt.Errorf("invalid file format"), "file")
+		}
+		if file.Size > 1<<20 {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "File quá lớn (tối đa 1MB)", nil, "file")
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể đọc file", err)
+		}
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Không thể đọc file", err)
+		}
+
+		events, err := utils.ParseICal(string(data), time.UTC)
+		if err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, err.Error(), err, "file")
+		}
+		if len(events) == 0 {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "File không có sự kiện (VEVENT) nào", nil, "file")
+		}
+		c.Locals("importPlan", helper.BuildHolidayImportPlan(events))
+		return c.Next()
+	}
+}
@@ -0,0 +1,227 @@
+package validate
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func CreateInventoryItem() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var input model.CreateInventoryItemInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		if isManager && (accountInfo.CinemaId == nil || *accountInfo.CinemaId != input.CinemaId) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Bạn chỉ được quản lý kho của rạp mình", nil)
+		}
+		var cinema model.Cinema
+		if err := database.DB.First(&cinema, input.CinemaId).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Rạp không tồn tại", err, "cinemaId")
+		}
+		var count int64
+		database.DB.Model(&model.InventoryItem{}).Where("cinema_id = ? AND code = ?", input.CinemaId, input.Code).Count(&count)
+		if count > 0 {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Mã nguyên liệu đã tồn tại trong rạp", nil, "code")
+		}
+		c.Locals("inventoryItem", model.InventoryItem{
+			CinemaId:          input.CinemaId,
+			Code:              input.Code,
+			Name:              input.Name,
+			Unit:              input.Unit,
+			LowStockThreshold: input.LowStockThreshold,
+			IsActive:          true,
+		})
+		c.Locals("openingQuantity", input.Quantity)
+		c.Locals("accountId", accountInfo.AccountId)
+		return c.Next()
+	}
+}
+
+// InventoryItem nạp nguyên liệu theo id cho GET/PUT/DELETE và ghi nhận nhập/hủy/kiểm kê (POST);
+// manager chỉ thao tác trên kho rạp mình
+func InventoryItem(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil || valueKey <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var item model.InventoryItem
+		if err := database.DB.First(&item, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Nguyên liệu không tồn tại", err, key)
+		}
+		if isManager && (accountInfo.CinemaId == nil || *accountInfo.CinemaId != item.CinemaId) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Bạn chỉ được quản lý kho của rạp mình", nil)
+		}
+
+		switch c.Method() {
+		case fiber.MethodPut:
+			var input model.UpdateInventoryItemInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			if input.Code != nil {
+				var count int64
+				database.DB.Model(&model.InventoryItem{}).
+					Where("cinema_id = ? AND code = ? AND id <> ?", item.CinemaId, *input.Code, item.ID).
+					Count(&count)
+				if count > 0 {
+					return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Mã nguyên liệu đã tồn tại trong rạp", nil, "code")
+				}
+				item.Code = *input.Code
+			}
+			if input.Name != nil {
+				item.Name = *input.Name
+			}
+			if input.Unit != nil {
+				item.Unit = *input.Unit
+			}
+			if input.LowStockThreshold != nil {
+				item.LowStockThreshold = *input.LowStockThreshold
+			}
+			if input.IsActive != nil {
+				item.IsActive = *input.IsActive
+			}
+		case fiber.MethodPost:
+			var input model.CreateStockMovementInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			if input.Type == model.StockAdjustment {
+				if input.CountedQuantity == nil {
+					return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Kiểm kê cần số lượng đếm thực tế", nil, "countedQuantity")
+				}
+			} else if input.Quantity <= 0 {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Số lượng phải lớn hơn 0", nil, "quantity")
+			}
+			if !item.IsActive {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Nguyên liệu đã ngừng sử dụng", nil)
+			}
+			c.Locals("movementInput", input)
+		case fiber.MethodDelete:
+			var count int64
+			database.DB.Model(&model.StockMovement{}).Where("inventory_item_id = ?", item.ID).Count(&count)
+			if count > 0 {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Nguyên liệu đã có lịch sử xuất nhập, hãy chuyển sang ngừng sử dụng", nil)
+			}
+			database.DB.Model(&model.ConcessionRecipe{}).Where("inventory_item_id = ?", item.ID).Count(&count)
+			if count > 0 {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Nguyên liệu đang nằm trong công thức sản phẩm", nil)
+			}
+		}
+		c.Locals("inventoryItem", item)
+		c.Locals("accountId", accountInfo.AccountId)
+		return c.Next()
+	}
+}
+
+// ConcessionRecipe nạp sản phẩm và kiểm tra công thức (nguyên liệu cùng rạp, không trùng)
+func ConcessionRecipe(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil || valueKey <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var product model.ConcessionProduct
+		if err := database.DB.First(&product, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Sản phẩm không tồn tại", err, key)
+		}
+		if isManager && (accountInfo.CinemaId == nil || *accountInfo.CinemaId != product.CinemaId) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Bạn chỉ được quản lý bắp nước của rạp mình", nil)
+		}
+
+		if c.Method() == fiber.MethodPut {
+			var input model.UpdateRecipeInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			seen := make(map[uint]bool)
+			ids := make([]uint, 0, len(input.Lines))
+			for _, line := range input.Lines {
+				if seen[line.InventoryItemId] {
+					return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, fmt.Sprintf("Nguyên liệu %d bị trùng", line.InventoryItemId), nil, "lines")
+				}
+				seen[line.InventoryItemId] = true
+				ids = append(ids, line.InventoryItemId)
+			}
+			if len(ids) > 0 {
+				var count int64
+				database.DB.Model(&model.InventoryItem{}).Where("id IN ? AND cinema_id = ?", ids, product.CinemaId).Count(&count)
+				if int(count) != len(ids) {
+					return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Nguyên liệu phải thuộc kho của cùng rạp", nil, "lines")
+				}
+			}
+			c.Locals("recipeInput", input)
+		}
+		c.Locals("concessionProduct", product)
+		return c.Next()
+	}
+}
+
+// StockReport kiểm tra kỳ báo cáo xuất nhập tồn (tối đa 1 năm); manager chỉ xem rạp mình
+func StockReport() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var filter model.StockReportFilter
+		if err := c.QueryParser(&filter); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+		}
+		if err := validate.Struct(filter); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		if isManager {
+			if accountInfo.CinemaId == nil {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, "Tài khoản chưa được gán rạp", nil)
+			}
+			filter.CinemaId = *accountInfo.CinemaId
+		}
+		if filter.CinemaId == 0 {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Vui lòng chọn rạp", nil, "cinemaId")
+		}
+		from, _ := time.Parse("2006-01-02", filter.From)
+		to, _ := time.Parse("2006-01-02", filter.To)
+		if to.Before(from) {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Ngày kết thúc phải sau ngày bắt đầu", nil, "to")
+		}
+		if to.Sub(from) > 366*24*time.Hour {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Kỳ báo cáo tối đa 1 năm", nil, "to")
+		}
+		c.Locals("stockReportFilter", filter)
+		return c.Next()
+	}
+}
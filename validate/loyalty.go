@@ -0,0 +1,43 @@
+package validate
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+
+	"cinema_manager/helper"
+	"github.com/gofiber/fiber/v2"
+)
+
+func UpdateLoyaltySetting() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Chỉ admin được phép", nil)
+		}
+		var input model.UpdateLoyaltySettingInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, 400, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, 400, err.Error(), err)
+		}
+		c.Locals("loyaltySettingInput", input)
+		return c.Next()
+	}
+}
+
+// LoyaltyHistory đọc bộ lọc lịch sử điểm (khách xem của mình, admin/manager xem theo customerId)
+func LoyaltyHistory() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var filter model.FilterLoyaltyTransactionInput
+		if err := c.QueryParser(&filter); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.ERROR_INPUT, err)
+		}
+		if err := validate.Struct(filter); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		c.Locals("loyaltyFilter", filter)
+		return c.Next()
+	}
+}
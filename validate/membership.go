@@ -0,0 +1,162 @@
+package validate
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func CreateMembershipTier() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Chỉ admin được phép", nil)
+		}
+		var input model.CreateMembershipTierInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		var count int64
+		database.DB.Model(&model.MembershipTier{}).Where("code = ?", input.Code).Count(&count)
+		if count > 0 {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Mã hạng đã tồn tại", nil, "code")
+		}
+		database.DB.Model(&model.MembershipTier{}).Where("rank = ?", input.Rank).Count(&count)
+		if count > 0 {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Thứ hạng đã được dùng cho hạng khác", nil, "rank")
+		}
+		c.Locals("membershipTier", model.MembershipTier{
+			Code:                  input.Code,
+			Name:                  input.Name,
+			Rank:                  input.Rank,
+			MinSpend:              input.MinSpend,
+			DiscountPercent:       input.DiscountPercent,
+			FreeVipUpgrades:       input.FreeVipUpgrades,
+			BirthdayVoucherAmount: input.BirthdayVoucherAmount,
+			PresaleHours:          input.PresaleHours,
+			IsActive:              true,
+		})
+		return c.Next()
+	}
+}
+
+// MembershipTier nạp hạng theo id cho GET/PUT/DELETE; chỉ admin được sửa/xóa
+func MembershipTier(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil || valueKey <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		_, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && (c.Method() != fiber.MethodGet || !isManager) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var tier model.MembershipTier
+		if err := database.DB.First(&tier, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Hạng thành viên không tồn tại", err, key)
+		}
+
+		switch c.Method() {
+		case fiber.MethodPut:
+			var input model.UpdateMembershipTierInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			var count int64
+			if input.Code != nil {
+				database.DB.Model(&model.MembershipTier{}).Where("code = ? AND id <> ?", *input.Code, tier.ID).Count(&count)
+				if count > 0 {
+					return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Mã hạng đã tồn tại", nil, "code")
+				}
+				tier.Code = *input.Code
+			}
+			if input.Rank != nil {
+				database.DB.Model(&model.MembershipTier{}).Where("rank = ? AND id <> ?", *input.Rank, tier.ID).Count(&count)
+				if count > 0 {
+					return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Thứ hạng đã được dùng cho hạng khác", nil, "rank")
+				}
+				tier.Rank = *input.Rank
+			}
+			if input.Name != nil {
+				tier.Name = *input.Name
+			}
+			if input.MinSpend != nil {
+				tier.MinSpend = *input.MinSpend
+			}
+			if input.DiscountPercent != nil {
+				tier.DiscountPercent = *input.DiscountPercent
+			}
+			if input.FreeVipUpgrades != nil {
+				tier.FreeVipUpgrades = *input.FreeVipUpgrades
+			}
+			if input.BirthdayVoucherAmount != nil {
+				tier.BirthdayVoucherAmount = *input.BirthdayVoucherAmount
+			}
+			if input.PresaleHours != nil {
+				tier.PresaleHours = *input.PresaleHours
+			}
+			if input.IsActive != nil {
+				tier.IsActive = *input.IsActive
+			}
+		case fiber.MethodDelete:
+			var count int64
+			database.DB.Model(&model.Customer{}).Where("tier_id = ?", tier.ID).Count(&count)
+			if count > 0 {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Hạng đang có khách, hãy chuyển sang ngừng áp dụng", nil)
+			}
+		}
+		c.Locals("membershipTier", tier)
+		return c.Next()
+	}
+}
+
+// OverrideCustomerTier: admin gán tay hạng cho khách (bắt buộc ghi chú lý do)
+func OverrideCustomerTier(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil || valueKey <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		accountInfo, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Chỉ admin được phép", nil)
+		}
+		var input model.OverrideCustomerTierInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		var customer model.Customer
+		if err := database.DB.First(&customer, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, constants.NOT_FOUND_RECORDS, err, key)
+		}
+		if input.Release {
+			if !customer.TierLocked {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Hạng của khách đang được xét tự động", nil)
+			}
+		} else if input.TierId != nil {
+			var tier model.MembershipTier
+			if err := database.DB.Where("id = ? AND is_active = ?", *input.TierId, true).First(&tier).Error; err != nil {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Hạng thành viên không tồn tại hoặc ngừng áp dụng", err, "tierId")
+			}
+		}
+		c.Locals("targetCustomer", customer)
+		c.Locals("tierInput", input)
+		c.Locals("accountId", accountInfo.AccountId)
+		return c.Next()
+	}
+}
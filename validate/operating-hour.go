@@ -0,0 +1,82 @@
+package validate
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func UpdateRoomTurnaround() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Chỉ admin được phép", nil)
+		}
+		var input model.UpdateRoomTurnaroundInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, 400, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, 400, err.Error(), err)
+		}
+		seen := map[model.RoomType]bool{}
+		for _, item := range input.Items {
+			if seen[item.RoomType] {
+				return utils.ErrorResponseHaveKey(c, 400, fmt.Sprintf("Loại phòng %s bị trùng", item.RoomType), nil, "items")
+			}
+			seen[item.RoomType] = true
+		}
+		c.Locals("turnaroundInput", input)
+		return c.Next()
+	}
+}
+
+func CinemaOperatingHours(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		if isManager && (accountInfo.CinemaId == nil || *accountInfo.CinemaId != uint(valueKey)) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Bạn chỉ được cấu hình rạp của mình", nil)
+		}
+		var cinema model.Cinema
+		if err := database.DB.First(&cinema, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Rạp không tồn tại", err, "cinemaId")
+		}
+
+		if c.Method() == fiber.MethodPut {
+			var input model.UpdateOperatingHoursInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, 400, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, 400, err.Error(), err)
+			}
+			seen := map[int]bool{}
+			for _, h := range input.Hours {
+				if seen[*h.Weekday] {
+					return utils.ErrorResponseHaveKey(c, 400, fmt.Sprintf("Thứ %d bị trùng", *h.Weekday), nil, "hours")
+				}
+				seen[*h.Weekday] = true
+				if h.OpenTime == h.CloseTime {
+					return utils.ErrorResponseHaveKey(c, 400, "Giờ mở cửa và đóng cửa không được trùng nhau", nil, "hours")
+				}
+			}
+			c.Locals("operatingHoursInput", input)
+		}
+		c.Locals("cinemaId", uint(valueKey))
+		return c.Next()
+	}
+}
@@ -0,0 +1,91 @@
+package validate
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreateReservation: chỉ nhân viên bán vé (đã gán rạp) đặt chỗ cho khách gọi điện
+func CreateReservation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, _, _, _, isStaff := helper.GetInfoAccountFromToken(c)
+		if !isStaff {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		if accountInfo.CinemaId == nil {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Nhân viên chưa được gán rạp", nil)
+		}
+		var input model.CreateReservationInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		c.Locals("reservationInput", input)
+		c.Locals("accountId", accountInfo.AccountId)
+		c.Locals("cinemaId", *accountInfo.CinemaId)
+		return c.Next()
+	}
+}
+
+// Reservation nạp đơn đặt chỗ theo mã đơn; nhân viên / quản lý chỉ thao tác đơn của rạp mình.
+// Thanh toán và hủy (POST) chỉ áp dụng cho đơn đang RESERVED.
+func Reservation(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, isAdmin, isManager, _, isStaff := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager && !isStaff {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var order model.Order
+		if err := database.DB.
+			Preload("Showtime.Movie").
+			Preload("Showtime.Room").
+			Preload("Tickets.ShowtimeSeat.Seat").
+			Where("public_code = ? AND reserved_until IS NOT NULL", c.Params(key)).
+			First(&order).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Không tìm thấy đơn đặt chỗ", err, key)
+		}
+		if !isAdmin && (accountInfo.CinemaId == nil || *accountInfo.CinemaId != order.Showtime.Room.CinemaId) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Đơn đặt chỗ không thuộc rạp của bạn", nil)
+		}
+
+		if c.Method() == fiber.MethodPost {
+			if order.Status != model.OrderReserved {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("Đơn đặt chỗ đang ở trạng thái %s", order.Status), nil)
+			}
+		}
+		c.Locals("reservation", order)
+		c.Locals("accountId", accountInfo.AccountId)
+		return c.Next()
+	}
+}
+
+// PayReservation đọc hình thức thanh toán tại quầy; chạy sau Reservation(key)
+func PayReservation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, _, _, _, isStaff := helper.GetInfoAccountFromToken(c)
+		if !isStaff {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Chỉ nhân viên bán vé được thu tiền tại quầy", nil)
+		}
+		var input model.PayReservationInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if input.PaymentMethod == "" && len(input.Payments) == 0 {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Vui lòng chọn hình thức thanh toán", nil, "paymentMethod")
+		}
+		if err := validate.Var(input.Payments, "omitempty,dive"); err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, err.Error(), err, "payments")
+		}
+		c.Locals("payReservationInput", input)
+		return c.Next()
+	}
+}
@@ -44,6 +44,10 @@ func CreateScheduleTemplate() fiber.Handler {
 			})
 		}
 
+		if err := helper.ValidateFormatRules(input.FormatRules); err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, err.Error(), err, "formatRules")
+		}
+
 		// Truyền vào Locals
 		c.Locals("createScheduleTemplateInput", input)
 
@@ -112,6 +116,10 @@ func UpdateSchedulerTemplate(key string) fiber.Handler {
 			})
 		}
 
+		if err := helper.ValidateFormatRules(input.FormatRules); err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, err.Error(), err, "formatRules")
+		}
+
 		// Truyền vào Locals
 		c.Locals("updateScheduleTemplateInput", input)
 		c.Locals("scheduleTemplateId", uint(valueKey))
@@ -238,7 +246,111 @@ func AutoGenerateSchedule() fiber.Handler {
 		c.Locals("endDate", endDate)
 		c.Locals("accountInfo", accountInfo)
 		c.Locals("useTemplate", true)
-		c.Locals("template", &template)
+		c.Locals("template", template)
+		return c.Next()
+	}
+}
+
+// checkTemplateItemRoom: phòng phải tồn tại, đang hoạt động, hỗ trợ định dạng và thuộc rạp của manager
+func checkTemplateItemRoom(roomId uint, format string, accountInfo model.TokenClaim, isManager bool) (int, error) {
+	var room model.Room
+	if err := database.DB.Preload("Formats").First(&room, roomId).Error; err != nil {
+		return fiber.StatusBadRequest, fmt.Errorf("Phòng %d không tồn tại", roomId)
+	}
+	if isManager && (accountInfo.CinemaId == nil || room.CinemaId != *accountInfo.CinemaId) {
+		return fiber.StatusForbidden, fmt.Errorf("Phòng %s không thuộc rạp của bạn", room.Name)
+	}
+	if room.Status != "available" {
+		return fiber.StatusBadRequest, fmt.Errorf("Phòng %s không hoạt động", room.Name)
+	}
+	if !helper.RoomSupportsFormat(room.Formats, format) {
+		return fiber.StatusBadRequest, fmt.Errorf("Phòng %s không hỗ trợ định dạng %s", room.Name, format)
+	}
+	return 0, nil
+}
+
+// ScheduleTemplateItems kiểm tra request CRUD item của mẫu lịch (GET/POST/PUT/DELETE)
+func ScheduleTemplateItems(key, itemKey string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil || valueKey <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var template model.ScheduleTemplate
+		if err := database.DB.First(&template, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Mẫu lịch chiếu không tồn tại", err, "scheduleTemplateId")
+		}
+		c.Locals("scheduleTemplateId", uint(valueKey))
+
+		var item model.ScheduleTemplateItem
+		if c.Method() == fiber.MethodPut || c.Method() == fiber.MethodDelete {
+			itemId, err := strconv.Atoi(c.Params(itemKey))
+			if err != nil || itemId <= 0 {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+			}
+			if err := database.DB.Preload("Room").Where("id = ? AND schedule_template_id = ?", itemId, valueKey).First(&item).Error; err != nil {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Suất trong mẫu lịch không tồn tại", err, "itemId")
+			}
+			if isManager && (accountInfo.CinemaId == nil || item.Room.CinemaId != *accountInfo.CinemaId) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, "Suất này thuộc phòng của rạp khác", nil)
+			}
+			c.Locals("scheduleTemplateItem", item)
+		}
+
+		switch c.Method() {
+		case fiber.MethodPost:
+			var input model.CreateScheduleTemplateItemInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			if status, err := checkTemplateItemRoom(input.RoomId, input.Format, accountInfo, isManager); err != nil {
+				return utils.ErrorResponseHaveKey(c, status, err.Error(), err, "roomId")
+			}
+			var count int64
+			database.DB.Model(&model.ScheduleTemplateItem{}).
+				Where("schedule_template_id = ? AND room_id = ? AND time = ?", valueKey, input.RoomId, input.Time).
+				Count(&count)
+			if count > 0 {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusConflict, fmt.Sprintf("Phòng đã có suất lúc %s trong mẫu", input.Time), nil, "time")
+			}
+			c.Locals("createItemInput", input)
+		case fiber.MethodPut:
+			var input model.UpdateScheduleTemplateItemInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			roomId, format, slot := item.RoomId, item.Format, item.Time
+			if input.RoomId != nil {
+				roomId = *input.RoomId
+			}
+			if input.Format != nil {
+				format = *input.Format
+			}
+			if input.Time != nil {
+				slot = *input.Time
+			}
+			if status, err := checkTemplateItemRoom(roomId, format, accountInfo, isManager); err != nil {
+				return utils.ErrorResponseHaveKey(c, status, err.Error(), err, "roomId")
+			}
+			var count int64
+			database.DB.Model(&model.ScheduleTemplateItem{}).
+				Where("schedule_template_id = ? AND room_id = ? AND time = ? AND id <> ?", valueKey, roomId, slot, item.ID).
+				Count(&count)
+			if count > 0 {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusConflict, fmt.Sprintf("Phòng đã có suất lúc %s trong mẫu", slot), nil, "time")
+			}
+			c.Locals("updateItemInput", input)
+		}
 		return c.Next()
 	}
 }
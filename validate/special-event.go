@@ -0,0 +1,167 @@
+package validate
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// checkSpecialEventScope kiểm tra phạm vi sự kiện: chuỗi/rạp/phim tồn tại, rạp thuộc chuỗi,
+// manager chỉ được tạo sự kiện cho rạp của mình
+func checkSpecialEventScope(chainId, cinemaId, movieId *uint, accountInfo model.TokenClaim, isManager bool) (int, string, error) {
+	if isManager {
+		if accountInfo.CinemaId == nil || cinemaId == nil || *cinemaId != *accountInfo.CinemaId {
+			return fiber.StatusForbidden, "Bạn chỉ được tạo sự kiện cho rạp của mình", nil
+		}
+	}
+	if chainId != nil {
+		var chain model.CinemaChain
+		if err := database.DB.First(&chain, *chainId).Error; err != nil {
+			return fiber.StatusBadRequest, "Chuỗi rạp không tồn tại", err
+		}
+	}
+	if cinemaId != nil {
+		var cinema model.Cinema
+		if err := database.DB.First(&cinema, *cinemaId).Error; err != nil {
+			return fiber.StatusBadRequest, "Rạp không tồn tại", err
+		}
+		if chainId != nil && cinema.ChainId != *chainId {
+			return fiber.StatusBadRequest, "Rạp không thuộc chuỗi đã chọn", nil
+		}
+	}
+	if movieId != nil {
+		var movie model.Movie
+		if err := database.DB.First(&movie, *movieId).Error; err != nil {
+			return fiber.StatusBadRequest, "Phim không tồn tại", err
+		}
+	}
+	return 0, "", nil
+}
+
+func CreateSpecialEvent() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var input model.CreateSpecialEventInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		startDate, _ := time.Parse("2006-01-02", input.StartDate)
+		endDate, _ := time.Parse("2006-01-02", input.EndDate)
+		if endDate.Before(startDate) {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Ngày kết thúc phải sau ngày bắt đầu", nil, "endDate")
+		}
+		if status, msg, err := checkSpecialEventScope(input.ChainId, input.CinemaId, input.MovieId, accountInfo, isManager); msg != "" {
+			return utils.ErrorResponse(c, status, msg, err)
+		}
+
+		event := model.SpecialEvent{
+			Name:          input.Name,
+			Description:   input.Description,
+			Type:          input.Type,
+			StartDate:     startDate,
+			EndDate:       endDate,
+			ChainId:       input.ChainId,
+			CinemaId:      input.CinemaId,
+			MovieId:       input.MovieId,
+			PricePercent:  input.PricePercent,
+			PriceAmount:   input.PriceAmount,
+			NonRefundable: input.NonRefundable,
+			Tags:          input.Tags,
+			IsActive:      input.IsActive == nil || *input.IsActive,
+			CreatedBy:     accountInfo.AccountId,
+		}
+		c.Locals("specialEvent", event)
+		return c.Next()
+	}
+}
+
+// SpecialEvent nạp sự kiện theo id cho GET/PUT/DELETE; manager chỉ được sửa/xóa sự kiện của rạp mình
+func SpecialEvent(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil || valueKey <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		accountInfo, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && !isManager {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var event model.SpecialEvent
+		if err := database.DB.First(&event, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Sự kiện không tồn tại", err, key)
+		}
+		if c.Method() != fiber.MethodGet && isManager && (accountInfo.CinemaId == nil || event.CinemaId == nil || *event.CinemaId != *accountInfo.CinemaId) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Bạn chỉ được sửa sự kiện của rạp mình", nil)
+		}
+
+		if c.Method() == fiber.MethodPut {
+			var input model.UpdateSpecialEventInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			if input.Name != nil {
+				event.Name = *input.Name
+			}
+			if input.Description != nil {
+				event.Description = *input.Description
+			}
+			if input.Type != nil {
+				event.Type = *input.Type
+			}
+			if input.StartDate != nil {
+				event.StartDate, _ = time.Parse("2006-01-02", *input.StartDate)
+			}
+			if input.EndDate != nil {
+				event.EndDate, _ = time.Parse("2006-01-02", *input.EndDate)
+			}
+			if input.ChainId != nil {
+				event.ChainId = input.ChainId
+			}
+			if input.CinemaId != nil {
+				event.CinemaId = input.CinemaId
+			}
+			if input.MovieId != nil {
+				event.MovieId = input.MovieId
+			}
+			if input.PricePercent != nil {
+				event.PricePercent = *input.PricePercent
+			}
+			if input.PriceAmount != nil {
+				event.PriceAmount = *input.PriceAmount
+			}
+			if input.NonRefundable != nil {
+				event.NonRefundable = *input.NonRefundable
+			}
+			if input.Tags != nil {
+				event.Tags = input.Tags
+			}
+			if input.IsActive != nil {
+				event.IsActive = *input.IsActive
+			}
+			if event.EndDate.Before(event.StartDate) {
+				return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Ngày kết thúc phải sau ngày bắt đầu", nil, "endDate")
+			}
+			if status, msg, err := checkSpecialEventScope(event.ChainId, event.CinemaId, event.MovieId, accountInfo, isManager); msg != "" {
+				return utils.ErrorResponse(c, status, msg, err)
+			}
+		}
+		c.Locals("specialEvent", event)
+		return c.Next()
+	}
+}
@@ -0,0 +1,115 @@
+package validate
+
+import (
+	"cinema_manager/constants"
+	"cinema_manager/database"
+	"cinema_manager/helper"
+	"cinema_manager/model"
+	"cinema_manager/utils"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func CreateTicketCategory() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, isAdmin, _, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Chỉ admin được phép", nil)
+		}
+		var input model.CreateTicketCategoryInput
+		if err := c.BodyParser(&input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+		}
+		if err := validate.Struct(input); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+		}
+		input.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+		if input.Code == model.TicketCategoryAdult {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "ADULT là loại vé thường, không cần tạo", nil, "code")
+		}
+		var count int64
+		database.DB.Model(&model.TicketCategory{}).Where("code = ?", input.Code).Count(&count)
+		if count > 0 {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusBadRequest, "Mã loại vé đã tồn tại", nil, "code")
+		}
+		c.Locals("ticketCategory", model.TicketCategory{
+			Code:            input.Code,
+			Name:            input.Name,
+			DiscountPercent: input.DiscountPercent,
+			FixedPrice:      input.FixedPrice,
+			AgeRatings:      strings.Join(input.AgeRatings, ","),
+			RequiresIdCheck: input.RequiresIdCheck,
+			Description:     input.Description,
+			SortOrder:       input.SortOrder,
+			IsActive:        true,
+		})
+		return c.Next()
+	}
+}
+
+// TicketCategory nạp loại vé theo id cho GET/PUT/DELETE; chỉ admin được sửa/xóa
+func TicketCategory(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		valueKey, err := strconv.Atoi(c.Params(key))
+		if err != nil || valueKey <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, constants.DATA_INPUT_IS_NOT_NUMBER, errors.New("params invalid"))
+		}
+		_, isAdmin, isManager, _, _ := helper.GetInfoAccountFromToken(c)
+		if !isAdmin && (c.Method() != fiber.MethodGet || !isManager) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, constants.NOT_ADMIN, errors.New("bạn không có thẩm quyền "))
+		}
+		var category model.TicketCategory
+		if err := database.DB.First(&category, valueKey).Error; err != nil {
+			return utils.ErrorResponseHaveKey(c, fiber.StatusNotFound, "Loại vé không tồn tại", err, key)
+		}
+
+		switch c.Method() {
+		case fiber.MethodPut:
+			var input model.UpdateTicketCategoryInput
+			if err := c.BodyParser(&input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Dữ liệu không hợp lệ", err)
+			}
+			if err := validate.Struct(input); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err)
+			}
+			if input.Name != nil {
+				category.Name = *input.Name
+			}
+			if input.DiscountPercent != nil {
+				category.DiscountPercent = *input.DiscountPercent
+			}
+			if input.FixedPrice != nil {
+				category.FixedPrice = input.FixedPrice
+			}
+			if input.ClearFixedPrice {
+				category.FixedPrice = nil
+			}
+			if input.AgeRatings != nil {
+				category.AgeRatings = strings.Join(*input.AgeRatings, ",")
+			}
+			if input.RequiresIdCheck != nil {
+				category.RequiresIdCheck = *input.RequiresIdCheck
+			}
+			if input.Description != nil {
+				category.Description = *input.Description
+			}
+			if input.SortOrder != nil {
+				category.SortOrder = *input.SortOrder
+			}
+			if input.IsActive != nil {
+				category.IsActive = *input.IsActive
+			}
+		case fiber.MethodDelete:
+			var count int64
+			database.DB.Model(&model.Ticket{}).Where("category_code = ?", category.Code).Count(&count)
+			if count > 0 {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, "Loại vé đã được bán, hãy chuyển sang ngừng áp dụng", nil)
+			}
+		}
+		c.Locals("ticketCategory", category)
+		return c.Next()
+	}
+}